@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Token types, so a refresh token cannot be used as an access token
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+var (
+	// ErrInvalidToken is returned for malformed tokens and bad signatures
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrExpiredToken is returned for correctly signed tokens past their expiry
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims is the payload of the tokens issued by the server
+type Claims struct {
+	Subject   string `json:"sub"` // user ID
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// header is the only JWT header the server issues or accepts
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign encodes claims as an HS256 JSON Web Token
+func Sign(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signature(unsigned, secret), nil
+}
+
+// Parse verifies a token's signature and expiry and returns its claims
+func Parse(token string, secret []byte, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return Claims{}, ErrInvalidToken
+	}
+
+	expected := signature(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+func signature(unsigned string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"quiz-platform/models"
+	"quiz-platform/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userKey is the gin context key holding the signed-in user
+const userKey = "user"
+
+// Authenticate resolves the user of a bearer access token. Requests without
+// an Authorization header continue anonymously; requests with an invalid or
+// expired token are rejected.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be a Bearer token"})
+			return
+		}
+
+		userID, err := ParseToken(token, TypeAccess, time.Now())
+		if err == ErrExpiredToken {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Access token has expired"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, err := repository.Users.Get(ctx, userID)
+		if err == repository.ErrNotFound {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user: " + err.Error()})
+			return
+		}
+
+		c.Set(userKey, user)
+		c.Next()
+	}
+}
+
+// RequireUser rejects requests that are not signed in. It must run after Authenticate.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentUser(c); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests from users without one of the given roles. It
+// must run after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+			return
+		}
+		if !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your role does not allow this action"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the signed-in user of a request
+func CurrentUser(c *gin.Context) (models.User, bool) {
+	value, ok := c.Get(userKey)
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
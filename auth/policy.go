@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"quiz-platform/models"
+)
+
+// CanAuthor reports whether a user may create tryouts
+func CanAuthor(user models.User) bool {
+	return user.Role == models.RoleAdmin || user.Role == models.RoleAuthor
+}
+
+// CanManageTryout reports whether a user may edit or delete a tryout and its
+// questions. Authors may only manage the tryouts they own.
+func CanManageTryout(user models.User, tryout models.Tryout) bool {
+	if user.Role == models.RoleAdmin {
+		return true
+	}
+	return user.Role == models.RoleAuthor && tryout.OwnerID == user.ID
+}
+
+// CanViewTryout reports whether a user may see a tryout. Drafts are only
+// visible to those who manage them.
+func CanViewTryout(user models.User, tryout models.Tryout) bool {
+	return tryout.Status != models.TryoutDraft || CanManageTryout(user, tryout)
+}
+
+// CanGradeTryout reports whether a user may see and grade every submission of a tryout
+func CanGradeTryout(user models.User, tryout models.Tryout) bool {
+	return user.Role == models.RoleGrader || CanManageTryout(user, tryout)
+}
+
+// CanViewSubmission reports whether a user may see a submission: its taker
+// and anyone who grades the tryout
+func CanViewSubmission(user models.User, tryout models.Tryout, submission models.Submission) bool {
+	return submission.UserID == user.ID || CanGradeTryout(user, tryout)
+}
+
+// CanViewAttempt reports whether a user may see an attempt
+func CanViewAttempt(user models.User, tryout models.Tryout, attempt models.Attempt) bool {
+	return attempt.UserID == user.ID || CanGradeTryout(user, tryout)
+}
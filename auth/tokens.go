@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"time"
+
+	"quiz-platform/config"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IssueTokens creates a new access and refresh token for a user
+func IssueTokens(userID primitive.ObjectID, now time.Time) (models.TokenPair, error) {
+	accessTTL := config.AccessTokenTTL()
+	access, err := Sign(Claims{
+		Subject:   userID.Hex(),
+		Type:      TypeAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(accessTTL).Unix(),
+	}, config.JWTSecret())
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refresh, err := Sign(Claims{
+		Subject:   userID.Hex(),
+		Type:      TypeRefresh,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(config.RefreshTokenTTL()).Unix(),
+	}, config.JWTSecret())
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL.Seconds()),
+	}, nil
+}
+
+// ParseToken verifies a token of the given type and returns the ID of its user
+func ParseToken(token, tokenType string, now time.Time) (primitive.ObjectID, error) {
+	claims, err := Parse(token, config.JWTSecret(), now)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if claims.Type != tokenType {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return userID, nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultGracePeriod is how long after the deadline answers are still accepted
+const defaultGracePeriod = 30 * time.Second
+
+// AttemptGracePeriod returns the grace period applied to attempt deadlines,
+// read from ATTEMPT_GRACE_PERIOD_SECONDS
+func AttemptGracePeriod() time.Duration {
+	value := os.Getenv("ATTEMPT_GRACE_PERIOD_SECONDS")
+	if value == "" {
+		return defaultGracePeriod
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		log.Printf("Invalid ATTEMPT_GRACE_PERIOD_SECONDS %q, using default of %s", value, defaultGracePeriod)
+		return defaultGracePeriod
+	}
+	return time.Duration(seconds) * time.Second
+}
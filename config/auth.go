@@ -0,0 +1,64 @@
+package config
+
+import (
+	"crypto/rand"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default token lifetimes
+const (
+	defaultAccessTokenMinutes = 15
+	defaultRefreshTokenHours  = 7 * 24
+)
+
+var (
+	jwtSecret     []byte
+	jwtSecretOnce sync.Once
+)
+
+// JWTSecret returns the key used to sign tokens, read from JWT_SECRET. Without
+// one a random key is generated, so tokens stop working when the server restarts.
+func JWTSecret() []byte {
+	jwtSecretOnce.Do(func() {
+		if secret := os.Getenv("JWT_SECRET"); secret != "" {
+			jwtSecret = []byte(secret)
+			return
+		}
+
+		log.Println("JWT_SECRET not specified, using a random key; tokens will not survive a restart")
+		jwtSecret = make([]byte, 32)
+		if _, err := rand.Read(jwtSecret); err != nil {
+			log.Fatal("Failed to generate JWT secret: ", err)
+		}
+	})
+	return jwtSecret
+}
+
+// AccessTokenTTL returns how long access tokens are valid, read from JWT_ACCESS_TTL_MINUTES
+func AccessTokenTTL() time.Duration {
+	return positiveDuration("JWT_ACCESS_TTL_MINUTES", defaultAccessTokenMinutes, time.Minute)
+}
+
+// RefreshTokenTTL returns how long refresh tokens are valid, read from JWT_REFRESH_TTL_HOURS
+func RefreshTokenTTL() time.Duration {
+	return positiveDuration("JWT_REFRESH_TTL_HOURS", defaultRefreshTokenHours, time.Hour)
+}
+
+// positiveDuration reads a positive number of units from an environment variable
+func positiveDuration(name string, fallback int, unit time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Duration(fallback) * unit
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		log.Printf("Invalid %s %q, using default of %d", name, value, fallback)
+		return time.Duration(fallback) * unit
+	}
+	return time.Duration(parsed) * unit
+}
@@ -7,8 +7,10 @@ import (
 	"os"
 	"time"
 
+	"quiz-platform/models"
+	"quiz-platform/repository"
+
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -70,6 +72,36 @@ func ConnectDB() {
 	fmt.Println("Successfully connected to MongoDB Atlas!")
 }
 
+// StorageDriver returns the configured storage backend from STORAGE_DRIVER,
+// defaulting to MongoDB
+func StorageDriver() string {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		return repository.DriverMongo
+	}
+	return driver
+}
+
+// ConnectStorage sets up the repositories for the configured storage driver
+func ConnectStorage() {
+	switch driver := StorageDriver(); driver {
+	case repository.DriverMemory:
+		fmt.Println("Using in-memory storage, data will be lost on shutdown")
+		repository.UseMemory()
+	case repository.DriverMongo:
+		ConnectDB()
+		repository.UseMongo(DB)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := repository.EnsureIndexes(ctx, DB); err != nil {
+			log.Fatal("Failed to create indexes: ", err)
+		}
+	default:
+		log.Fatalf("Unknown STORAGE_DRIVER %q, expected %q or %q", driver, repository.DriverMongo, repository.DriverMemory)
+	}
+}
+
 // GetCollection returns a MongoDB collection
 func GetCollection(collectionName string) *mongo.Collection {
 	return DB.Collection(collectionName)
@@ -94,394 +126,421 @@ func SeedDummyData() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Get collections
-	tryoutCollection := GetCollection("tryouts")
-	questionCollection := GetCollection("questions")
-
 	// Check if tryout collection is empty
-	count, err := tryoutCollection.CountDocuments(ctx, bson.M{})
+	existing, err := repository.Tryouts.List(ctx, repository.TryoutFilter{}, repository.ListOptions{Limit: 1})
 	if err != nil {
-		log.Printf("Error checking collection count: %v", err)
+		log.Printf("Error checking existing tryouts: %v", err)
 		return
 	}
 
 	// Skip seeding if data already exists
-	if count > 0 {
+	if existing.Total > 0 {
 		fmt.Println("Dummy data already exists, skipping seed.")
 		return
 	}
 
 	fmt.Println("Database is empty. Seeding with dummy data...")
+	seeder := models.User{Name: models.SeedActor}
 
 	// Create dummy tryout data with more realistic details
-	dummyTryouts := []interface{}{
-		bson.M{
-			"title":         "Basic Mathematics Quiz",
-			"description":   "Test your basic math skills with this quiz covering arithmetic, algebra, and geometry concepts suitable for high school students. Topics include equation solving, basic geometry theorems, and number properties.",
-			"category":      "Mathematics",
-			"duration":      30,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-10 * 24 * time.Hour), // 10 days ago
-			"updatedAt":     time.Now().Add(-10 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Advanced Calculus Challenge",
-			"description":   "Challenge yourself with complex calculus problems including limits, derivatives, integrals, and series. This tryout is designed for college-level mathematics students looking to test their understanding of advanced concepts.",
-			"category":      "Mathematics",
-			"duration":      60,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-15 * 24 * time.Hour), // 15 days ago
-			"updatedAt":     time.Now().Add(-15 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "English Grammar Challenge",
-			"description":   "Improve your grammar skills with this comprehensive quiz covering punctuation, sentence structure, verb tenses, and common English usage errors. Perfect for non-native speakers and language enthusiasts alike.",
-			"category":      "Language",
-			"duration":      45,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-8 * 24 * time.Hour), // 8 days ago
-			"updatedAt":     time.Now().Add(-8 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Science Fundamentals",
-			"description":   "Explore basic scientific concepts across physics, chemistry, and biology. Perfect for students preparing for general science tests. This quiz covers scientific method, basic laws of physics, periodic table concepts, and biological systems.",
-			"category":      "Science",
-			"duration":      60,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-5 * 24 * time.Hour), // 5 days ago
-			"updatedAt":     time.Now().Add(-5 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "World History Overview",
-			"description":   "Test your knowledge of major historical events, civilizations, and influential figures throughout world history. From ancient civilizations to modern geopolitics, this comprehensive quiz covers key moments that shaped our world.",
-			"category":      "History",
-			"duration":      40,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-12 * 24 * time.Hour), // 12 days ago
-			"updatedAt":     time.Now().Add(-12 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Computer Science Basics",
-			"description":   "A quiz covering fundamental computer science concepts including algorithms, data structures, and basic programming principles. Ideal for students beginning their journey into computer science or programmers wanting to review core concepts.",
-			"category":      "Computer Science",
-			"duration":      50,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-3 * 24 * time.Hour), // 3 days ago
-			"updatedAt":     time.Now().Add(-3 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Geography Challenge",
-			"description":   "Test your knowledge of world geography, including countries, capitals, major landmarks, and geographical features. This quiz will take you around the globe, from the highest peaks to the deepest oceans.",
-			"category":      "Geography",
-			"duration":      35,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-2 * 24 * time.Hour), // 2 days ago
-			"updatedAt":     time.Now().Add(-2 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Physics Problem Solving",
-			"description":   "Challenge yourself with physics problem-solving scenarios covering mechanics, thermodynamics, and electromagnetism. This advanced quiz requires application of physics principles to solve complex, real-world problems.",
-			"category":      "Science",
-			"duration":      55,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-7 * 24 * time.Hour), // 7 days ago
-			"updatedAt":     time.Now().Add(-7 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Literature Classics Quiz",
-			"description":   "Test your knowledge of classic literature, famous authors, literary movements, and iconic quotes from renowned works. From Shakespeare to Tolstoy, this quiz covers literary masterpieces from around the world.",
-			"category":      "Literature",
-			"duration":      40,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-9 * 24 * time.Hour), // 9 days ago
-			"updatedAt":     time.Now().Add(-9 * 24 * time.Hour),
-		},
-		bson.M{
-			"title":         "Web Development Fundamentals",
-			"description":   "A quiz covering HTML, CSS, JavaScript, and basic web development concepts for beginners. Test your understanding of responsive design, DOM manipulation, and basic front-end development principles.",
-			"category":      "Computer Science",
-			"duration":      45,
-			"hasSubmission": false,
-			"createdAt":     time.Now().Add(-1 * 24 * time.Hour), // 1 day ago
-			"updatedAt":     time.Now().Add(-1 * 24 * time.Hour),
+	dummyTryouts := []models.Tryout{
+		{
+			Title:         "Basic Mathematics Quiz",
+			Description:   "Test your basic math skills with this quiz covering arithmetic, algebra, and geometry concepts suitable for high school students. Topics include equation solving, basic geometry theorems, and number properties.",
+			Category:      "Mathematics",
+			Duration:      30,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-10 * 24 * time.Hour), // 10 days ago
+			UpdatedAt:     time.Now().Add(-10 * 24 * time.Hour),
+		},
+		{
+			Title:         "Advanced Calculus Challenge",
+			Description:   "Challenge yourself with complex calculus problems including limits, derivatives, integrals, and series. This tryout is designed for college-level mathematics students looking to test their understanding of advanced concepts.",
+			Category:      "Mathematics",
+			Duration:      60,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-15 * 24 * time.Hour), // 15 days ago
+			UpdatedAt:     time.Now().Add(-15 * 24 * time.Hour),
+		},
+		{
+			Title:         "English Grammar Challenge",
+			Description:   "Improve your grammar skills with this comprehensive quiz covering punctuation, sentence structure, verb tenses, and common English usage errors. Perfect for non-native speakers and language enthusiasts alike.",
+			Category:      "Language",
+			Duration:      45,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-8 * 24 * time.Hour), // 8 days ago
+			UpdatedAt:     time.Now().Add(-8 * 24 * time.Hour),
+		},
+		{
+			Title:         "Science Fundamentals",
+			Description:   "Explore basic scientific concepts across physics, chemistry, and biology. Perfect for students preparing for general science tests. This quiz covers scientific method, basic laws of physics, periodic table concepts, and biological systems.",
+			Category:      "Science",
+			Duration:      60,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-5 * 24 * time.Hour), // 5 days ago
+			UpdatedAt:     time.Now().Add(-5 * 24 * time.Hour),
+		},
+		{
+			Title:         "World History Overview",
+			Description:   "Test your knowledge of major historical events, civilizations, and influential figures throughout world history. From ancient civilizations to modern geopolitics, this comprehensive quiz covers key moments that shaped our world.",
+			Category:      "History",
+			Duration:      40,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-12 * 24 * time.Hour), // 12 days ago
+			UpdatedAt:     time.Now().Add(-12 * 24 * time.Hour),
+		},
+		{
+			Title:         "Computer Science Basics",
+			Description:   "A quiz covering fundamental computer science concepts including algorithms, data structures, and basic programming principles. Ideal for students beginning their journey into computer science or programmers wanting to review core concepts.",
+			Category:      "Computer Science",
+			Duration:      50,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-3 * 24 * time.Hour), // 3 days ago
+			UpdatedAt:     time.Now().Add(-3 * 24 * time.Hour),
+		},
+		{
+			Title:         "Geography Challenge",
+			Description:   "Test your knowledge of world geography, including countries, capitals, major landmarks, and geographical features. This quiz will take you around the globe, from the highest peaks to the deepest oceans.",
+			Category:      "Geography",
+			Duration:      35,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-2 * 24 * time.Hour), // 2 days ago
+			UpdatedAt:     time.Now().Add(-2 * 24 * time.Hour),
+		},
+		{
+			Title:         "Physics Problem Solving",
+			Description:   "Challenge yourself with physics problem-solving scenarios covering mechanics, thermodynamics, and electromagnetism. This advanced quiz requires application of physics principles to solve complex, real-world problems.",
+			Category:      "Science",
+			Duration:      55,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-7 * 24 * time.Hour), // 7 days ago
+			UpdatedAt:     time.Now().Add(-7 * 24 * time.Hour),
+		},
+		{
+			Title:         "Literature Classics Quiz",
+			Description:   "Test your knowledge of classic literature, famous authors, literary movements, and iconic quotes from renowned works. From Shakespeare to Tolstoy, this quiz covers literary masterpieces from around the world.",
+			Category:      "Literature",
+			Duration:      40,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-9 * 24 * time.Hour), // 9 days ago
+			UpdatedAt:     time.Now().Add(-9 * 24 * time.Hour),
+		},
+		{
+			Title:         "Web Development Fundamentals",
+			Description:   "A quiz covering HTML, CSS, JavaScript, and basic web development concepts for beginners. Test your understanding of responsive design, DOM manipulation, and basic front-end development principles.",
+			Category:      "Computer Science",
+			Duration:      45,
+			HasSubmission: false,
+			Status:        models.TryoutPublished,
+			CreatedAt:     time.Now().Add(-1 * 24 * time.Hour), // 1 day ago
+			UpdatedAt:     time.Now().Add(-1 * 24 * time.Hour),
 		},
 	}
 
 	// Insert tryout data first
-	tryoutResults, err := tryoutCollection.InsertMany(ctx, dummyTryouts)
-	if err != nil {
-		log.Printf("Error seeding tryout data: %v", err)
-		return
+	for i := range dummyTryouts {
+		if err := repository.Tryouts.Create(ctx, &dummyTryouts[i], seeder); err != nil {
+			log.Printf("Error seeding tryout data: %v", err)
+			return
+		}
 	}
-	fmt.Printf("Successfully seeded %d dummy tryouts\n", len(tryoutResults.InsertedIDs))
+	fmt.Printf("Successfully seeded %d dummy tryouts\n", len(dummyTryouts))
 
 	// Now create questions for each tryout
-	var questions []interface{}
+	var questions []models.Question
 
 	// Math questions for "Basic Mathematics Quiz"
-	math1ID := tryoutResults.InsertedIDs[0].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  math1ID,
-			"text":      "The square root of 144 is 12.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math1ID,
-			"text":      "In a right-angled triangle, the square of the hypotenuse equals the sum of the squares of the other two sides.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math1ID,
-			"text":      "The formula for the area of a circle is πr.",
-			"isTrue":    false, // It's πr²
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math1ID,
-			"text":      "The sum of all angles in a triangle is 180 degrees.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math1ID,
-			"text":      "The value of π (pi) is exactly 22/7.",
-			"isTrue":    false, // It's an irrational number, 22/7 is an approximation
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	math1ID := dummyTryouts[0].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  math1ID,
+			Text:      "The square root of 144 is 12.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math1ID,
+			Text:      "In a right-angled triangle, the square of the hypotenuse equals the sum of the squares of the other two sides.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math1ID,
+			Text:      "The formula for the area of a circle is πr.",
+			IsTrue:    false, // It's πr²
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math1ID,
+			Text:      "The sum of all angles in a triangle is 180 degrees.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math1ID,
+			Text:      "The value of π (pi) is exactly 22/7.",
+			IsTrue:    false, // It's an irrational number, 22/7 is an approximation
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// Advanced Calculus questions
-	math2ID := tryoutResults.InsertedIDs[1].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  math2ID,
-			"text":      "The derivative of e^x is e^x.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math2ID,
-			"text":      "The integral of 1/x is ln|x| + C.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math2ID,
-			"text":      "For any continuous function f(x), the derivative of the integral of f(x) from a to x with respect to x is equal to f(x).",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  math2ID,
-			"text":      "L'Hôpital's rule can be applied to any indeterminate form.",
-			"isTrue":    false, // Only applicable to 0/0 and ∞/∞ forms directly
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	math2ID := dummyTryouts[1].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  math2ID,
+			Text:      "The derivative of e^x is e^x.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math2ID,
+			Text:      "The integral of 1/x is ln|x| + C.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math2ID,
+			Text:      "For any continuous function f(x), the derivative of the integral of f(x) from a to x with respect to x is equal to f(x).",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  math2ID,
+			Text:      "L'Hôpital's rule can be applied to any indeterminate form.",
+			IsTrue:    false, // Only applicable to 0/0 and ∞/∞ forms directly
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// English Grammar questions
-	englishID := tryoutResults.InsertedIDs[2].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  englishID,
-			"text":      "In English, the subject always comes before the verb in a sentence.",
-			"isTrue":    false, // Not in questions or certain literary constructions
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  englishID,
-			"text":      "'i' comes before 'e' except after 'c' is a grammar rule that has no exceptions.",
-			"isTrue":    false, // Many exceptions like "weird", "science", "efficient"
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  englishID,
-			"text":      "A semicolon can be used to join two independent clauses.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  englishID,
-			"text":      "The past participle of 'go' is 'went'.",
-			"isTrue":    false, // It's "gone", "went" is past tense
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	englishID := dummyTryouts[2].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  englishID,
+			Text:      "In English, the subject always comes before the verb in a sentence.",
+			IsTrue:    false, // Not in questions or certain literary constructions
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  englishID,
+			Text:      "'i' comes before 'e' except after 'c' is a grammar rule that has no exceptions.",
+			IsTrue:    false, // Many exceptions like "weird", "science", "efficient"
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  englishID,
+			Text:      "A semicolon can be used to join two independent clauses.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  englishID,
+			Text:      "The past participle of 'go' is 'went'.",
+			IsTrue:    false, // It's "gone", "went" is past tense
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// Science questions
-	scienceID := tryoutResults.InsertedIDs[3].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  scienceID,
-			"text":      "Mitochondria are known as the powerhouse of the cell.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  scienceID,
-			"text":      "According to Newton's First Law, an object will remain at rest or in uniform motion unless acted upon by an external force.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  scienceID,
-			"text":      "Water's chemical formula is H2O2.",
-			"isTrue":    false, // It's H2O, H2O2 is hydrogen peroxide
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  scienceID,
-			"text":      "DNA is a double helix structure.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  scienceID,
-			"text":      "Sound travels faster in air than in water.",
-			"isTrue":    false, // Sound travels faster in denser mediums like water
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	scienceID := dummyTryouts[3].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  scienceID,
+			Text:      "Mitochondria are known as the powerhouse of the cell.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  scienceID,
+			Text:      "According to Newton's First Law, an object will remain at rest or in uniform motion unless acted upon by an external force.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  scienceID,
+			Text:      "Water's chemical formula is H2O2.",
+			IsTrue:    false, // It's H2O, H2O2 is hydrogen peroxide
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  scienceID,
+			Text:      "DNA is a double helix structure.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  scienceID,
+			Text:      "Sound travels faster in air than in water.",
+			IsTrue:    false, // Sound travels faster in denser mediums like water
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// History questions
-	historyID := tryoutResults.InsertedIDs[4].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  historyID,
-			"text":      "The American Declaration of Independence was signed in 1776.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  historyID,
-			"text":      "The Berlin Wall fell in 1989.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  historyID,
-			"text":      "World War II ended in 1950.",
-			"isTrue":    false, // It ended in 1945
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  historyID,
-			"text":      "The Ancient Roman Empire was centered around Greece.",
-			"isTrue":    false, // It was centered around Rome, Italy
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	historyID := dummyTryouts[4].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  historyID,
+			Text:      "The American Declaration of Independence was signed in 1776.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  historyID,
+			Text:      "The Berlin Wall fell in 1989.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  historyID,
+			Text:      "World War II ended in 1950.",
+			IsTrue:    false, // It ended in 1945
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  historyID,
+			Text:      "The Ancient Roman Empire was centered around Greece.",
+			IsTrue:    false, // It was centered around Rome, Italy
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// Computer Science questions
-	csID := tryoutResults.InsertedIDs[5].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  csID,
-			"text":      "In binary, the decimal number 10 is represented as 1010.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  csID,
-			"text":      "HTML is a programming language.",
-			"isTrue":    false, // It's a markup language
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  csID,
-			"text":      "An array index typically starts at 1 in most programming languages.",
-			"isTrue":    false, // Most start at 0
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  csID,
-			"text":      "The Big O notation O(n²) represents a quadratic time complexity.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  csID,
-			"text":      "DNS stands for Domain Name System.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	csID := dummyTryouts[5].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  csID,
+			Text:      "In binary, the decimal number 10 is represented as 1010.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  csID,
+			Text:      "HTML is a programming language.",
+			IsTrue:    false, // It's a markup language
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  csID,
+			Text:      "An array index typically starts at 1 in most programming languages.",
+			IsTrue:    false, // Most start at 0
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  csID,
+			Text:      "The Big O notation O(n²) represents a quadratic time complexity.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  csID,
+			Text:      "DNS stands for Domain Name System.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// Web Dev questions
-	webdevID := tryoutResults.InsertedIDs[9].(primitive.ObjectID)
-	questions = append(questions, []interface{}{
-		bson.M{
-			"tryoutId":  webdevID,
-			"text":      "CSS stands for Cascading Style Sheets.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  webdevID,
-			"text":      "JavaScript can directly modify database records without a backend server.",
-			"isTrue":    false, // Client-side JS cannot directly access databases
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  webdevID,
-			"text":      "The box model in CSS consists of margin, border, padding, and content.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  webdevID,
-			"text":      "HTTP status code 404 means 'Server Error'.",
-			"isTrue":    false, // 404 is "Not Found", 500 is "Server Error"
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
-		},
-		bson.M{
-			"tryoutId":  webdevID,
-			"text":      "In responsive design, the 'viewport' meta tag helps to ensure proper display on mobile devices.",
-			"isTrue":    true,
-			"createdAt": time.Now(),
-			"updatedAt": time.Now(),
+	webdevID := dummyTryouts[9].ID
+	questions = append(questions, []models.Question{
+		{
+			TryoutID:  webdevID,
+			Text:      "CSS stands for Cascading Style Sheets.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  webdevID,
+			Text:      "JavaScript can directly modify database records without a backend server.",
+			IsTrue:    false, // Client-side JS cannot directly access databases
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  webdevID,
+			Text:      "The box model in CSS consists of margin, border, padding, and content.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  webdevID,
+			Text:      "HTTP status code 404 means 'Server Error'.",
+			IsTrue:    false, // 404 is "Not Found", 500 is "Server Error"
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		{
+			TryoutID:  webdevID,
+			Text:      "In responsive design, the 'viewport' meta tag helps to ensure proper display on mobile devices.",
+			IsTrue:    true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		},
 	}...)
 
 	// Insert question data
-	questionResult, err := questionCollection.InsertMany(ctx, questions)
-	if err != nil {
-		log.Printf("Error seeding question data: %v", err)
-		return
+	for i := range questions {
+		if err := repository.Questions.Create(ctx, &questions[i], seeder); err != nil {
+			log.Printf("Error seeding question data: %v", err)
+			return
+		}
+	}
+	fmt.Printf("Successfully seeded %d dummy questions\n", len(questions))
+
+	// Published tryouts get their questions as version 1, like tryouts
+	// published through the API
+	for _, tryout := range dummyTryouts {
+		if tryout.Status != models.TryoutPublished {
+			continue
+		}
+		tryoutQuestions, err := repository.Questions.ListByTryout(ctx, tryout.ID)
+		if err != nil {
+			log.Printf("Error fetching seeded questions: %v", err)
+			return
+		}
+		version := models.NewTryoutVersion(tryout, tryoutQuestions, primitive.NilObjectID, tryout.CreatedAt)
+		if err := repository.Versions.Publish(ctx, &version, models.TryoutPublished, seeder); err != nil {
+			log.Printf("Error publishing seeded tryout: %v", err)
+			return
+		}
 	}
-	fmt.Printf("Successfully seeded %d dummy questions\n", len(questionResult.InsertedIDs))
 }
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultTrashRetentionDays is how long deleted items stay restorable
+const defaultTrashRetentionDays = 30
+
+// TrashRetention returns how long deleted tryouts and questions are kept in
+// the trash before being purged, read from TRASH_RETENTION_DAYS
+func TrashRetention() time.Duration {
+	days := defaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Printf("Invalid TRASH_RETENTION_DAYS %q, using default of %d days", value, defaultTrashRetentionDays)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
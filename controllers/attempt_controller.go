@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/config"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StartAttempt starts a timed attempt on the tryout's current version, whose
+// deadline is computed from the version's duration. The attempt keeps the
+// tryout's shuffle settings and a seed for its own order.
+func StartAttempt(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutID := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(tryoutID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	var input models.AttemptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	tryout, err := repository.Tryouts.Get(ctx, objectID)
+	if err == nil && !auth.CanViewTryout(user, tryout) {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return
+	}
+	if tryout.Status != models.TryoutPublished {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only published tryouts can be attempted"})
+		return
+	}
+
+	now := time.Now()
+	switch tryout.Window(now) {
+	case models.WindowUpcoming:
+		c.JSON(http.StatusConflict, gin.H{"error": "Tryout opens at " + tryout.OpensAt.Format(time.RFC3339)})
+		return
+	case models.WindowClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": "Tryout closed at " + tryout.ClosesAt.Format(time.RFC3339)})
+		return
+	}
+
+	takerName := strings.TrimSpace(input.TakerName)
+	if takerName == "" {
+		takerName = user.Name
+	}
+
+	// Tryouts published before versions existed are taken as they are
+	duration := tryout.Duration
+	if tryout.Version > 0 {
+		version, err := repository.Versions.Get(ctx, objectID, tryout.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version: " + err.Error()})
+			return
+		}
+		duration = version.Duration
+	}
+
+	// Attempts end when the window closes, even if their duration has not run out
+	deadline := now.Add(time.Duration(duration) * time.Minute)
+	if tryout.ClosesAt != nil && tryout.ClosesAt.Before(deadline) {
+		deadline = *tryout.ClosesAt
+	}
+
+	attempt := models.Attempt{
+		TryoutID:  objectID,
+		UserID:    user.ID,
+		TakerName: takerName,
+		Version:   tryout.Version,
+		Status:    models.AttemptInProgress,
+		StartedAt: now,
+		Deadline:  deadline,
+		Shuffle:   tryout.Shuffle,
+		Seed:      models.NewSeed(),
+	}
+
+	if err := repository.Attempts.Create(ctx, &attempt); err != nil {
+		log.Printf("Error starting attempt: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start attempt: " + err.Error()})
+		return
+	}
+
+	attempt.RemainingSeconds = int64(attempt.Remaining(now).Seconds())
+	c.JSON(http.StatusCreated, attempt)
+}
+
+// GetAttempt returns an attempt with its remaining time, closing it if the deadline has passed
+func GetAttempt(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	attempt, ok := viewAttempt(ctx, c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if attempt.Status == models.AttemptInProgress && isPastGracePeriod(attempt, now) {
+		if err := expireAttempt(ctx, &attempt); err != nil {
+			log.Printf("Error expiring attempt %s: %v", attempt.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close attempt: " + err.Error()})
+			return
+		}
+	}
+
+	attempt.RemainingSeconds = int64(attempt.Remaining(now).Seconds())
+	c.JSON(http.StatusOK, attempt)
+}
+
+// GetAttemptQuestions returns the questions of an attempt's version in the
+// order the attempt shows them, which stays the same across reloads
+func GetAttemptQuestions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	attempt, ok := viewAttempt(ctx, c)
+	if !ok {
+		return
+	}
+
+	questions, err := takerQuestions(ctx, attempt.TryoutID, attempt.Version)
+	if err != nil {
+		log.Printf("Error fetching questions for attempt %s: %v", attempt.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt.Arrange(questions))
+}
+
+// viewAttempt loads the attempt named by the route if the signed-in user may
+// view it. It responds with an error and returns false otherwise.
+func viewAttempt(ctx context.Context, c *gin.Context) (models.Attempt, bool) {
+	tryoutObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return models.Attempt{}, false
+	}
+
+	attemptID := c.Param("attemptId")
+	attemptObjectID, err := primitive.ObjectIDFromHex(attemptID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID format"})
+		return models.Attempt{}, false
+	}
+
+	attempt, err := findAttempt(ctx, tryoutObjectID, attemptObjectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+			return attempt, false
+		}
+		log.Printf("Error fetching attempt %s: %v", attemptID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempt: " + err.Error()})
+		return attempt, false
+	}
+
+	tryout, err := repository.Tryouts.Get(ctx, tryoutObjectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return attempt, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return attempt, false
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanViewAttempt(user, tryout, attempt) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own attempts"})
+		return attempt, false
+	}
+	return attempt, true
+}
+
+// findAttempt loads an attempt belonging to the given tryout
+func findAttempt(ctx context.Context, tryoutID, attemptID primitive.ObjectID) (models.Attempt, error) {
+	attempt, err := repository.Attempts.Get(ctx, attemptID)
+	if err == nil && attempt.TryoutID != tryoutID {
+		return models.Attempt{}, repository.ErrNotFound
+	}
+	return attempt, err
+}
+
+// isPastGracePeriod reports whether answers for the attempt can no longer be accepted
+func isPastGracePeriod(attempt models.Attempt, now time.Time) bool {
+	return now.After(attempt.Deadline.Add(config.AttemptGracePeriod()))
+}
+
+// expireAttempt closes an in-progress attempt whose deadline has passed
+func expireAttempt(ctx context.Context, attempt *models.Attempt) error {
+	if err := repository.Attempts.Expire(ctx, attempt.ID); err != nil {
+		return err
+	}
+	attempt.Status = models.AttemptExpired
+	return nil
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTryoutHistory returns the audit log of a tryout and its questions, newest
+// first, optionally narrowed down to one entityId or action
+func GetTryoutHistory(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	var filter repository.AuditFilter
+	if value := c.Query("entityId"); value != "" {
+		entityID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entityId format"})
+			return
+		}
+		filter.EntityID = &entityID
+	}
+	if filter.Action = c.Query("action"); filter.Action != "" && !models.IsValidAction(filter.Action) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be created, updated, deleted, restored, published or archived"})
+		return
+	}
+
+	if _, ok := authorizeTryout(ctx, c, objectID); !ok {
+		return
+	}
+
+	entries, err := repository.Audit.ListByTryout(ctx, objectID, filter)
+	if err != nil {
+		log.Printf("Error fetching history of tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash is compared against when an email is unknown, so failed
+// logins take as long whether or not the account exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// Register creates an account and signs it in
+func Register(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var input models.RegisterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	user := models.User{
+		Email:        models.NormalizeEmail(input.Email),
+		Name:         strings.TrimSpace(input.Name),
+		Role:         models.RoleStudent,
+		PasswordHash: string(hash),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := repository.Users.Create(ctx, &user); err != nil {
+		if err == repository.ErrEmailTaken {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email is already registered"})
+			return
+		}
+		log.Printf("Error creating user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user: " + err.Error()})
+		return
+	}
+
+	tokens, err := auth.IssueTokens(user.ID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue tokens: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"user": user, "tokens": tokens})
+}
+
+// Login exchanges an email and password for tokens
+func Login(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var input models.LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	user, err := repository.Users.GetByEmail(ctx, models.NormalizeEmail(input.Email))
+	if err != nil && err != repository.ErrNotFound {
+		log.Printf("Error fetching user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user: " + err.Error()})
+		return
+	}
+
+	found := err == nil
+	hash := dummyPasswordHash
+	if found {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(input.Password)) != nil || !found {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+
+	tokens, err := auth.IssueTokens(user.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue tokens: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "tokens": tokens})
+}
+
+// RefreshTokens exchanges a refresh token for a new pair of tokens
+func RefreshTokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var input models.RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	userID, err := auth.ParseToken(input.RefreshToken, auth.TypeRefresh, now)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token: " + err.Error()})
+		return
+	}
+
+	// Accounts removed since the token was issued cannot refresh
+	if _, err := repository.Users.Get(ctx, userID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user: " + err.Error()})
+		return
+	}
+
+	tokens, err := auth.IssueTokens(userID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue tokens: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// GetCurrentUser returns the signed-in user
+func GetCurrentUser(c *gin.Context) {
+	user, _ := auth.CurrentUser(c)
+	c.JSON(http.StatusOK, user)
+}
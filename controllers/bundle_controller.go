@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ways of handling an imported tryout whose title is already taken
+const (
+	conflictRename = "rename" // append a number to the title
+	conflictFail   = "fail"
+)
+
+// ExportTryout exports a version of a tryout with its questions and answer
+// keys as a bundle that ImportTryout can recreate elsewhere. The working copy
+// is exported unless a version is given.
+func ExportTryout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	version, ok := findVersion(ctx, c, tryout, c.DefaultQuery("version", models.DraftVersion))
+	if !ok {
+		return
+	}
+
+	bundle := models.NewTryoutBundle(version, tryout.Shuffle, time.Now())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "tryout-"+objectID.Hex()+".json"))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportTryout creates a draft tryout owned by the signed-in user from an
+// exported bundle. A title that is already taken gets a number appended,
+// unless onConflict=fail asks for the import to be rejected instead.
+func ImportTryout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	onConflict, ok := importConflict(c)
+	if !ok {
+		return
+	}
+
+	var bundle models.TryoutBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+	if err := bundle.CheckVersion(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported bundle: " + err.Error()})
+		return
+	}
+
+	input := bundle.Tryout.Input()
+	if err := validateTryoutImport(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout: " + err.Error()})
+		return
+	}
+	if len(bundle.Questions) > models.MaxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d questions can be imported at once", models.MaxImportRows)})
+		return
+	}
+
+	inputs := make([]models.QuestionInput, len(bundle.Questions))
+	for i := range bundle.Questions {
+		inputs[i] = bundle.Questions[i].Input()
+	}
+	questions, rowErrors := validateImport(inputs, nil, time.Now())
+	if len(rowErrors) > 0 {
+		rejectImport(c, rowErrors, len(inputs))
+		return
+	}
+
+	createImport(ctx, c, input, questions, onConflict, models.BundleImport{})
+}
+
+// importConflict reads how an import handles a title that is taken
+func importConflict(c *gin.Context) (string, bool) {
+	onConflict := c.DefaultQuery("onConflict", conflictRename)
+	if onConflict != conflictRename && onConflict != conflictFail {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "onConflict must be rename or fail"})
+		return "", false
+	}
+	return onConflict, true
+}
+
+// validateTryoutImport validates an imported tryout like a created one
+func validateTryoutImport(input *models.TryoutInput) error {
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return err
+	}
+	return input.Validate()
+}
+
+// createImport creates an imported tryout with its validated questions as a
+// draft owned by the signed-in user and responds with result filled in. The
+// tryout and its questions are created together or not at all.
+func createImport(ctx context.Context, c *gin.Context, input models.TryoutInput, questions []models.Question, onConflict string, result models.BundleImport) {
+	now := time.Now()
+	user, _ := auth.CurrentUser(c)
+	tryout := models.Tryout{
+		Title:       input.Title,
+		Description: input.Description,
+		Category:    input.Category,
+		Duration:    input.Duration,
+		Shuffle:     input.Shuffle,
+		OwnerID:     user.ID,
+		Status:      models.TryoutDraft,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.Tryouts.CreateWithQuestions(ctx, &tryout, questions, onConflict == conflictRename, user); err != nil {
+		if err == repository.ErrTitleTaken {
+			c.JSON(http.StatusConflict, gin.H{"error": "A tryout titled " + input.Title + " already exists"})
+			return
+		}
+		log.Printf("Error creating imported tryout: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import tryout: " + err.Error()})
+		return
+	}
+	if tryout.Title != input.Title {
+		result.RenamedFrom = input.Title
+	}
+
+	result.Tryout = tryout
+	result.Questions = questions
+	c.JSON(http.StatusCreated, result)
+}
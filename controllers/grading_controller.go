@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetGradingQueue lists the answers of a tryout that are waiting for manual grading,
+// oldest submission first
+func GetGradingQueue(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutID := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(tryoutID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	if _, ok := authorizeGrading(ctx, c, objectID); !ok {
+		return
+	}
+
+	submissions, err := repository.Submissions.ListPending(ctx, objectID)
+	if err != nil {
+		log.Printf("Error fetching pending submissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch grading queue: " + err.Error()})
+		return
+	}
+
+	// Look up question text for the queue entries in the version each
+	// submission was taken against
+	questionText := map[int]map[primitive.ObjectID]string{}
+	queue := []models.GradingQueueItem{}
+	for _, submission := range submissions {
+		texts, ok := questionText[submission.Version]
+		if !ok {
+			questions, err := versionQuestions(ctx, objectID, submission.Version)
+			if err != nil {
+				log.Printf("Error fetching questions: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+				return
+			}
+			texts = make(map[primitive.ObjectID]string, len(questions))
+			for _, question := range questions {
+				texts[question.ID] = question.Text
+			}
+			questionText[submission.Version] = texts
+		}
+
+		for _, answer := range submission.Answers {
+			if answer.Status != models.AnswerPending {
+				continue
+			}
+			queue = append(queue, models.GradingQueueItem{
+				SubmissionID: submission.ID,
+				TakerName:    submission.TakerName,
+				QuestionID:   answer.QuestionID,
+				QuestionText: texts[answer.QuestionID],
+				Text:         answer.Text,
+				MaxPoints:    answer.MaxPoints,
+				SubmittedAt:  submission.SubmittedAt,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GradeAnswer assigns points and feedback to a manually graded answer and
+// rescores the submission
+func GradeAnswer(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	submissionID := c.Param("submissionId")
+	submissionObjectID, err := primitive.ObjectIDFromHex(submissionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID format"})
+		return
+	}
+
+	questionObjectID, err := primitive.ObjectIDFromHex(c.Param("questionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID format"})
+		return
+	}
+
+	var input models.GradeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	if _, ok := authorizeGrading(ctx, c, tryoutObjectID); !ok {
+		return
+	}
+
+	// Grade and rescore atomically so concurrent graders of the same
+	// submission do not overwrite each other
+	submission, err := repository.Submissions.Modify(ctx, submissionObjectID, func(submission *models.Submission) error {
+		if submission.TryoutID != tryoutObjectID {
+			return repository.ErrNotFound
+		}
+
+		index := -1
+		for i, answer := range submission.Answers {
+			if answer.QuestionID == questionObjectID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return gradingError(http.StatusNotFound, "Answer not found")
+		}
+
+		answer := &submission.Answers[index]
+		if answer.Status != models.AnswerPending && answer.Status != models.AnswerGraded {
+			return gradingError(http.StatusBadRequest, "Answer is graded automatically")
+		}
+		if err := answer.ApplyManualGrade(*input.Points, input.Feedback, time.Now()); err != nil {
+			return gradingError(http.StatusBadRequest, "Invalid input data: "+err.Error())
+		}
+		submission.Recalculate()
+		return nil
+	})
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	var rejected *gradingRejection
+	if errors.As(err, &rejected) {
+		c.JSON(rejected.status, gin.H{"error": rejected.message})
+		return
+	}
+	if err != nil {
+		log.Printf("Error grading submission %s: %v", submissionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grade answer: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// authorizeGrading fetches a tryout and checks that the signed-in user may
+// grade it, responding with an error and returning false otherwise
+func authorizeGrading(ctx context.Context, c *gin.Context, tryoutID primitive.ObjectID) (models.Tryout, bool) {
+	tryout, err := repository.Tryouts.Get(ctx, tryoutID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return tryout, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return tryout, false
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanGradeTryout(user, tryout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only graders and the tryout's author can grade it"})
+		return tryout, false
+	}
+	return tryout, true
+}
+
+// gradingRejection aborts a grading change with a client error
+type gradingRejection struct {
+	status  int
+	message string
+}
+
+func gradingError(status int, message string) *gradingRejection {
+	return &gradingRejection{status: status, message: message}
+}
+
+func (e *gradingRejection) Error() string {
+	return e.message
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Formats questions can be imported from and exported to
+const (
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatGIFT  = "gift"
+	formatAiken = "aiken"
+)
+
+// questionParsers read the questions of an import in the text formats
+var questionParsers = map[string]func(io.Reader) ([]models.QuestionInput, []models.ImportError, error){
+	formatCSV:   models.ParseQuestionCSV,
+	formatGIFT:  models.ParseGIFT,
+	formatAiken: models.ParseAiken,
+}
+
+// questionWriters write the questions of an export in the Moodle formats
+var questionWriters = map[string]func(io.Writer, []models.Question) ([]int, error){
+	formatGIFT:  models.WriteGIFT,
+	formatAiken: models.WriteAiken,
+}
+
+// ImportQuestions adds questions to a tryout in bulk from a JSON array of
+// question inputs, CSV, or Moodle's GIFT or Aiken formats. JSON and CSV are
+// told apart by their content type; other formats are named with format.
+// Every row is validated like a single question and nothing is stored unless
+// all of them are valid. With dryRun=true the rows are only validated. Like
+// other edits, the questions go into the tryout's working copy and reach
+// takers once it is published.
+func ImportQuestions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dryRun"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dryRun must be true or false"})
+			return
+		}
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok || refuseLockedQuestions(c, tryout) {
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = formatCSV
+		case "application/json", "":
+			format = formatJSON
+		default:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Send questions as application/json or text/csv, or name their format"})
+			return
+		}
+	}
+
+	var inputs []models.QuestionInput
+	var rowErrors []models.ImportError
+	if format == formatJSON {
+		inputs, rowErrors = []models.QuestionInput{}, []models.ImportError{}
+		err = json.NewDecoder(c.Request.Body).Decode(&inputs)
+	} else if parse, ok := questionParsers[format]; ok {
+		inputs, rowErrors, err = parse(c.Request.Body)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv, gift or aiken"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+	if len(inputs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "There are no questions to import"})
+		return
+	}
+	if len(inputs) > models.MaxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d questions can be imported at once", models.MaxImportRows)})
+		return
+	}
+
+	now := time.Now()
+	questions, rowErrors := validateImport(inputs, rowErrors, now)
+	if len(rowErrors) > 0 {
+		rejectImport(c, rowErrors, len(inputs))
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, models.QuestionImport{DryRun: true, Questions: questions})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if err := repository.Questions.CreateMany(ctx, objectID, questions, user); err != nil {
+		log.Printf("Error importing questions into tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import questions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.QuestionImport{Imported: len(questions), Questions: questions})
+}
+
+// ExportQuestions downloads the questions of a tryout in one of Moodle's
+// formats, gift or aiken. Like ExportTryout it exports the working copy
+// unless a version is given. Questions the format cannot express are left
+// out and listed by number in the X-Skipped-Questions header.
+func ExportQuestions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	format := c.Query("format")
+	write, ok := questionWriters[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be gift or aiken"})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	version, ok := findVersion(ctx, c, tryout, c.DefaultQuery("version", models.DraftVersion))
+	if !ok {
+		return
+	}
+
+	var body bytes.Buffer
+	skipped, err := write(&body, version.Questions)
+	if err != nil {
+		log.Printf("Error exporting questions of tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export questions: " + err.Error()})
+		return
+	}
+
+	setSkippedQuestions(c, skipped)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "tryout-"+objectID.Hex()+"-"+format+".txt"))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body.Bytes())
+}
+
+// setSkippedQuestions lists the numbers of questions an export left out in
+// the X-Skipped-Questions header
+func setSkippedQuestions(c *gin.Context, skipped []int) {
+	if len(skipped) == 0 {
+		return
+	}
+	numbers := make([]string, len(skipped))
+	for i, number := range skipped {
+		numbers[i] = strconv.Itoa(number)
+	}
+	c.Header("X-Skipped-Questions", strings.Join(numbers, ","))
+}
+
+// rejectImport responds that nothing was imported because of the errors in
+// some of the total rows
+func rejectImport(c *gin.Context, rowErrors []models.ImportError, total int) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  fmt.Sprintf("%d of %d questions are invalid, nothing was imported", len(rowErrors), total),
+		"errors": rowErrors,
+	})
+}
+
+// validateImport validates the rows of an import that could be read and
+// builds their questions. It returns the errors of all rows in row order.
+func validateImport(inputs []models.QuestionInput, rowErrors []models.ImportError, now time.Time) ([]models.Question, []models.ImportError) {
+	unreadable := make(map[int]bool, len(rowErrors))
+	for _, rowError := range rowErrors {
+		unreadable[rowError.Row] = true
+	}
+
+	questions := make([]models.Question, 0, len(inputs))
+	for i := range inputs {
+		row, input := i+1, &inputs[i]
+		if unreadable[row] {
+			continue
+		}
+		err := binding.Validator.ValidateStruct(input)
+		if err == nil {
+			err = input.Validate()
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		// Imported questions go after the tryout's last question, in order
+		question := input.ToQuestion()
+		question.Position = 0
+		question.CreatedAt = now
+		question.UpdatedAt = now
+		questions = append(questions, question)
+	}
+
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	return questions, rowErrors
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"quiz-platform/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Print formats
+const (
+	printHTML = "html"
+	printText = "text"
+)
+
+// PrintTryout renders a version of a tryout as a paper exam, or with
+// answerKey=true as its answer key, in HTML or plain text. Like ExportTryout
+// it prints the working copy unless a version is given. variants=N prints N
+// shuffled papers labelled A, B, C and so on instead of one in question
+// order; the same variant always comes out the same.
+func PrintTryout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	format := c.DefaultQuery("format", printHTML)
+	if format != printHTML && format != printText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html or text"})
+		return
+	}
+	answerKey := false
+	if value := c.Query("answerKey"); value != "" {
+		if answerKey, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "answerKey must be true or false"})
+			return
+		}
+	}
+	variants := 0
+	if value := c.Query("variants"); value != "" {
+		if variants, err = strconv.Atoi(value); err != nil || variants < 0 || variants > models.MaxPrintVariants {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("variants must be a number from 0 to %d", models.MaxPrintVariants)})
+			return
+		}
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	version, ok := findVersion(ctx, c, tryout, c.DefaultQuery("version", models.DraftVersion))
+	if !ok {
+		return
+	}
+
+	sheet := models.NewPrintSheet(version, variants, answerKey)
+	var body bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	write := models.WritePrintHTML
+	if format == printText {
+		contentType = "text/plain; charset=utf-8"
+		write = models.WritePrintText
+	}
+	if err := write(&body, sheet); err != nil {
+		log.Printf("Error printing tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to print tryout: " + err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
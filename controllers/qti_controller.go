@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"quiz-platform/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxPackageSize is the largest QTI package an import accepts
+const maxPackageSize = 20 << 20
+
+// ExportTryoutQTI downloads a version of a tryout as an IMS QTI 2.1 package.
+// Like ExportTryout it exports the working copy unless a version is given.
+// Questions QTI cannot express are left out and listed by number in the
+// X-Skipped-Questions header.
+func ExportTryoutQTI(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	version, ok := findVersion(ctx, c, tryout, c.DefaultQuery("version", models.DraftVersion))
+	if !ok {
+		return
+	}
+
+	var body bytes.Buffer
+	skipped, err := models.WriteQTI(&body, models.NewTryoutBundle(version, tryout.Shuffle, time.Now()))
+	if err != nil {
+		log.Printf("Error exporting tryout %s as QTI: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export tryout: " + err.Error()})
+		return
+	}
+
+	setSkippedQuestions(c, skipped)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "tryout-"+objectID.Hex()+"-qti.zip"))
+	c.Data(http.StatusOK, "application/zip", body.Bytes())
+}
+
+// ImportTryoutQTI creates a draft tryout owned by the signed-in user from a
+// QTI 2.1 package sent as the request body. Items with no matching question
+// type are left out and reported under unsupported; the rest are validated
+// like any import, and nothing is created unless all of them are valid. The
+// title, description, category and duration query parameters fill in or
+// replace what the package says of the tryout. Titles that are taken are
+// handled as in ImportTryout.
+func ImportTryoutQTI(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	onConflict, ok := importConflict(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPackageSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the package: " + err.Error()})
+		return
+	}
+	if len(body) > maxPackageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Packages can be at most %d bytes", maxPackageSize)})
+		return
+	}
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The package is not a zip file: " + err.Error()})
+		return
+	}
+	pkg, readErrors, err := models.ReadQTI(archive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid package: " + err.Error()})
+		return
+	}
+
+	for name, field := range map[string]*string{
+		"title":       &pkg.Tryout.Title,
+		"description": &pkg.Tryout.Description,
+		"category":    &pkg.Tryout.Category,
+	} {
+		if value := c.Query(name); value != "" {
+			*field = value
+		}
+	}
+	if value := c.Query("duration"); value != "" {
+		if pkg.Tryout.Duration, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a number of minutes"})
+			return
+		}
+	}
+	input := pkg.Tryout.Input()
+	if err := validateTryoutImport(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout: " + err.Error()})
+		return
+	}
+
+	total := len(pkg.Questions) + len(pkg.Unsupported) + len(readErrors)
+	if len(pkg.Questions) == 0 && len(readErrors) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The package has no supported items", "unsupported": pkg.Unsupported})
+		return
+	}
+	if len(pkg.Questions) > models.MaxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d questions can be imported at once", models.MaxImportRows)})
+		return
+	}
+
+	// Errors are numbered by item, counting the unsupported ones too
+	questions, rowErrors := validateImport(pkg.Questions, nil, time.Now())
+	for i := range rowErrors {
+		rowErrors[i].Row = pkg.Items[rowErrors[i].Row-1]
+	}
+	rowErrors = append(rowErrors, readErrors...)
+	if len(rowErrors) > 0 {
+		sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+		rejectImport(c, rowErrors, total)
+		return
+	}
+
+	createImport(ctx, c, input, questions, onConflict, models.BundleImport{Unsupported: pkg.Unsupported})
+}
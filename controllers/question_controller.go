@@ -4,18 +4,95 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"quiz-platform/config"
+	"quiz-platform/auth"
 	"quiz-platform/models"
+	"quiz-platform/repository"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const questionCollection = "questions"
+// Question views selected with the view query parameter
+const (
+	viewAuthor = "author" // with answer keys and explanations
+	viewTaker  = "taker"  // without anything that gives the answers away
+)
+
+// questionView picks the view of a tryout's questions for the signed-in
+// user and returns it with the tryout. Those who manage or grade the tryout
+// get the author view unless they ask for the taker view; everyone else gets
+// the taker view and is refused the author view. It responds with an error
+// and returns false on failure.
+func questionView(ctx context.Context, c *gin.Context, tryoutID primitive.ObjectID) (string, models.Tryout, bool) {
+	requested := c.Query("view")
+	if requested != "" && requested != viewAuthor && requested != viewTaker {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be author or taker"})
+		return "", models.Tryout{}, false
+	}
+
+	tryout, err := repository.Tryouts.Get(ctx, tryoutID)
+	if err != nil && err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return "", tryout, false
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if err == nil && !auth.CanViewTryout(user, tryout) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+		return "", tryout, false
+	}
+	if requested == viewTaker {
+		return viewTaker, tryout, true
+	}
+	if err == nil && auth.CanGradeTryout(user, tryout) {
+		return viewAuthor, tryout, true
+	}
+	if requested == viewAuthor {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the tryout's author and graders can see the answer keys"})
+		return "", tryout, false
+	}
+	return viewTaker, tryout, true
+}
+
+// takerQuestions returns the questions takers get: those of the tryout's
+// current version, or its working copy if it has never been versioned
+func takerQuestions(ctx context.Context, tryoutID primitive.ObjectID, version int) ([]models.TakerQuestion, error) {
+	if version == 0 {
+		return repository.Questions.ListForTaker(ctx, tryoutID)
+	}
+	return repository.Versions.ListQuestionsForTaker(ctx, tryoutID, version)
+}
+
+// takerQuestion is like takerQuestions for a single question
+func takerQuestion(ctx context.Context, tryoutID primitive.ObjectID, version int, id primitive.ObjectID) (models.TakerQuestion, error) {
+	if version == 0 {
+		return repository.Questions.GetForTaker(ctx, id)
+	}
+	questions, err := repository.Versions.ListQuestionsForTaker(ctx, tryoutID, version)
+	if err != nil {
+		return models.TakerQuestion{}, err
+	}
+	for _, question := range questions {
+		if question.ID == id {
+			return question, nil
+		}
+	}
+	return models.TakerQuestion{}, repository.ErrNotFound
+}
+
+// lockedQuestionsMessage explains why the questions of a tryout cannot change
+const lockedQuestionsMessage = "Takers get the questions of this tryout as they are; publish a version of it before changing them"
+
+// refuseLockedQuestions responds with an error and returns true if the
+// questions of a tryout are locked, see models.Tryout.QuestionsLocked
+func refuseLockedQuestions(c *gin.Context, tryout models.Tryout) bool {
+	if !tryout.QuestionsLocked() {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": lockedQuestionsMessage})
+	return true
+}
 
 // GetQuestionsByTryoutID returns all questions for a specific tryout
 func GetQuestionsByTryoutID(c *gin.Context) {
@@ -29,24 +106,24 @@ func GetQuestionsByTryoutID(c *gin.Context) {
 		return
 	}
 
-	collection := config.GetCollection(questionCollection)
-	findOptions := options.Find()
-	findOptions.SetMaxTime(15 * time.Second)
+	view, tryout, ok := questionView(ctx, c, objectID)
+	if !ok {
+		return
+	}
 
-	cursor, err := collection.Find(ctx, bson.M{"tryoutId": objectID}, findOptions)
+	// Authors edit the working copy, takers answer the published version
+	var questions interface{}
+	if view == viewAuthor {
+		questions, err = repository.Questions.ListByTryout(ctx, objectID)
+	} else {
+		questions, err = takerQuestions(ctx, objectID, tryout.Version)
+	}
 	if err != nil {
 		log.Printf("Error fetching questions: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
 		return
 	}
 
-	var questions []models.Question
-	if err = cursor.All(ctx, &questions); err != nil {
-		log.Printf("Error decoding questions: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode questions: " + err.Error()})
-		return
-	}
-
 	c.JSON(http.StatusOK, questions)
 }
 
@@ -69,22 +146,27 @@ func GetQuestionByID(c *gin.Context) {
 		return
 	}
 
-	collection := config.GetCollection(questionCollection)
-	findOneOptions := options.FindOne()
-	findOneOptions.SetMaxTime(15 * time.Second)
-
-	var question models.Question
-	err = collection.FindOne(
-		ctx,
-		bson.M{
-			"_id":      questionObjectID,
-			"tryoutId": tryoutObjectID,
-		},
-		findOneOptions,
-	).Decode(&question)
+	view, tryout, ok := questionView(ctx, c, tryoutObjectID)
+	if !ok {
+		return
+	}
 
+	var question interface{}
+	var questionTryoutID primitive.ObjectID
+	if view == viewAuthor {
+		var full models.Question
+		full, err = repository.Questions.Get(ctx, questionObjectID)
+		question, questionTryoutID = full, full.TryoutID
+	} else {
+		var taker models.TakerQuestion
+		taker, err = takerQuestion(ctx, tryoutObjectID, tryout.Version, questionObjectID)
+		question, questionTryoutID = taker, taker.TryoutID
+	}
+	if err == nil && questionTryoutID != tryoutObjectID {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 			return
 		}
@@ -108,12 +190,10 @@ func CreateQuestion(c *gin.Context) {
 		return
 	}
 
-	// Check if tryout exists and has no submissions
-	tryoutCollection := config.GetCollection(tryoutCollection)
-	var tryout models.Tryout
-	err = tryoutCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&tryout)
+	// Check if tryout exists
+	tryout, err := repository.Tryouts.Get(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
 			return
 		}
@@ -121,9 +201,12 @@ func CreateQuestion(c *gin.Context) {
 		return
 	}
 
-	// Check if tryout has submissions
-	if tryout.HasSubmission {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot add questions to a tryout that has submissions"})
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanManageTryout(user, tryout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
+		return
+	}
+	if refuseLockedQuestions(c, tryout) {
 		return
 	}
 
@@ -132,27 +215,23 @@ func CreateQuestion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
 		return
 	}
-
-	now := time.Now()
-	newQuestion := models.Question{
-		TryoutID:  objectID,
-		Text:      input.Text,
-		IsTrue:    input.IsTrue,
-		CreatedAt: now,
-		UpdatedAt: now,
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
 	}
 
-	collection := config.GetCollection(questionCollection)
-	insertOptions := options.InsertOne()
-	result, err := collection.InsertOne(ctx, newQuestion, insertOptions)
+	now := time.Now()
+	newQuestion := input.ToQuestion()
+	newQuestion.TryoutID = objectID
+	newQuestion.CreatedAt = now
+	newQuestion.UpdatedAt = now
 
-	if err != nil {
+	if err := repository.Questions.Create(ctx, &newQuestion, user); err != nil {
 		log.Printf("Error creating question: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create question: " + err.Error()})
 		return
 	}
 
-	newQuestion.ID = result.InsertedID.(primitive.ObjectID)
 	c.JSON(http.StatusCreated, newQuestion)
 }
 
@@ -169,11 +248,9 @@ func UpdateQuestion(c *gin.Context) {
 	}
 
 	// Check if question exists and get tryout ID
-	collection := config.GetCollection(questionCollection)
-	var existingQuestion models.Question
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&existingQuestion)
+	existingQuestion, err := repository.Questions.Get(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 			return
 		}
@@ -181,17 +258,19 @@ func UpdateQuestion(c *gin.Context) {
 		return
 	}
 
-	// Check if tryout has submissions
-	tryoutCollection := config.GetCollection(tryoutCollection)
-	var tryout models.Tryout
-	err = tryoutCollection.FindOne(ctx, bson.M{"_id": existingQuestion.TryoutID}).Decode(&tryout)
+	// Check that the user manages the question's tryout
+	tryout, err := repository.Tryouts.Get(ctx, existingQuestion.TryoutID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
 		return
 	}
 
-	if tryout.HasSubmission {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot modify questions of a tryout that has submissions"})
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanManageTryout(user, tryout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
+		return
+	}
+	if refuseLockedQuestions(c, tryout) {
 		return
 	}
 
@@ -200,37 +279,28 @@ func UpdateQuestion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
 		return
 	}
-
-	update := bson.M{
-		"$set": bson.M{
-			"text":      input.Text,
-			"isTrue":    input.IsTrue,
-			"updatedAt": time.Now(),
-		},
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
 	}
 
-	updateOptions := options.Update()
-	result, err := collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		update,
-		updateOptions,
-	)
+	question := input.ToQuestion()
+	question.ID = objectID
+	question.KeepOptionIDs(existingQuestion.Options)
+	question.UpdatedAt = time.Now()
 
-	if err != nil {
+	if err := repository.Questions.Update(ctx, &question, user); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+			return
+		}
 		log.Printf("Error updating question %s: %v", questionID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question: " + err.Error()})
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
-		return
-	}
-
 	// Get updated question
-	var updatedQuestion models.Question
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&updatedQuestion)
+	updatedQuestion, err := repository.Questions.Get(ctx, objectID)
 	if err != nil {
 		log.Printf("Error fetching updated question %s: %v", questionID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Question updated but failed to retrieve updated data: " + err.Error()})
@@ -240,7 +310,50 @@ func UpdateQuestion(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedQuestion)
 }
 
-// DeleteQuestion deletes a question
+// ReorderQuestions sets the order of the questions of a tryout in one go. The
+// body must list every question of the tryout exactly once.
+func ReorderQuestions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok || refuseLockedQuestions(c, tryout) {
+		return
+	}
+
+	var input models.QuestionOrder
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if err := repository.Questions.Reorder(ctx, objectID, input.QuestionIDs, time.Now(), user); err != nil {
+		if err == repository.ErrOrderMismatch {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "questionIds must list every question of the tryout exactly once"})
+			return
+		}
+		log.Printf("Error reordering questions of tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder questions: " + err.Error()})
+		return
+	}
+
+	reordered, err := repository.Questions.ListByTryout(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Questions reordered but failed to retrieve them: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reordered)
+}
+
+// DeleteQuestion moves a question to the trash
 func DeleteQuestion(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
@@ -253,11 +366,9 @@ func DeleteQuestion(c *gin.Context) {
 	}
 
 	// Check if question exists and get tryout ID
-	collection := config.GetCollection(questionCollection)
-	var existingQuestion models.Question
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&existingQuestion)
+	existingQuestion, err := repository.Questions.Get(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 			return
 		}
@@ -265,32 +376,31 @@ func DeleteQuestion(c *gin.Context) {
 		return
 	}
 
-	// Check if tryout has submissions
-	tryoutCollection := config.GetCollection(tryoutCollection)
-	var tryout models.Tryout
-	err = tryoutCollection.FindOne(ctx, bson.M{"_id": existingQuestion.TryoutID}).Decode(&tryout)
+	// Check that the user manages the question's tryout
+	tryout, err := repository.Tryouts.Get(ctx, existingQuestion.TryoutID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
 		return
 	}
 
-	if tryout.HasSubmission {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete questions of a tryout that has submissions"})
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanManageTryout(user, tryout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
 		return
 	}
-
-	deleteOptions := options.Delete()
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID}, deleteOptions)
-	if err != nil {
-		log.Printf("Error deleting question %s: %v", questionID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete question: " + err.Error()})
+	if refuseLockedQuestions(c, tryout) {
 		return
 	}
 
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+	if err := repository.Questions.Delete(ctx, objectID, time.Now(), user); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+			return
+		}
+		log.Printf("Error deleting question %s: %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete question: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Question deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Question moved to trash"})
 }
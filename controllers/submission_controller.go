@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateSubmission grades the answers of an in-progress attempt and records the result
+func CreateSubmission(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutID := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(tryoutID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	var input models.SubmissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	// Check if tryout exists
+	if _, err := repository.Tryouts.Get(ctx, objectID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return
+	}
+
+	// Check that the attempt is still open
+	attemptObjectID, err := primitive.ObjectIDFromHex(input.AttemptID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID format"})
+		return
+	}
+
+	attempt, err := findAttempt(ctx, objectID, attemptObjectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempt: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if attempt.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only submit your own attempts"})
+		return
+	}
+
+	if attempt.Status != models.AttemptInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attempt is already " + attempt.Status})
+		return
+	}
+
+	now := time.Now()
+	if isPastGracePeriod(attempt, now) {
+		if err := expireAttempt(ctx, &attempt); err != nil {
+			log.Printf("Error expiring attempt %s: %v", input.AttemptID, err)
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Attempt deadline has passed"})
+		return
+	}
+
+	// Load the answer key of the version the attempt was started on
+	questions, err := versionQuestions(ctx, objectID, attempt.Version)
+	if err != nil {
+		log.Printf("Error fetching questions for tryout %s: %v", tryoutID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+		return
+	}
+
+	if len(questions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot submit to a tryout without questions"})
+		return
+	}
+
+	if err := resolvePositions(attempt, questions, input.Answers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answers: " + err.Error()})
+		return
+	}
+
+	answers, err := gradeAnswers(questions, input.Answers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answers: " + err.Error()})
+		return
+	}
+
+	submission := models.Submission{
+		TryoutID:    objectID,
+		AttemptID:   attempt.ID,
+		UserID:      attempt.UserID,
+		TakerName:   attempt.TakerName,
+		Version:     attempt.Version,
+		Answers:     answers,
+		IsLate:      now.After(attempt.Deadline),
+		SubmittedAt: now,
+	}
+	submission.Recalculate()
+
+	// Record the submission, close the attempt and lock the tryout's questions atomically
+	err = repository.Submissions.Create(ctx, &submission)
+	if err == repository.ErrAttemptClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attempt is no longer in progress"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error recording submission for tryout %s: %v", tryoutID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record submission: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, submission)
+}
+
+// GetSubmissionsByTryoutID returns all submissions for a specific tryout
+func GetSubmissionsByTryoutID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutID := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(tryoutID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, err := repository.Tryouts.Get(ctx, objectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return
+	}
+
+	// Narrow the results down to one version of the tryout
+	version := -1
+	if value := c.Query("version"); value != "" {
+		if version, err = strconv.Atoi(value); err != nil || version < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a non-negative number"})
+			return
+		}
+	}
+
+	submissions, err := repository.Submissions.ListByTryout(ctx, objectID)
+	if err != nil {
+		log.Printf("Error fetching submissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions: " + err.Error()})
+		return
+	}
+
+	// Takers only see their own submissions
+	user, _ := auth.CurrentUser(c)
+	visible := []models.Submission{}
+	for _, submission := range submissions {
+		if version >= 0 && submission.Version != version {
+			continue
+		}
+		if auth.CanViewSubmission(user, tryout, submission) {
+			visible = append(visible, submission)
+		}
+	}
+
+	c.JSON(http.StatusOK, visible)
+}
+
+// GetSubmissionByID returns a specific submission by its ID
+func GetSubmissionByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryoutID := c.Param("id")
+	tryoutObjectID, err := primitive.ObjectIDFromHex(tryoutID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	submissionID := c.Param("submissionId")
+	submissionObjectID, err := primitive.ObjectIDFromHex(submissionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID format"})
+		return
+	}
+
+	submission, err := repository.Submissions.Get(ctx, submissionObjectID)
+	if err == nil && submission.TryoutID != tryoutObjectID {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+			return
+		}
+		log.Printf("Error fetching submission %s: %v", submissionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submission: " + err.Error()})
+		return
+	}
+
+	tryout, err := repository.Tryouts.Get(ctx, tryoutObjectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanViewSubmission(user, tryout, submission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own submissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// resolvePositions fills in the question IDs of answers given by the position
+// at which the attempt showed the question. questions must be in their
+// canonical order.
+func resolvePositions(attempt models.Attempt, questions []models.Question, inputs []models.AnswerInput) error {
+	order := attempt.QuestionOrder(len(questions))
+	for i := range inputs {
+		input := &inputs[i]
+		if input.Position == 0 {
+			continue
+		}
+		if input.Position > len(order) {
+			return fmt.Errorf("there is no question at position %d", input.Position)
+		}
+
+		questionID := questions[order[input.Position-1]].ID
+		if input.QuestionID != "" {
+			if given, err := primitive.ObjectIDFromHex(input.QuestionID); err == nil && given != questionID {
+				return fmt.Errorf("question %s is not at position %d", input.QuestionID, input.Position)
+			}
+		}
+		input.QuestionID = questionID.Hex()
+	}
+	return nil
+}
+
+// gradeAnswers checks each answer against the question's answer key.
+// Questions left unanswered are recorded with zero points.
+func gradeAnswers(questions []models.Question, inputs []models.AnswerInput) ([]models.Answer, error) {
+	given := make(map[primitive.ObjectID]models.AnswerInput, len(inputs))
+	for _, input := range inputs {
+		questionID, err := primitive.ObjectIDFromHex(input.QuestionID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid question ID format %q", input.QuestionID)
+		}
+		if _, dup := given[questionID]; dup {
+			return nil, fmt.Errorf("question %s answered more than once", input.QuestionID)
+		}
+		given[questionID] = input
+	}
+
+	answers := make([]models.Answer, 0, len(questions))
+	for _, question := range questions {
+		input, ok := given[question.ID]
+		if !ok {
+			answers = append(answers, question.Unanswered())
+			continue
+		}
+		delete(given, question.ID)
+
+		answer, err := question.Grade(input)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+
+	for questionID := range given {
+		return nil, fmt.Errorf("question %s does not belong to this tryout", questionID.Hex())
+	}
+
+	return answers, nil
+}
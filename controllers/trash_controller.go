@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/config"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errNotOwner aborts a restore by a user who may not manage the tryout
+var errNotOwner = errors.New("not the owner of the tryout")
+
+// errQuestionsLocked aborts the restore of a question into a tryout whose
+// questions are locked
+var errQuestionsLocked = errors.New("the questions of the tryout are locked")
+
+// GetTrash lists the deleted tryouts and the questions deleted on their own
+// that the signed-in user may manage
+func GetTrash(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	tryouts, err := repository.Tryouts.ListDeleted(ctx)
+	if err != nil {
+		log.Printf("Error fetching deleted tryouts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash: " + err.Error()})
+		return
+	}
+
+	questions, err := repository.Questions.ListDeleted(ctx)
+	if err != nil {
+		log.Printf("Error fetching deleted questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	visibleTryouts := []models.Tryout{}
+	for _, tryout := range tryouts {
+		if auth.CanManageTryout(user, tryout) {
+			visibleTryouts = append(visibleTryouts, tryout)
+		}
+	}
+
+	// Questions deleted on their own belong to tryouts that are still active
+	manageable := map[primitive.ObjectID]bool{}
+	visibleQuestions := []models.Question{}
+	for _, question := range questions {
+		allowed, checked := manageable[question.TryoutID]
+		if !checked {
+			tryout, err := repository.Tryouts.Get(ctx, question.TryoutID)
+			if err != nil && err != repository.ErrNotFound {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+				return
+			}
+			allowed = err == nil && auth.CanManageTryout(user, tryout)
+			manageable[question.TryoutID] = allowed
+		}
+		if allowed {
+			visibleQuestions = append(visibleQuestions, question)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tryouts":       visibleTryouts,
+		"questions":     visibleQuestions,
+		"retentionDays": int(config.TrashRetention().Hours() / 24),
+	})
+}
+
+// RestoreTryout takes a tryout out of the trash with the questions deleted along with it
+func RestoreTryout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	err = repository.Tryouts.Restore(ctx, objectID, time.Now(), user, func(tryout models.Tryout) error {
+		if !auth.CanManageTryout(user, tryout) {
+			return errNotOwner
+		}
+		return nil
+	})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found in trash"})
+			return
+		}
+		if err == errNotOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
+			return
+		}
+		log.Printf("Error restoring tryout %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore tryout: " + err.Error()})
+		return
+	}
+
+	tryout, err := repository.Tryouts.Get(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restored tryout: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tryout)
+}
+
+// RestoreQuestion takes a question out of the trash
+func RestoreQuestion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID format"})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	err = repository.Questions.Restore(ctx, objectID, time.Now(), user, func(tryout models.Tryout) error {
+		if !auth.CanManageTryout(user, tryout) {
+			return errNotOwner
+		}
+		if tryout.QuestionsLocked() {
+			return errQuestionsLocked
+		}
+		return nil
+	})
+	if err != nil {
+		switch err {
+		case repository.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found in trash"})
+		case errNotOwner:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
+		case errQuestionsLocked:
+			c.JSON(http.StatusBadRequest, gin.H{"error": lockedQuestionsMessage})
+		case repository.ErrTryoutDeleted:
+			c.JSON(http.StatusConflict, gin.H{"error": "The question's tryout is in the trash; restore the tryout instead"})
+		default:
+			log.Printf("Error restoring question %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore question: " + err.Error()})
+		}
+		return
+	}
+
+	question, err := repository.Questions.Get(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restored question: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
@@ -2,49 +2,28 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"quiz-platform/config"
+	"quiz-platform/auth"
 	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const tryoutCollection = "tryouts"
-
-// GetAllTryouts returns all tryouts
+// GetAllTryouts returns a page of all tryouts
 func GetAllTryouts(c *gin.Context) {
 	// Increase timeout for MongoDB Atlas
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	collection := config.GetCollection(tryoutCollection)
-
-	// Set options to handle potential timeout issues
-	findOptions := options.Find()
-	findOptions.SetMaxTime(15 * time.Second)
-
-	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
-
-	if err != nil {
-		log.Printf("Error fetching tryouts: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryouts: " + err.Error()})
-		return
-	}
-
-	var tryouts []models.Tryout
-	if err = cursor.All(ctx, &tryouts); err != nil {
-		log.Printf("Error decoding tryouts: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tryouts: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, tryouts)
+	listTryouts(ctx, c, repository.TryoutFilter{})
 }
 
 // GetTryout returns a specific tryout by ID
@@ -59,17 +38,14 @@ func GetTryout(c *gin.Context) {
 		return
 	}
 
-	var tryout models.Tryout
-	collection := config.GetCollection(tryoutCollection)
-
-	// Set options for FindOne operation
-	findOneOptions := options.FindOne()
-	findOneOptions.SetMaxTime(15 * time.Second)
-
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}, findOneOptions).Decode(&tryout)
-
+	tryout, err := repository.Tryouts.Get(ctx, objectID)
+	if err == nil {
+		if user, _ := auth.CurrentUser(c); !auth.CanViewTryout(user, tryout) {
+			err = repository.ErrNotFound
+		}
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
 			return
 		}
@@ -78,6 +54,8 @@ func GetTryout(c *gin.Context) {
 		return
 	}
 
+	user, _ := auth.CurrentUser(c)
+	hideDraft(user, &tryout)
 	c.JSON(http.StatusOK, tryout)
 }
 
@@ -91,32 +69,41 @@ func CreateTryout(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
 		return
 	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
 
+	user, _ := auth.CurrentUser(c)
 	now := time.Now()
 	newTryout := models.Tryout{
 		Title:       input.Title,
 		Description: input.Description,
 		Category:    input.Category,
 		Duration:    input.Duration,
+		OpensAt:     input.OpensAt,
+		ClosesAt:    input.ClosesAt,
+		Shuffle:     input.Shuffle,
+		OwnerID:     user.ID,
+		Status:      models.TryoutDraft,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
-	collection := config.GetCollection(tryoutCollection)
-	insertOptions := options.InsertOne()
-	result, err := collection.InsertOne(ctx, newTryout, insertOptions)
-
-	if err != nil {
+	if err := repository.Tryouts.Create(ctx, &newTryout, user); err != nil {
 		log.Printf("Error creating tryout: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tryout: " + err.Error()})
 		return
 	}
 
-	newTryout.ID = result.InsertedID.(primitive.ObjectID)
 	c.JSON(http.StatusCreated, newTryout)
 }
 
-// UpdateTryout updates an existing tryout
+// UpdateTryout updates an existing tryout. Once the tryout has a published
+// version, its title, description, category and duration are only changed in
+// its draft, which takers get with the next version like edited questions;
+// the availability window and shuffle settings are not versioned and change
+// right away.
 func UpdateTryout(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
@@ -133,43 +120,39 @@ func UpdateTryout(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
 		return
 	}
-
-	update := bson.M{
-		"$set": bson.M{
-			"title":       input.Title,
-			"description": input.Description,
-			"category":    input.Category,
-			"duration":    input.Duration,
-			"updatedAt":   time.Now(),
-		},
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
 	}
 
-	collection := config.GetCollection(tryoutCollection)
-	updateOptions := options.Update()
-	result, err := collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		update,
-		updateOptions,
-	)
+	if _, ok := authorizeTryout(ctx, c, objectID); !ok {
+		return
+	}
 
+	user, _ := auth.CurrentUser(c)
+	err = repository.Tryouts.Update(ctx, &models.Tryout{
+		ID:          objectID,
+		Title:       input.Title,
+		Description: input.Description,
+		Category:    input.Category,
+		Duration:    input.Duration,
+		OpensAt:     input.OpensAt,
+		ClosesAt:    input.ClosesAt,
+		Shuffle:     input.Shuffle,
+		UpdatedAt:   time.Now(),
+	}, user)
 	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
 		log.Printf("Error updating tryout %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tryout: " + err.Error()})
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
-		return
-	}
-
 	// Get updated tryout
-	findOneOptions := options.FindOne()
-	findOneOptions.SetMaxTime(15 * time.Second)
-
-	var updatedTryout models.Tryout
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}, findOneOptions).Decode(&updatedTryout)
+	updatedTryout, err := repository.Tryouts.Get(ctx, objectID)
 	if err != nil {
 		log.Printf("Error fetching updated tryout %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Tryout updated but failed to retrieve updated data: " + err.Error()})
@@ -179,7 +162,7 @@ func UpdateTryout(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedTryout)
 }
 
-// DeleteTryout deletes a tryout
+// DeleteTryout moves a tryout and its questions to the trash
 func DeleteTryout(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
@@ -191,21 +174,34 @@ func DeleteTryout(c *gin.Context) {
 		return
 	}
 
-	collection := config.GetCollection(tryoutCollection)
-	deleteOptions := options.Delete()
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID}, deleteOptions)
-	if err != nil {
-		log.Printf("Error deleting tryout %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tryout: " + err.Error()})
+	if _, ok := authorizeTryout(ctx, c, objectID); !ok {
 		return
 	}
 
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+	force := false
+	if value := c.Query("force"); value != "" {
+		if force, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "force must be true or false"})
+			return
+		}
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if err := repository.Tryouts.Delete(ctx, objectID, force, time.Now(), user); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return
+		}
+		if err == repository.ErrHasSubmissions {
+			c.JSON(http.StatusConflict, gin.H{"error": "Tryout has submissions; delete it with force=true to delete it anyway"})
+			return
+		}
+		log.Printf("Error deleting tryout %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tryout: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Tryout deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Tryout moved to trash"})
 }
 
 // GetTryoutOptions returns all possible categories for filtering (helper function)
@@ -213,61 +209,38 @@ func GetTryoutOptions(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	collection := config.GetCollection(tryoutCollection)
-
-	// Get unique categories
-	pipeline := []bson.M{
-		{"$group": bson.M{"_id": "$category"}},
-		{"$project": bson.M{"category": "$_id", "_id": 0}},
-	}
-	categoryCursor, err := collection.Aggregate(ctx, pipeline)
+	values, err := repository.Tryouts.Categories(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var categories []bson.M
-	if err = categoryCursor.All(ctx, &categories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	categories := make([]gin.H, 0, len(values))
+	for _, category := range values {
+		categories = append(categories, gin.H{"category": category})
 	}
 
 	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
 
-// FilterTryouts filters tryouts based on query parameters
+// FilterTryouts returns a page of tryouts filtered by query parameters
 func FilterTryouts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	collection := config.GetCollection(tryoutCollection)
-
 	// Build filter based on query parameters
-	filter := bson.M{}
-
-	// Filter by title (case-insensitive regex search)
-	if title := c.Query("title"); title != "" {
-		filter["title"] = bson.M{"$regex": primitive.Regex{Pattern: title, Options: "i"}}
+	filter := repository.TryoutFilter{
+		Title:    c.Query("title"),    // case-insensitive substring match
+		Category: c.Query("category"), // exact match
 	}
 
-	// Filter by category (exact match)
-	if category := c.Query("category"); category != "" {
-		filter["category"] = category
-	}
-
-	// Initialize createdAt filter if needed
-	var createdAtFilter bson.M
-
 	// Filter by date range
 	if startDate := c.Query("startDate"); startDate != "" {
 		startTime, err := time.Parse(time.RFC3339, startDate)
 		if err != nil {
 			log.Printf("Error parsing startDate %s: %v", startDate, err)
 		} else {
-			if createdAtFilter == nil {
-				createdAtFilter = bson.M{}
-			}
-			createdAtFilter["$gte"] = startTime
+			filter.CreatedFrom = &startTime
 		}
 	}
 
@@ -276,36 +249,291 @@ func FilterTryouts(c *gin.Context) {
 		if err != nil {
 			log.Printf("Error parsing endDate %s: %v", endDate, err)
 		} else {
-			if createdAtFilter == nil {
-				createdAtFilter = bson.M{}
-			}
-			createdAtFilter["$lte"] = endTime
+			filter.CreatedTo = &endTime
 		}
 	}
 
-	if createdAtFilter != nil {
-		filter["createdAt"] = createdAtFilter
+	// Filter by availability window
+	switch window := c.Query("window"); window {
+	case "":
+	case models.WindowOpen, models.WindowUpcoming, models.WindowClosed:
+		filter.Window = window
+		filter.WindowAt = time.Now()
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be open, upcoming or closed"})
+		return
 	}
 
 	log.Printf("Applying filter: %+v", filter)
 
-	// Set options for the find operation
-	findOptions := options.Find()
-	findOptions.SetMaxTime(15 * time.Second)
+	listTryouts(ctx, c, filter)
+}
+
+// UpdateTryoutStatus moves a tryout along its lifecycle, from draft to
+// published to archived. Only tryouts with questions can be published.
+func UpdateTryoutStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var input models.StatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+	if !tryout.CanMoveTo(input.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot move a tryout from %s to %s", tryout.Status, input.Status)})
+		return
+	}
+	user, _ := auth.CurrentUser(c)
+
+	// Publishing takes the first version of the tryout
+	if input.Status == models.TryoutPublished {
+		if _, ok := publishVersion(ctx, c, tryout); !ok {
+			return
+		}
+	} else if err := repository.Tryouts.SetStatus(ctx, objectID, tryout.Status, input.Status, time.Now(), user); err != nil {
+		switch err {
+		case repository.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+		case repository.ErrStatusChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": "Tryout status was changed by another request, try again"})
+		default:
+			log.Printf("Error updating tryout status %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tryout status: " + err.Error()})
+		}
+		return
+	}
+
+	updatedTryout, err := repository.Tryouts.Get(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Status updated but failed to retrieve updated data: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTryout)
+}
 
-	cursor, err := collection.Find(ctx, filter, findOptions)
+// authorizeTryout fetches a tryout and checks that the signed-in user may
+// manage it, responding with an error and returning false otherwise
+func authorizeTryout(ctx context.Context, c *gin.Context, id primitive.ObjectID) (models.Tryout, bool) {
+	tryout, err := repository.Tryouts.Get(ctx, id)
 	if err != nil {
-		log.Printf("Error filtering tryouts: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to filter tryouts: " + err.Error()})
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+			return tryout, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryout: " + err.Error()})
+		return tryout, false
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if !auth.CanManageTryout(user, tryout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own tryouts"})
+		return tryout, false
+	}
+	return tryout, true
+}
+
+// hideDraft leaves out the unpublished details of a tryout for users who
+// cannot see its working copy
+func hideDraft(user models.User, tryout *models.Tryout) {
+	if !auth.CanGradeTryout(user, *tryout) {
+		tryout.Draft = nil
+	}
+}
+
+// restrictFilter narrows a tryout filter by the status query parameter and to
+// the tryouts the signed-in user may see: admins see every tryout, everyone
+// else published tryouts and their own
+func restrictFilter(c *gin.Context, filter *repository.TryoutFilter) error {
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			if !models.IsValidTryoutStatus(status) {
+				return errors.New("status must be draft, published or archived, or a comma-separated list of them")
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if user, _ := auth.CurrentUser(c); user.Role != models.RoleAdmin {
+		filter.VisibleTo = &user.ID
+	}
+	return nil
+}
+
+// SearchTryouts ranks tryouts by how well their title, description and
+// question text match the q query parameter
+func SearchTryouts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	query := c.Query("q")
+	if len(query) > models.MaxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("q must be at most %d characters", models.MaxSearchQueryLength)})
+		return
+	}
+	terms := models.SearchTerms(query)
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must contain at least one searchable word"})
 		return
 	}
 
-	var tryouts []models.Tryout
-	if err = cursor.All(ctx, &tryouts); err != nil {
-		log.Printf("Error decoding tryouts: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tryouts: " + err.Error()})
+	limit := models.DefaultPageSize
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > models.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", models.MaxPageSize)})
+			return
+		}
+	}
+
+	var filter repository.TryoutFilter
+	if err := restrictFilter(c, &filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, tryouts)
+	hits, err := repository.Tryouts.Search(ctx, terms, filter, int64(limit))
+	if err != nil {
+		log.Printf("Error searching tryouts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search tryouts: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	results := make([]models.SearchResult, len(hits))
+	for i, hit := range hits {
+		hideDraft(user, &hit.Tryout)
+		results[i] = searchResult(hit, terms)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"terms": terms, "results": results})
+}
+
+// searchResult highlights the fields of a search hit that match the terms
+func searchResult(hit repository.SearchHit, terms []string) models.SearchResult {
+	result := models.SearchResult{Tryout: hit.Tryout, Score: hit.Score, Highlights: []models.Highlight{}}
+
+	if snippet, ok := models.HighlightSnippet(hit.Tryout.Title, terms); ok {
+		result.Highlights = append(result.Highlights, models.Highlight{Field: models.HighlightTitle, Snippet: snippet})
+	}
+	if snippet, ok := models.HighlightSnippet(hit.Tryout.Description, terms); ok {
+		result.Highlights = append(result.Highlights, models.Highlight{Field: models.HighlightDescription, Snippet: snippet})
+	}
+	for _, question := range hit.Questions {
+		if snippet, ok := models.HighlightSnippet(question.Text, terms); ok {
+			questionID := question.ID
+			result.Highlights = append(result.Highlights, models.Highlight{
+				Field:      models.HighlightQuestion,
+				QuestionID: &questionID,
+				Snippet:    snippet,
+			})
+		}
+	}
+	return result
+}
+
+// listTryouts responds with the page of visible tryouts selected by the page,
+// pageSize, after, sort and status query parameters
+func listTryouts(ctx context.Context, c *gin.Context, filter repository.TryoutFilter) {
+	opts, page, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := restrictFilter(c, &filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := repository.Tryouts.List(ctx, filter, opts)
+	if err == repository.ErrInvalidCursor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor: " + err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching tryouts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tryouts: " + err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	for i := range result.Tryouts {
+		hideDraft(user, &result.Tryouts[i])
+	}
+
+	list := models.TryoutList{
+		Items:    result.Tryouts,
+		Total:    result.Total,
+		Page:     page,
+		PageSize: int(opts.Limit),
+	}
+	if result.HasMore {
+		list.NextCursor = result.Tryouts[len(result.Tryouts)-1].ID.Hex()
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// parseListOptions reads pagination and sorting from the query string. Pages
+// are selected either by number (page, pageSize) or by cursor (after); the
+// returned page number is 0 for cursor-based requests.
+func parseListOptions(c *gin.Context) (repository.ListOptions, int, error) {
+	opts := repository.ListOptions{Limit: models.DefaultPageSize}
+
+	if value := c.Query("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 || pageSize > models.MaxPageSize {
+			return opts, 0, fmt.Errorf("pageSize must be between 1 and %d", models.MaxPageSize)
+		}
+		opts.Limit = int64(pageSize)
+	}
+
+	if sortParam := c.Query("sort"); sortParam != "" {
+		field := strings.TrimPrefix(sortParam, "-")
+		switch field {
+		case repository.SortByCreatedAt, repository.SortByTitle, repository.SortByDuration:
+		default:
+			return opts, 0, fmt.Errorf("sort must be one of createdAt, title or duration, optionally prefixed with '-'")
+		}
+		opts.SortField = field
+		opts.Descending = strings.HasPrefix(sortParam, "-")
+	}
+
+	after := c.Query("after")
+	pageParam := c.Query("page")
+	if after != "" && pageParam != "" {
+		return opts, 0, errors.New("use either page or after, not both")
+	}
+
+	if after != "" {
+		cursor, err := primitive.ObjectIDFromHex(after)
+		if err != nil {
+			return opts, 0, errors.New("after must be a tryout ID")
+		}
+		opts.After = &cursor
+		return opts, 0, nil
+	}
+
+	page := 1
+	if pageParam != "" {
+		var err error
+		if page, err = strconv.Atoi(pageParam); err != nil || page < 1 {
+			return opts, 0, errors.New("page must be a positive integer")
+		}
+	}
+	opts.Skip = int64(page-1) * opts.Limit
+	return opts, page, nil
 }
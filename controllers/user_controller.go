@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UpdateUserRole changes the role of an account
+func UpdateUserRole(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+		return
+	}
+
+	// Admins cannot demote themselves, so at least one admin always remains
+	if current, _ := auth.CurrentUser(c); current.ID == objectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	if err := repository.Users.SetRole(ctx, objectID, input.Role, time.Now()); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error updating user role: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role: " + err.Error()})
+		return
+	}
+
+	user, err := repository.Users.Get(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated user: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"quiz-platform/auth"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetVersions lists the published versions of a tryout with the number of
+// submissions taken against each
+func GetVersions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeGrading(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	versions, err := repository.Versions.List(ctx, objectID)
+	if err != nil {
+		log.Printf("Error fetching versions of tryout %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch versions: " + err.Error()})
+		return
+	}
+
+	submissions, err := repository.Submissions.ListByTryout(ctx, objectID)
+	if err != nil {
+		log.Printf("Error fetching submissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions: " + err.Error()})
+		return
+	}
+	counts := map[int]int{}
+	for _, submission := range submissions {
+		counts[submission.Version]++
+	}
+	for i := range versions {
+		versions[i].Submissions = counts[versions[i].Number]
+	}
+
+	history := models.VersionHistory{Current: tryout.Version, Versions: versions}
+
+	// Compare the working copy with the current version, or with nothing if
+	// the tryout has never been published
+	current := models.TryoutVersion{}
+	if tryout.Version > 0 {
+		if current, err = repository.Versions.Get(ctx, objectID, tryout.Version); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version: " + err.Error()})
+			return
+		}
+	}
+	draft, err := draftVersion(ctx, tryout)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+		return
+	}
+	diff, err := models.DiffVersions(current, draft)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare versions: " + err.Error()})
+		return
+	}
+	history.HasDraftChanges = !diff.IsEmpty()
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetVersion returns a published version of a tryout with its questions, or
+// the working copy for the version draft
+func GetVersion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeGrading(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	version, ok := findVersion(ctx, c, tryout, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// DiffVersions compares two versions of a tryout. from defaults to the
+// current version and to defaults to the working copy, so without parameters
+// it shows the changes waiting to be published.
+func DiffVersions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeGrading(ctx, c, objectID)
+	if !ok {
+		return
+	}
+
+	from := c.DefaultQuery("from", strconv.Itoa(tryout.Version))
+	to := c.DefaultQuery("to", models.DraftVersion)
+
+	// A tryout that has never been published is compared with nothing
+	fromVersion := models.TryoutVersion{}
+	if from != "0" {
+		if fromVersion, ok = findVersion(ctx, c, tryout, from); !ok {
+			return
+		}
+	}
+	toVersion, ok := findVersion(ctx, c, tryout, to)
+	if !ok {
+		return
+	}
+
+	diff, err := models.DiffVersions(fromVersion, toVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare versions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// PublishVersion publishes the working copy of a draft or published tryout as
+// its next version. Attempts already started keep the version they started on.
+func PublishVersion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tryout ID format"})
+		return
+	}
+
+	tryout, ok := authorizeTryout(ctx, c, objectID)
+	if !ok {
+		return
+	}
+	if tryout.Status == models.TryoutArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Archived tryouts cannot be published"})
+		return
+	}
+
+	version, ok := publishVersion(ctx, c, tryout)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, version)
+}
+
+// publishVersion snapshots the working copy of a tryout as its next version,
+// publishing the tryout if it is a draft. It responds with an error and
+// returns false on failure.
+func publishVersion(ctx context.Context, c *gin.Context, tryout models.Tryout) (models.TryoutVersion, bool) {
+	questions, err := repository.Questions.ListByTryout(ctx, tryout.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+		return models.TryoutVersion{}, false
+	}
+	if len(questions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Add at least one question before publishing"})
+		return models.TryoutVersion{}, false
+	}
+
+	user, _ := auth.CurrentUser(c)
+	version := models.NewTryoutVersion(tryout, questions, user.ID, time.Now())
+
+	if tryout.Version > 0 {
+		current, err := repository.Versions.Get(ctx, tryout.ID, tryout.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version: " + err.Error()})
+			return models.TryoutVersion{}, false
+		}
+		diff, err := models.DiffVersions(current, version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare versions: " + err.Error()})
+			return models.TryoutVersion{}, false
+		}
+		if diff.IsEmpty() {
+			c.JSON(http.StatusConflict, gin.H{"error": "There are no changes to publish"})
+			return models.TryoutVersion{}, false
+		}
+	}
+
+	if err := repository.Versions.Publish(ctx, &version, tryout.Status, user); err != nil {
+		switch err {
+		case repository.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tryout not found"})
+		case repository.ErrStatusChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": "Tryout was changed by another request, try again"})
+		default:
+			log.Printf("Error publishing tryout %s: %v", tryout.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish tryout: " + err.Error()})
+		}
+		return models.TryoutVersion{}, false
+	}
+	return version, true
+}
+
+// findVersion loads a version of a tryout by its number, or the working copy
+// for draft. It responds with an error and returns false on failure.
+func findVersion(ctx context.Context, c *gin.Context, tryout models.Tryout, value string) (models.TryoutVersion, bool) {
+	if value == models.DraftVersion {
+		version, err := draftVersion(ctx, tryout)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions: " + err.Error()})
+			return version, false
+		}
+		return version, true
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version must be a positive number or draft"})
+		return models.TryoutVersion{}, false
+	}
+
+	version, err := repository.Versions.Get(ctx, tryout.ID, number)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+			return version, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version: " + err.Error()})
+		return version, false
+	}
+	return version, true
+}
+
+// draftVersion returns the working copy of a tryout as an unpublished version
+func draftVersion(ctx context.Context, tryout models.Tryout) (models.TryoutVersion, error) {
+	questions, err := repository.Questions.ListByTryout(ctx, tryout.ID)
+	if err != nil {
+		return models.TryoutVersion{}, err
+	}
+	return models.NewTryoutVersion(tryout, questions, primitive.NilObjectID, time.Time{}), nil
+}
+
+// versionQuestions returns the questions of a version of a tryout with their
+// answer keys. Version 0 stands for attempts started before versions existed,
+// which are graded against the working copy.
+func versionQuestions(ctx context.Context, tryoutID primitive.ObjectID, number int) ([]models.Question, error) {
+	if number == 0 {
+		return repository.Questions.ListByTryout(ctx, tryoutID)
+	}
+	version, err := repository.Versions.Get(ctx, tryoutID, number)
+	if err != nil {
+		return nil, err
+	}
+	return version.Questions, nil
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"quiz-platform/models"
+	"quiz-platform/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// unpublishable holds the due drafts that were left alone for having no
+// questions, so that is logged once rather than on every run
+var unpublishable sync.Map
+
+// ApplySchedules publishes drafts once their opensAt has come and archives
+// published tryouts once their closesAt has come. It runs immediately and
+// then every interval until ctx is cancelled.
+func ApplySchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ApplyDueSchedules(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApplyDueSchedules moves the tryouts that are due at now to their next
+// status, publishing drafts as their first version. Drafts without questions
+// are left alone, as they cannot be published; that is logged once per draft.
+func ApplyDueSchedules(ctx context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tryouts, err := repository.Tryouts.ListDue(ctx, now)
+	if err != nil {
+		log.Printf("Error fetching scheduled tryouts: %v", err)
+		return
+	}
+
+	scheduler := models.User{Name: models.SchedulerActor}
+	for _, tryout := range tryouts {
+		next := models.TryoutArchived
+		var err error
+		if tryout.Status == models.TryoutDraft {
+			next = models.TryoutPublished
+
+			questions, listErr := repository.Questions.ListByTryout(ctx, tryout.ID)
+			if listErr != nil {
+				log.Printf("Error fetching questions of tryout %s: %v", tryout.ID.Hex(), listErr)
+				continue
+			}
+			if len(questions) == 0 {
+				if _, logged := unpublishable.LoadOrStore(tryout.ID, true); !logged {
+					log.Printf("Not publishing scheduled tryout %s: it has no questions", tryout.ID.Hex())
+				}
+				continue
+			}
+			unpublishable.Delete(tryout.ID)
+
+			version := models.NewTryoutVersion(tryout, questions, primitive.NilObjectID, now)
+			err = repository.Versions.Publish(ctx, &version, tryout.Status, scheduler)
+		} else {
+			err = repository.Tryouts.SetStatus(ctx, tryout.ID, tryout.Status, next, now, scheduler)
+		}
+
+		// Tryouts changed by hand since they were listed are skipped
+		if err != nil && err != repository.ErrStatusChanged && err != repository.ErrNotFound {
+			log.Printf("Error moving tryout %s to %s: %v", tryout.ID.Hex(), next, err)
+			continue
+		}
+		if err == nil {
+			log.Printf("Moved scheduled tryout %s to %s", tryout.ID.Hex(), next)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"quiz-platform/repository"
+)
+
+// PurgeTrash permanently deletes tryouts and questions that have been in the
+// trash for longer than retention. It runs immediately and then every
+// interval until ctx is cancelled.
+func PurgeTrash(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeTrash(ctx, time.Now().Add(-retention))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeTrash(ctx context.Context, before time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	report, err := repository.Maintenance.PurgeDeleted(ctx, before)
+	if err != nil {
+		log.Printf("Error purging trash: %v", err)
+		return
+	}
+	if report.Tryouts > 0 || report.Questions > 0 {
+		log.Printf("Purged %d tryouts and %d questions from the trash", report.Tryouts, report.Questions)
+	}
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"quiz-platform/config"
+	"quiz-platform/jobs"
 	"quiz-platform/routes"
+	"time"
+
 	"github.com/joho/godotenv"
 )
 
@@ -15,13 +19,19 @@ func main() {
 		log.Println("No .env file found, using default values")
 	}
 
-	// Connect to MongoDB
-	config.ConnectDB()
+	// Connect to the configured storage (MongoDB unless STORAGE_DRIVER=memory)
+	config.ConnectStorage()
 	defer config.CloseDB()
 
 	// Seed database with dummy data if empty
 	config.SeedDummyData()
 
+	// Permanently delete items that have been in the trash past the retention period
+	go jobs.PurgeTrash(context.Background(), config.TrashRetention(), time.Hour)
+
+	// Publish and archive tryouts at their opensAt and closesAt times
+	go jobs.ApplySchedules(context.Background(), time.Minute)
+
 	// Set up router
 	router := routes.SetupRouter()
 
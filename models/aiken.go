@@ -0,0 +1,173 @@
+package models
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Aiken is Moodle's format for multiple choice questions: the question on
+// one line, then its options lettered from A, then the letter of the answer.
+//
+//	Largest planet?
+//	A. Mars
+//	B. Jupiter
+//	ANSWER: B
+//
+// True/false questions travel as the options True and False.
+var (
+	aikenOption = regexp.MustCompile(`^([A-Z])[.)]\s+(.*)$`)
+	aikenAnswer = regexp.MustCompile(`^ANSWER:\s*(.*)$`)
+)
+
+var errNoAikenAnswer = errors.New("the question has no ANSWER line")
+
+// aikenTrue and aikenFalse are the options of true/false questions
+const (
+	aikenTrue  = "True"
+	aikenFalse = "False"
+)
+
+// ParseAiken reads the questions of an Aiken file. Two options reading True
+// and False make a true/false question, anything else a multiple choice one.
+// It returns one input per question, with errors numbered from 1 for
+// questions that could not be read; it only fails as a whole when the file
+// itself is unreadable.
+func ParseAiken(r io.Reader) ([]QuestionInput, []ImportError, error) {
+	inputs := []QuestionInput{}
+	rowErrors := []ImportError{}
+	var text []string
+	var options []OptionInput
+	finish := func(answer string, err error) {
+		input := QuestionInput{Type: QuestionMultipleChoice, Text: strings.Join(text, "\n"), Options: options}
+		if err == nil {
+			err = input.setAikenAnswer(answer)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Row: len(inputs) + 1, Error: err.Error()})
+		}
+		inputs = append(inputs, input)
+		text, options = nil, nil
+	}
+
+	// After a broken option the rest of its question is skipped
+	skipping := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if skipping {
+			skipping = line != "" && !aikenAnswer.MatchString(line)
+			continue
+		}
+
+		if match := aikenAnswer.FindStringSubmatch(line); match != nil && len(text) > 0 {
+			finish(strings.TrimSpace(match[1]), nil)
+			continue
+		}
+		if match := aikenOption.FindStringSubmatch(line); match != nil && len(text) > 0 {
+			if want := string(rune('A' + len(options))); match[1] != want {
+				finish("", fmt.Errorf("expected option %s, got %s", want, match[1]))
+				skipping = true
+				continue
+			}
+			options = append(options, OptionInput{Text: strings.TrimSpace(match[2])})
+			continue
+		}
+
+		switch {
+		case line == "":
+			// Blank lines only separate questions
+			if len(options) > 0 {
+				finish("", errNoAikenAnswer)
+			}
+		case len(options) > 0:
+			// Text after the options starts the next question
+			finish("", errNoAikenAnswer)
+			text = append(text, line)
+		default:
+			text = append(text, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(text) > 0 {
+		finish("", errNoAikenAnswer)
+	}
+	return inputs, rowErrors, nil
+}
+
+// setAikenAnswer marks the option with the letter of the answer correct,
+// turning True and False options into a true/false question
+func (in *QuestionInput) setAikenAnswer(letter string) error {
+	if len(letter) != 1 || letter[0] < 'A' || int(letter[0]-'A') >= len(in.Options) {
+		return fmt.Errorf("ANSWER %q is not the letter of an option", letter)
+	}
+	answer := int(letter[0] - 'A')
+	in.Options[answer].IsCorrect = true
+
+	if len(in.Options) == 2 && strings.EqualFold(in.Options[0].Text, aikenTrue) && strings.EqualFold(in.Options[1].Text, aikenFalse) {
+		in.Type, in.IsTrue, in.Options = QuestionTrueFalse, answer == 0, nil
+	}
+	return nil
+}
+
+// WriteAiken writes the multiple choice and true/false questions among
+// questions in Aiken and skips the rest, along with questions of more than 26
+// options. Aiken has no points or explanations, and puts each text on a
+// single line. It returns the numbers of the skipped questions, counting
+// from 1.
+func WriteAiken(w io.Writer, questions []Question) ([]int, error) {
+	var b strings.Builder
+	skipped := []int{}
+	for i, question := range questions {
+		options, answer := aikenOptions(&question)
+		if answer < 0 || len(options) > 26 {
+			skipped = append(skipped, i+1)
+			continue
+		}
+
+		b.WriteString(aikenLine(question.Text) + "\n")
+		for j, option := range options {
+			fmt.Fprintf(&b, "%c. %s\n", 'A'+j, aikenLine(option))
+		}
+		fmt.Fprintf(&b, "ANSWER: %c\n\n", 'A'+answer)
+	}
+	_, err := io.WriteString(w, b.String())
+	return skipped, err
+}
+
+// aikenOptions returns the options of a question with the index of the
+// correct one, or -1 when Aiken cannot express the question
+func aikenOptions(q *Question) ([]string, int) {
+	switch q.Type {
+	case QuestionTrueFalse:
+		if q.IsTrue {
+			return []string{aikenTrue, aikenFalse}, 0
+		}
+		return []string{aikenTrue, aikenFalse}, 1
+	case QuestionMultipleChoice:
+		options, answer := make([]string, len(q.Options)), -1
+		for i, option := range q.Options {
+			options[i] = option.Text
+			if option.IsCorrect {
+				answer = i
+			}
+		}
+		return options, answer
+	}
+	return nil, -1
+}
+
+// aikenLine joins the lines of a text into one
+func aikenLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
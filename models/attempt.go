@@ -0,0 +1,93 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attempt statuses
+const (
+	AttemptInProgress = "in_progress"
+	AttemptSubmitted  = "submitted"
+	AttemptExpired    = "expired"
+)
+
+// Attempt represents a timed session in which a taker answers a tryout
+type Attempt struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TryoutID         primitive.ObjectID  `json:"tryoutId" bson:"tryoutId"`
+	UserID           primitive.ObjectID  `json:"userId" bson:"userId,omitempty"`
+	TakerName        string              `json:"takerName" bson:"takerName"`
+	Version          int                 `json:"version" bson:"version,omitempty"` // tryout version being answered; 0 before versions existed
+	Status           string              `json:"status" bson:"status"`
+	StartedAt        time.Time           `json:"startedAt" bson:"startedAt"`
+	Deadline         time.Time           `json:"deadline" bson:"deadline"`
+	SubmissionID     *primitive.ObjectID `json:"submissionId,omitempty" bson:"submissionId,omitempty"`
+	Shuffle          ShuffleSettings     `json:"shuffle" bson:"shuffle"`    // the tryout's settings when the attempt started
+	Seed             int64               `json:"-" bson:"seed,omitempty"`   // fixes the attempt's order across reloads
+	RemainingSeconds int64               `json:"remainingSeconds" bson:"-"` // computed from server time
+}
+
+// AttemptInput is used for starting an attempt
+type AttemptInput struct {
+	TakerName string `json:"takerName"` // defaults to the name of the signed-in user
+}
+
+// Remaining returns the time left before the attempt's deadline
+func (a *Attempt) Remaining(now time.Time) time.Duration {
+	if a.Status != AttemptInProgress || now.After(a.Deadline) {
+		return 0
+	}
+	return a.Deadline.Sub(now)
+}
+
+// NewSeed returns a random seed for the order of an attempt
+func NewSeed() int64 {
+	for {
+		if seed := rand.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// QuestionOrder returns the order in which the attempt shows n questions, as
+// indexes into the questions in their canonical order. The same seed always
+// gives the same order.
+func (a *Attempt) QuestionOrder(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if a.Shuffle.Questions {
+		shuffleOrder(order, a.Seed)
+	}
+	return order
+}
+
+// shuffleOrder shuffles a list of indexes in place, the same way every time
+// for the same seed
+func shuffleOrder(order []int, seed int64) {
+	rand.New(rand.NewSource(seed)).Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+}
+
+// Arrange returns the questions in the order the attempt shows them, with
+// their positions in that order and their options shuffled when the attempt
+// shuffles options. questions must be in their canonical order.
+func (a *Attempt) Arrange(questions []TakerQuestion) []TakerQuestion {
+	arranged := make([]TakerQuestion, 0, len(questions))
+	for position, i := range a.QuestionOrder(len(questions)) {
+		question := questions[i]
+		question.Position = position + 1
+		if a.Shuffle.Options && len(question.Options) > 1 {
+			// Each question gets its own source so its options keep their
+			// order however the questions are shuffled
+			options := append([]TakerOption(nil), question.Options...)
+			rand.New(rand.NewSource(a.Seed+int64(i)+1)).Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+			question.Options = options
+		}
+		arranged = append(arranged, question)
+	}
+	return arranged
+}
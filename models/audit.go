@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited entity types
+const (
+	EntityTryout   = "tryout"
+	EntityQuestion = "question"
+)
+
+// Audited actions
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionDeleted   = "deleted"   // moved to the trash
+	ActionRestored  = "restored"  // taken out of the trash
+	ActionPublished = "published" // a version was published
+	ActionArchived  = "archived"
+)
+
+// Actor names of changes made by the server itself rather than a user
+const (
+	SchedulerActor = "scheduler" // scheduled publishing and archiving
+	SeedActor      = "seed"      // sample content seeded into an empty database
+)
+
+// auditIgnoredFields are the JSON fields left out of audit diffs; the entry
+// records when the change happened itself
+var auditIgnoredFields = []string{"id", "createdAt", "updatedAt"}
+
+// AuditEntry records one change to the content of a tryout. Entries are only
+// ever appended, never changed or removed.
+type AuditEntry struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TryoutID   primitive.ObjectID `json:"tryoutId" bson:"tryoutId"`
+	EntityType string             `json:"entityType" bson:"entityType"`
+	EntityID   primitive.ObjectID `json:"entityId" bson:"entityId"`
+	Action     string             `json:"action" bson:"action"`
+	ActorID    primitive.ObjectID `json:"actorId" bson:"actorId,omitempty"` // unset for changes made by the server itself
+	ActorName  string             `json:"actorName" bson:"actorName"`       // as it was at the time of the change
+	Changes    []FieldChange      `json:"changes" bson:"changes"`           // every field for deletes and restores
+	At         time.Time          `json:"at" bson:"at"`
+}
+
+// NewAuditEntry records a change by actor to an entity of a tryout, diffing
+// the entity before and after the change. before is nil for created and
+// restored entities, after is nil for deleted ones. It fails when the entity
+// cannot be diffed.
+func NewAuditEntry(actor User, action, entityType string, entityID, tryoutID primitive.ObjectID, before, after interface{}, at time.Time) (AuditEntry, error) {
+	changes, err := diffFields(before, after, auditIgnoredFields)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	return AuditEntry{
+		TryoutID:   tryoutID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		ActorID:    actor.ID,
+		ActorName:  actor.Name,
+		Changes:    changes,
+		At:         at,
+	}, nil
+}
+
+// IsValidAction reports whether action is one of the audited actions
+func IsValidAction(action string) bool {
+	switch action {
+	case ActionCreated, ActionUpdated, ActionDeleted, ActionRestored, ActionPublished, ActionArchived:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bundles are tryouts exported with their questions to be imported into
+// another installation
+const (
+	BundleFormat        = "quiz-platform/tryout"
+	BundleSchemaVersion = 1 // bumped whenever the bundle layout changes
+)
+
+// TryoutBundle is a self-contained export of a tryout and its questions. It
+// holds no database IDs: questions are numbered q1, q2, ... in order, and
+// imports assign fresh IDs.
+type TryoutBundle struct {
+	Format        string           `json:"format"`
+	SchemaVersion int              `json:"schemaVersion"`
+	ExportedAt    time.Time        `json:"exportedAt"`
+	Version       string           `json:"version"` // tryout version exported, or draft for the working copy
+	Tryout        BundleTryout     `json:"tryout"`
+	Questions     []BundleQuestion `json:"questions"`
+}
+
+// BundleTryout holds the content of an exported tryout. Status, ownership and
+// availability windows belong to the installation and are left out.
+type BundleTryout struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Category    string          `json:"category"`
+	Duration    int             `json:"duration"`
+	Shuffle     ShuffleSettings `json:"shuffle"`
+}
+
+// BundleQuestion is an exported question with its answer key
+type BundleQuestion struct {
+	ID      string        `json:"id"`
+	Type    string        `json:"type"`
+	Text    string        `json:"text"`
+	Points  *float64      `json:"points"` // defaults to 1 when left out
+	IsTrue  bool          `json:"isTrue,omitempty"`
+	Options []OptionInput `json:"options,omitempty"`
+
+	AcceptedAnswers  []string `json:"acceptedAnswers,omitempty"`
+	AcceptedPatterns []string `json:"acceptedPatterns,omitempty"`
+	CaseSensitive    bool     `json:"caseSensitive,omitempty"`
+
+	NumericAnswer     *float64 `json:"numericAnswer,omitempty"`
+	Tolerance         float64  `json:"tolerance,omitempty"`
+	RelativeTolerance float64  `json:"relativeTolerance,omitempty"`
+
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// BundleImport reports a tryout created from a bundle
+type BundleImport struct {
+	Tryout      Tryout     `json:"tryout"`
+	Questions   []Question `json:"questions"`
+	RenamedFrom string     `json:"renamedFrom,omitempty"` // the bundle's title, when it was taken
+
+	Unsupported []UnsupportedItem `json:"unsupported,omitempty"` // items of QTI packages that were left out
+}
+
+// NewTryoutBundle exports a version of a tryout, along with the tryout's
+// shuffle settings
+func NewTryoutBundle(version TryoutVersion, shuffle ShuffleSettings, at time.Time) TryoutBundle {
+	bundle := TryoutBundle{
+		Format:        BundleFormat,
+		SchemaVersion: BundleSchemaVersion,
+		ExportedAt:    at,
+		Version:       version.Label(),
+		Tryout: BundleTryout{
+			Title:       version.Title,
+			Description: version.Description,
+			Category:    version.Category,
+			Duration:    version.Duration,
+			Shuffle:     shuffle,
+		},
+		Questions: []BundleQuestion{},
+	}
+	for i, question := range version.Questions {
+		input := question.Input()
+		bundle.Questions = append(bundle.Questions, BundleQuestion{
+			ID:      fmt.Sprintf("q%d", i+1),
+			Type:    input.Type,
+			Text:    input.Text,
+			Points:  input.Points,
+			IsTrue:  input.IsTrue,
+			Options: input.Options,
+
+			AcceptedAnswers:  input.AcceptedAnswers,
+			AcceptedPatterns: input.AcceptedPatterns,
+			CaseSensitive:    input.CaseSensitive,
+
+			NumericAnswer:     input.NumericAnswer,
+			Tolerance:         input.Tolerance,
+			RelativeTolerance: input.RelativeTolerance,
+
+			Explanation: input.Explanation,
+		})
+	}
+	return bundle
+}
+
+// CheckVersion checks that the bundle is in a format this server reads
+func (b *TryoutBundle) CheckVersion() error {
+	if b.Format != BundleFormat {
+		return fmt.Errorf("format must be %q", BundleFormat)
+	}
+	if b.SchemaVersion != BundleSchemaVersion {
+		return fmt.Errorf("schemaVersion %d is not supported, this server reads version %d", b.SchemaVersion, BundleSchemaVersion)
+	}
+	return nil
+}
+
+// Input returns the tryout of the bundle as input for creating it
+func (t *BundleTryout) Input() TryoutInput {
+	return TryoutInput{
+		Title:       t.Title,
+		Description: t.Description,
+		Category:    t.Category,
+		Duration:    t.Duration,
+		Shuffle:     t.Shuffle,
+	}
+}
+
+// points returns the points of the question, or the default when left out
+func (q *BundleQuestion) points() float64 {
+	if q.Points == nil {
+		return defaultPoints
+	}
+	return *q.Points
+}
+
+// Input returns the question as input for creating it
+func (q *BundleQuestion) Input() QuestionInput {
+	return QuestionInput{
+		Type:    q.Type,
+		Text:    q.Text,
+		Points:  q.Points,
+		IsTrue:  q.IsTrue,
+		Options: q.Options,
+
+		AcceptedAnswers:  q.AcceptedAnswers,
+		AcceptedPatterns: q.AcceptedPatterns,
+		CaseSensitive:    q.CaseSensitive,
+
+		NumericAnswer:     q.NumericAnswer,
+		Tolerance:         q.Tolerance,
+		RelativeTolerance: q.RelativeTolerance,
+
+		Explanation: q.Explanation,
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FieldChange is a field whose value differs between two versions of a
+// document. Values are kept as JSON, as the API shows them.
+type FieldChange struct {
+	Field string          `json:"field" bson:"field"`
+	From  json.RawMessage `json:"from" bson:"from"` // null when the field was unset
+	To    json.RawMessage `json:"to" bson:"to"`     // null when the field was removed
+}
+
+// diffFields compares the JSON fields of two documents, either of which may
+// be nil, and returns the changed fields sorted by name. The ignored fields
+// are left out. It fails when either document cannot be encoded as JSON.
+func diffFields(before, after interface{}, ignored []string) ([]FieldChange, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range ignored {
+		delete(from, field)
+		delete(to, field)
+	}
+
+	names := []string{}
+	for field := range from {
+		names = append(names, field)
+	}
+	for field := range to {
+		if _, ok := from[field]; !ok {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, field := range names {
+		if !bytes.Equal(from[field], to[field]) {
+			changes = append(changes, FieldChange{Field: field, From: from[field], To: to[field]})
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns a document as its JSON fields, or no fields for nil
+func jsonFields(document interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if document == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode document: %w", err)
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("cannot decode document: %w", err)
+	}
+	return fields, nil
+}
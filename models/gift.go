@@ -0,0 +1,448 @@
+package models
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GIFT is Moodle's plain-text question format. Questions are separated by
+// blank lines and keep their answers in braces after the text:
+//
+//	The sun is a star. {T}
+//	Largest planet? {=Jupiter ~Mars ~Venus}
+//	Who wrote Hamlet? {=Shakespeare =William Shakespeare}
+//	Value of pi? {#3.14:0.01}
+//	Describe photosynthesis. {}
+//
+// The characters ~ = # { } : are escaped with a backslash in text, and \n
+// stands for a line break.
+const giftSpecial = `~=#{}:`
+
+// giftBlank stands in for the answer block of GIFT missing word questions,
+// whose answers sit in the middle of the text
+const giftBlank = "_____"
+
+// giftAnswer is one entry of a GIFT answer list, such as =Jupiter or ~%50%Mars
+type giftAnswer struct {
+	correct bool     // marked with =
+	weight  *float64 // percentage of the credit, when given
+	text    string   // still escaped, without the weight and feedback
+}
+
+// ParseGIFT reads the questions of a GIFT file. Titles, comments, categories
+// and per-answer feedback are ignored, and general feedback becomes the
+// explanation. It returns one input per question, with errors numbered from 1
+// for questions that could not be read; it only fails as a whole when the
+// file itself is unreadable.
+func ParseGIFT(r io.Reader) ([]QuestionInput, []ImportError, error) {
+	blocks, err := giftBlocks(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inputs := []QuestionInput{}
+	rowErrors := []ImportError{}
+	for i, block := range blocks {
+		input, err := parseGIFTQuestion(block)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Row: i + 1, Error: err.Error()})
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, rowErrors, nil
+}
+
+// giftBlocks splits a GIFT file into the source of its questions
+func giftBlocks(r io.Reader) ([]string, error) {
+	var blocks []string
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			lines = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "$CATEGORY:"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return blocks, scanner.Err()
+}
+
+// parseGIFTQuestion reads the question in one block of a GIFT file
+func parseGIFTQuestion(block string) (QuestionInput, error) {
+	var input QuestionInput
+	source := strings.TrimSpace(block)
+	if strings.HasPrefix(source, "::") {
+		end := indexUnescaped(source[2:], "::")
+		if end < 0 {
+			return input, errors.New("the title is not closed with ::")
+		}
+		source = strings.TrimSpace(source[2+end+2:])
+	}
+	for _, markup := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		source = strings.TrimPrefix(source, markup)
+	}
+
+	open := indexUnescaped(source, "{")
+	if open < 0 {
+		return input, errors.New("the question has no answers in braces")
+	}
+	end := indexUnescaped(source[open:], "}")
+	if end < 0 {
+		return input, errors.New("the answers are not closed with }")
+	}
+	end += open
+
+	input.Text = giftUnescape(source[:open])
+	if after := giftUnescape(source[end+1:]); after != "" {
+		input.Text = strings.TrimSpace(input.Text + " " + giftBlank + " " + after)
+	}
+
+	answers, feedback := cutUnescaped(source[open+1:end], "####")
+	input.Explanation = giftUnescape(feedback)
+	return input, parseGIFTAnswers(&input, strings.TrimSpace(answers))
+}
+
+// parseGIFTAnswers fills the type and answer key of a question from its
+// answer block, without the general feedback
+func parseGIFTAnswers(input *QuestionInput, answers string) error {
+	if answers == "" {
+		input.Type = QuestionEssay
+		return nil
+	}
+	if strings.HasPrefix(answers, "#") {
+		input.Type = QuestionNumeric
+		return parseGIFTNumeric(input, answers[1:])
+	}
+
+	// True/false answers may carry feedback for wrong and right answers
+	value, _ := cutUnescaped(answers, "#")
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "T", "TRUE":
+		input.Type, input.IsTrue = QuestionTrueFalse, true
+		return nil
+	case "F", "FALSE":
+		input.Type = QuestionTrueFalse
+		return nil
+	}
+
+	entries, err := splitGIFTAnswers(answers)
+	if err != nil {
+		return err
+	}
+	wrong, correct, weighted := 0, 0, false
+	for _, entry := range entries {
+		if strings.Contains(entry.text, "->") && entry.correct {
+			return errors.New("matching questions are not supported")
+		}
+		if !entry.correct {
+			wrong++
+		}
+		weighted = weighted || (!entry.correct && entry.weight != nil && *entry.weight > 0)
+	}
+
+	// Without wrong answers to choose from, the answers are typed in
+	if wrong == 0 {
+		input.Type = QuestionShortAnswer
+		for _, entry := range entries {
+			if entry.weight == nil || *entry.weight == 100 {
+				input.AcceptedAnswers = append(input.AcceptedAnswers, giftUnescape(entry.text))
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		isCorrect := entry.correct || (entry.weight != nil && *entry.weight > 0)
+		if isCorrect {
+			correct++
+		}
+		input.Options = append(input.Options, OptionInput{Text: giftUnescape(entry.text), IsCorrect: isCorrect})
+	}
+	input.Type = QuestionMultipleChoice
+	if correct != 1 || weighted {
+		input.Type = QuestionMultiSelect
+	}
+	return nil
+}
+
+// parseGIFTNumeric reads a numeric answer: a value with an optional
+// tolerance, as in 3.14:0.01, or a range, as in 3.1..3.2. Of several
+// answers with credits, the first one with full credit counts.
+func parseGIFTNumeric(input *QuestionInput, answers string) error {
+	answers = strings.TrimSpace(answers)
+	if strings.HasPrefix(answers, "=") || strings.HasPrefix(answers, "~") {
+		entries, err := splitGIFTAnswers(answers)
+		if err != nil {
+			return err
+		}
+		answers = ""
+		for _, entry := range entries {
+			if entry.correct && (entry.weight == nil || *entry.weight == 100) {
+				answers = entry.text
+				break
+			}
+		}
+		if answers == "" {
+			return errors.New("the numeric question has no answer with full credit")
+		}
+	}
+
+	value, _ := cutUnescaped(answers, "#")
+	value = strings.TrimSpace(value)
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		from, errFrom := strconv.ParseFloat(strings.TrimSpace(low), 64)
+		to, errTo := strconv.ParseFloat(strings.TrimSpace(high), 64)
+		if errFrom != nil || errTo != nil || !isFinite(from) || !isFinite(to) || from > to {
+			return fmt.Errorf("%q is not a numeric range", value)
+		}
+		answer := (from + to) / 2
+		input.NumericAnswer, input.Tolerance = &answer, (to-from)/2
+		return nil
+	}
+
+	number, tolerance, hasTolerance := strings.Cut(value, ":")
+	answer, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || !isFinite(answer) {
+		return fmt.Errorf("%q is not a number", number)
+	}
+	input.NumericAnswer = &answer
+	if hasTolerance {
+		if input.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(tolerance), 64); err != nil || !isFinite(input.Tolerance) {
+			return fmt.Errorf("%q is not a tolerance", tolerance)
+		}
+	}
+	return nil
+}
+
+// splitGIFTAnswers splits a list of answers marked with = and ~, dropping
+// their feedback
+func splitGIFTAnswers(answers string) ([]giftAnswer, error) {
+	var entries []giftAnswer
+	start := -1
+	add := func(end int) error {
+		if start < 0 {
+			if strings.TrimSpace(answers[:end]) != "" {
+				return errors.New("answers must start with = or ~")
+			}
+			return nil
+		}
+		entry, err := parseGIFTAnswer(answers[start], answers[start+1:end])
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	}
+
+	for i := 0; i < len(answers); i++ {
+		switch answers[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if err := add(i); err != nil {
+				return nil, err
+			}
+			start = i
+		}
+	}
+	if err := add(len(answers)); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func parseGIFTAnswer(marker byte, source string) (giftAnswer, error) {
+	entry := giftAnswer{correct: marker == '='}
+	source = strings.TrimSpace(source)
+	if strings.HasPrefix(source, "%") {
+		end := strings.Index(source[1:], "%")
+		if end < 0 {
+			return entry, fmt.Errorf("the weight of %q is not closed with %%", source)
+		}
+		weight, err := strconv.ParseFloat(source[1:end+1], 64)
+		if err != nil || !isFinite(weight) {
+			return entry, fmt.Errorf("%q is not a weight", source[1:end+1])
+		}
+		entry.weight = &weight
+		source = source[end+2:]
+	}
+	entry.text, _ = cutUnescaped(source, "#")
+	if giftUnescape(entry.text) == "" {
+		return entry, errors.New("an answer has no text")
+	}
+	return entry, nil
+}
+
+// WriteGIFT writes questions in GIFT, one after the other. GIFT has no
+// points or case-sensitive answers, so those are left out, as are accepted
+// patterns; short answer questions with nothing but patterns are skipped.
+// It returns the numbers of the skipped questions, counting from 1.
+func WriteGIFT(w io.Writer, questions []Question) ([]int, error) {
+	var b strings.Builder
+	skipped := []int{}
+	for i, question := range questions {
+		answers, multiline := giftAnswers(&question)
+		if answers == nil {
+			skipped = append(skipped, i+1)
+			continue
+		}
+		if question.Explanation != "" {
+			answers = append(answers, "####"+giftEscape(question.Explanation))
+		}
+
+		b.WriteString(giftEscape(question.Text))
+		if multiline {
+			b.WriteString(" {\n\t" + strings.Join(answers, "\n\t") + "\n}\n\n")
+		} else {
+			b.WriteString(" {" + strings.Join(answers, " ") + "}\n\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return skipped, err
+}
+
+// giftAnswers returns the entries of a question's answer block, one per line
+// when multiline, or nil when GIFT cannot express the question
+func giftAnswers(q *Question) (answers []string, multiline bool) {
+	switch q.Type {
+	case QuestionTrueFalse:
+		if q.IsTrue {
+			return []string{"T"}, false
+		}
+		return []string{"F"}, false
+	case QuestionEssay:
+		return []string{}, false
+	case QuestionNumeric:
+		if q.NumericAnswer == nil {
+			return nil, false
+		}
+		// GIFT only has absolute tolerances
+		answer := "#" + formatNumber(*q.NumericAnswer)
+		if tolerance := math.Max(q.Tolerance, q.RelativeTolerance*math.Abs(*q.NumericAnswer)); tolerance > 0 {
+			answer += ":" + formatNumber(tolerance)
+		}
+		return []string{answer}, false
+	case QuestionShortAnswer:
+		for _, accepted := range q.AcceptedAnswers {
+			answers = append(answers, "="+giftEscape(accepted))
+		}
+		return answers, false
+	case QuestionMultipleChoice:
+		for _, option := range q.Options {
+			marker := "~"
+			if option.IsCorrect {
+				marker = "="
+			}
+			answers = append(answers, marker+giftEscape(option.Text))
+		}
+		return answers, true
+	case QuestionMultiSelect:
+		correct := 0
+		for _, option := range q.Options {
+			if option.IsCorrect {
+				correct++
+			}
+		}
+		if correct == 0 {
+			return nil, false
+		}
+		credit := formatNumber(math.Round(100/float64(correct)*1e5) / 1e5)
+		for _, option := range q.Options {
+			weight := "-100"
+			if option.IsCorrect {
+				weight = credit
+			}
+			answers = append(answers, "~%"+weight+"%"+giftEscape(option.Text))
+		}
+		return answers, true
+	}
+	return nil, false
+}
+
+// giftEscape escapes text for GIFT
+func giftEscape(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ReplaceAll(text, "\r\n", "\n") {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\\' || strings.ContainsRune(giftSpecial, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// giftUnescape turns GIFT source into plain text. Line breaks in the source
+// are only layout, so they read as spaces.
+func giftUnescape(source string) string {
+	var b strings.Builder
+	for i := 0; i < len(source); i++ {
+		switch c := source[i]; {
+		case c == '\\' && i+1 < len(source):
+			i++
+			if source[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(source[i])
+			}
+		case c == '\n' || c == '\r':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// indexUnescaped returns the index of the first occurrence of substr in s
+// that is not escaped with a backslash, or -1
+func indexUnescaped(s, substr string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// cutUnescaped slices s around the first unescaped occurrence of sep
+func cutUnescaped(s, sep string) (before, after string) {
+	if i := indexUnescaped(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):]
+	}
+	return s, ""
+}
+
+// formatNumber formats a number in its shortest exact form
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// numberPattern matches a leading decimal, scientific or fractional number,
+// optionally followed by a unit which is ignored
+var numberPattern = regexp.MustCompile(`^([-+]?(?:\d{1,3}(?:,\d{3})+|\d+)?(?:\.\d+)?(?:[eE][-+]?\d+)?)(?:\s*/\s*(\d+(?:\.\d+)?))?\s*([^\d.,+\-/].*)?$`)
+
+// Unanswered returns the zero-point answer recorded for a skipped question
+func (q *Question) Unanswered() Answer {
+	return Answer{QuestionID: q.ID, Status: AnswerAutoGraded, MaxPoints: q.Points}
+}
+
+// Grade checks an answer against the question's answer key. Essay answers
+// are left pending for a grader.
+func (q *Question) Grade(input AnswerInput) (Answer, error) {
+	answer := q.Unanswered()
+
+	switch q.Type {
+	case QuestionEssay:
+		answer.Text = strings.TrimSpace(input.Text)
+		if answer.Text != "" {
+			answer.Status = AnswerPending
+		}
+	case QuestionMultipleChoice, QuestionMultiSelect:
+		selected, err := q.parseSelection(input.SelectedOptions)
+		if err != nil {
+			return answer, err
+		}
+		if q.Type == QuestionMultipleChoice && len(selected) != 1 {
+			return answer, fmt.Errorf("question %s expects exactly one selected option", q.ID.Hex())
+		}
+		answer.SelectedOptions = selected
+		answer.IsCorrect = q.isCorrectSelection(selected)
+	case QuestionShortAnswer:
+		answer.Text = input.Text
+		answer.IsCorrect = q.matchesShortAnswer(input.Text)
+	case QuestionNumeric:
+		answer.Text = input.Text
+		value, err := ParseNumber(input.Text)
+		if err != nil {
+			// An unparseable response is wrong rather than invalid
+			break
+		}
+		answer.IsCorrect = q.withinTolerance(value)
+	default:
+		if input.Answer == nil {
+			return answer, fmt.Errorf("question %s expects a true/false answer", q.ID.Hex())
+		}
+		value := *input.Answer
+		answer.Answer = &value
+		answer.IsCorrect = value == q.IsTrue
+	}
+
+	if answer.IsCorrect {
+		answer.Points = q.Points
+	}
+	return answer, nil
+}
+
+// ApplyManualGrade records a grader's points and feedback on an answer
+func (a *Answer) ApplyManualGrade(points float64, feedback string, at time.Time) error {
+	if points < 0 || points > a.MaxPoints {
+		return fmt.Errorf("points must be between 0 and %g", a.MaxPoints)
+	}
+	a.Points = points
+	a.Feedback = feedback
+	a.Status = AnswerGraded
+	a.IsCorrect = points == a.MaxPoints
+	a.GradedAt = &at
+	return nil
+}
+
+// parseSelection resolves the selected option IDs against the question's options
+func (q *Question) parseSelection(ids []string) ([]primitive.ObjectID, error) {
+	selected := make([]primitive.ObjectID, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		optionID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid option ID format %q", id)
+		}
+		if !q.hasOption(optionID) {
+			return nil, fmt.Errorf("option %s does not belong to question %s", id, q.ID.Hex())
+		}
+		if seen[optionID] {
+			return nil, fmt.Errorf("option %s selected more than once", id)
+		}
+		seen[optionID] = true
+		selected = append(selected, optionID)
+	}
+	return selected, nil
+}
+
+func (q *Question) hasOption(id primitive.ObjectID) bool {
+	for _, option := range q.Options {
+		if option.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isCorrectSelection reports whether exactly the correct options were selected
+func (q *Question) isCorrectSelection(selected []primitive.ObjectID) bool {
+	chosen := make(map[primitive.ObjectID]bool, len(selected))
+	for _, id := range selected {
+		chosen[id] = true
+	}
+	for _, option := range q.Options {
+		if option.IsCorrect != chosen[option.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesShortAnswer compares a normalized response with the accepted answers and patterns
+func (q *Question) matchesShortAnswer(response string) bool {
+	normalized := normalizeText(response, q.CaseSensitive)
+	if normalized == "" {
+		return false
+	}
+
+	for _, accepted := range q.AcceptedAnswers {
+		if normalized == normalizeText(accepted, q.CaseSensitive) {
+			return true
+		}
+	}
+
+	for _, pattern := range q.AcceptedPatterns {
+		re, err := compileAnswerPattern(pattern, q.CaseSensitive)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinTolerance reports whether value matches the numeric answer within
+// either the absolute or the relative tolerance
+func (q *Question) withinTolerance(value float64) bool {
+	if q.NumericAnswer == nil {
+		return false
+	}
+	expected := *q.NumericAnswer
+	diff := math.Abs(value - expected)
+
+	// Always allow for floating point rounding
+	if diff <= 1e-9*math.Max(1, math.Abs(expected)) {
+		return true
+	}
+	if diff <= q.Tolerance {
+		return true
+	}
+	return diff <= q.RelativeTolerance*math.Abs(expected)
+}
+
+// normalizeText trims the text, collapses inner whitespace and, unless
+// case sensitive, lowercases it
+func normalizeText(text string, caseSensitive bool) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if !caseSensitive {
+		text = strings.ToLower(text)
+	}
+	return text
+}
+
+// compileAnswerPattern compiles an accepted pattern so that it must match the
+// whole normalized response
+func compileAnswerPattern(pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("pattern is empty")
+	}
+	flags := ""
+	if !caseSensitive {
+		flags = "(?i)"
+	}
+	return regexp.Compile(flags + "^(?:" + pattern + ")$")
+}
+
+// ParseNumber parses a numeric response such as "42", "-3.5e2", "1,000",
+// "1/3" or "9.81 m/s^2". Any trailing unit is ignored.
+func ParseNumber(text string) (float64, error) {
+	match := numberPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil || strings.Trim(match[1], "+-") == "" {
+		return 0, fmt.Errorf("%q is not a number", text)
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", text)
+	}
+
+	if match[2] != "" {
+		denominator, err := strconv.ParseFloat(match[2], 64)
+		if err != nil || denominator == 0 {
+			return 0, fmt.Errorf("%q is not a valid fraction", text)
+		}
+		value /= denominator
+	}
+	return value, nil
+}
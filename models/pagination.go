@@ -0,0 +1,16 @@
+package models
+
+// Pagination defaults
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// TryoutList is one page of a tryout listing
+type TryoutList struct {
+	Items      []Tryout `json:"items"`
+	Total      int64    `json:"total"`          // tryouts matching the filter across all pages
+	Page       int      `json:"page,omitempty"` // omitted for cursor-based requests
+	PageSize   int      `json:"pageSize"`
+	NextCursor string   `json:"nextCursor,omitempty"` // pass as "after" to fetch the next page
+}
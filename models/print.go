@@ -0,0 +1,391 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"math/rand"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxPrintVariants is the most variants one print can have, one per letter
+const MaxPrintVariants = 26
+
+// Lines left for written answers on paper
+const (
+	printAnswerLines = 1
+	printEssayLines  = 8
+)
+
+// PrintSheet is a version of a tryout laid out for paper: either the exam
+// handed to takers or its answer key
+type PrintSheet struct {
+	Title       string
+	Description string
+	Category    string
+	Duration    int    // in minutes
+	Version     string // number, or draft for the working copy
+	AnswerKey   bool
+	Papers      []PrintPaper
+}
+
+// PrintPaper is one variant of a printed exam with its questions in printed
+// order and their options in printed order
+type PrintPaper struct {
+	Variant   string // A, B, C and so on; empty for a single unshuffled paper
+	Questions []Question
+}
+
+// NewPrintSheet lays out a version of a tryout for printing. With no
+// variants there is one paper with the questions in their own order.
+// Otherwise each variant, labelled A, B, C and so on, shuffles the questions
+// and their options in an order fixed by the tryout and the label, so an
+// exam and an answer key printed separately always match.
+func NewPrintSheet(version TryoutVersion, variants int, answerKey bool) PrintSheet {
+	sheet := PrintSheet{
+		Title:       version.Title,
+		Description: version.Description,
+		Category:    version.Category,
+		Duration:    version.Duration,
+		Version:     version.Label(),
+		AnswerKey:   answerKey,
+	}
+	if variants == 0 {
+		sheet.Papers = []PrintPaper{{Questions: version.Questions}}
+		return sheet
+	}
+	for v := 0; v < variants; v++ {
+		label := string(rune('A' + v))
+		sheet.Papers = append(sheet.Papers, PrintPaper{Variant: label, Questions: shuffleVariant(version.TryoutID, label, version.Questions)})
+	}
+	return sheet
+}
+
+// shuffleVariant returns the questions of a variant in its own order, with
+// their options shuffled
+func shuffleVariant(tryoutID primitive.ObjectID, label string, questions []Question) []Question {
+	hash := fnv.New64a()
+	hash.Write(tryoutID[:])
+	hash.Write([]byte(label))
+	seed := int64(hash.Sum64())
+
+	order := make([]int, len(questions))
+	for i := range order {
+		order[i] = i
+	}
+	shuffleOrder(order, seed)
+
+	shuffled := make([]Question, 0, len(questions))
+	for _, i := range order {
+		question := questions[i]
+		if len(question.Options) > 1 {
+			// Seeded per question like Attempt.Arrange
+			options := append([]Option(nil), question.Options...)
+			rand.New(rand.NewSource(seed+int64(i)+1)).Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+			question.Options = options
+		}
+		shuffled = append(shuffled, question)
+	}
+	return shuffled
+}
+
+// printItem is a question as printed, numbered from 1
+type printItem struct {
+	Number      int
+	Lines       []string
+	Points      string
+	Instruction string
+	Choices     []printChoice
+	AnswerLines int // blank lines for a written answer
+	Answer      string
+	Explanation []string
+}
+
+// printChoice is an option with the letter of its answer bubble
+type printChoice struct {
+	Letter string
+	Text   string
+}
+
+// printPaper is a paper as the templates render it
+type printPaper struct {
+	Title   string
+	Variant string
+	Items   []printItem
+	Summary string
+}
+
+func (s *PrintSheet) papers() []printPaper {
+	papers := make([]printPaper, len(s.Papers))
+	for p, paper := range s.Papers {
+		var total float64
+		items := make([]printItem, len(paper.Questions))
+		for i := range paper.Questions {
+			question := &paper.Questions[i]
+			total += question.Points
+			items[i] = newPrintItem(i+1, question)
+		}
+
+		summary := []string{}
+		if s.Category != "" {
+			summary = append(summary, s.Category)
+		}
+		summary = append(summary,
+			plural(float64(s.Duration), "minute"),
+			plural(float64(len(items)), "question"),
+			plural(total, "point"),
+		)
+		if s.Version == DraftVersion {
+			summary = append(summary, "Draft")
+		} else {
+			summary = append(summary, "Version "+s.Version)
+		}
+		papers[p] = printPaper{Title: s.printTitle(paper.Variant), Variant: paper.Variant, Items: items, Summary: strings.Join(summary, " · ")}
+	}
+	return papers
+}
+
+func newPrintItem(number int, q *Question) printItem {
+	item := printItem{
+		Number: number,
+		Lines:  strings.Split(q.Text, "\n"),
+		Points: plural(q.Points, "point"),
+		Answer: printAnswer(q),
+	}
+	if q.Explanation != "" {
+		item.Explanation = strings.Split(q.Explanation, "\n")
+	}
+	switch q.Type {
+	case QuestionTrueFalse:
+		item.Choices = []printChoice{{"T", aikenTrue}, {"F", aikenFalse}}
+	case QuestionMultipleChoice, QuestionMultiSelect:
+		if q.Type == QuestionMultiSelect {
+			item.Instruction = "Select all that apply."
+		}
+		for i, option := range q.Options {
+			item.Choices = append(item.Choices, printChoice{optionLetter(i), option.Text})
+		}
+	case QuestionEssay:
+		item.AnswerLines = printEssayLines
+	default:
+		item.AnswerLines = printAnswerLines
+	}
+	return item
+}
+
+// printAnswer returns the key of a question as printed on the answer key
+func printAnswer(q *Question) string {
+	switch q.Type {
+	case QuestionTrueFalse:
+		if q.IsTrue {
+			return aikenTrue
+		}
+		return aikenFalse
+	case QuestionMultipleChoice, QuestionMultiSelect:
+		letters := []string{}
+		for i, option := range q.Options {
+			if option.IsCorrect {
+				letters = append(letters, optionLetter(i))
+			}
+		}
+		return strings.Join(letters, ", ")
+	case QuestionShortAnswer:
+		answers := append([]string(nil), q.AcceptedAnswers...)
+		for _, pattern := range q.AcceptedPatterns {
+			answers = append(answers, "/"+pattern+"/")
+		}
+		answer := strings.Join(answers, " or ")
+		if q.CaseSensitive {
+			answer += " (case-sensitive)"
+		}
+		return answer
+	case QuestionNumeric:
+		if q.NumericAnswer == nil {
+			return ""
+		}
+		answer := formatNumber(*q.NumericAnswer)
+		tolerances := []string{}
+		if q.Tolerance > 0 {
+			tolerances = append(tolerances, "± "+formatNumber(q.Tolerance))
+		}
+		if q.RelativeTolerance > 0 {
+			tolerances = append(tolerances, "± "+formatNumber(q.RelativeTolerance*100)+"%")
+		}
+		if len(tolerances) > 0 {
+			answer += " " + strings.Join(tolerances, " or ")
+		}
+		return answer
+	default:
+		return "Graded by hand"
+	}
+}
+
+// optionLetter returns the letter of the i-th option, as in Aiken
+func optionLetter(i int) string {
+	return string(rune('A' + i))
+}
+
+// plural formats a count of a unit, e.g. "1 point" or "2.5 points"
+func plural(count float64, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return formatNumber(count) + " " + unit + "s"
+}
+
+// printTitle is the heading of a paper
+func (s *PrintSheet) printTitle(variant string) string {
+	title := s.Title
+	if s.AnswerKey {
+		title += " — Answer key"
+	}
+	if variant != "" {
+		title += ", variant " + variant
+	}
+	return title
+}
+
+var printHTML = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  @page { size: A4; margin: 2cm; }
+  body { font-family: Georgia, "Times New Roman", serif; font-size: 12pt; color: #000; max-width: 18cm; margin: 0 auto; }
+  .paper + .paper { break-before: page; page-break-before: always; }
+  header { border-bottom: 2px solid #000; margin-bottom: 1.5em; }
+  h1 { font-size: 18pt; margin: 0 0 .3em; }
+  .variant { float: right; border: 2px solid #000; padding: .1em .5em; font-size: 16pt; font-weight: bold; }
+  .summary { font-style: italic; }
+  .taker { display: flex; gap: 2em; margin: 1em 0; }
+  .taker span { flex: 1; border-bottom: 1px solid #000; }
+  ol.questions { list-style: none; padding: 0; }
+  .question { break-inside: avoid; page-break-inside: avoid; margin-bottom: 1.2em; }
+  .number { font-weight: bold; }
+  .points, .instruction { font-size: 10pt; font-style: italic; }
+  ul.choices { list-style: none; padding-left: 1.5em; margin: .4em 0; }
+  ul.choices li { margin: .3em 0; }
+  .bubble { display: inline-block; width: 1.5em; height: 1.5em; line-height: 1.5em; border: 1px solid #000; border-radius: 50%; text-align: center; font-size: 9pt; margin-right: .5em; }
+  .answer-line { border-bottom: 1px solid #000; height: 2em; margin-left: 1.5em; }
+  table.key { border-collapse: collapse; width: 100%; }
+  table.key th, table.key td { border: 1px solid #000; padding: .3em .5em; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+{{- $key := .AnswerKey}}
+{{- range .Papers}}
+<section class="paper">
+<header>
+{{- if .Variant}}
+<div class="variant">{{.Variant}}</div>
+{{- end}}
+<h1>{{.Title}}</h1>
+{{- if $.Description}}
+<p>{{$.Description}}</p>
+{{- end}}
+<p class="summary">{{.Summary}}</p>
+{{- if not $key}}
+<div class="taker">Name: <span></span> Date: <span></span></div>
+{{- end}}
+</header>
+{{- if $key}}
+<table class="key">
+<thead><tr><th>No.</th><th>Answer</th><th>Points</th><th>Explanation</th></tr></thead>
+<tbody>
+{{- range .Items}}
+<tr><td>{{.Number}}</td><td>{{.Answer}}</td><td>{{.Points}}</td><td>{{range $i, $line := .Explanation}}{{if $i}}<br>{{end}}{{$line}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<ol class="questions">
+{{- range .Items}}
+<li class="question">
+<p><span class="number">{{.Number}}.</span> {{range $i, $line := .Lines}}{{if $i}}<br>{{end}}{{$line}}{{end}} <span class="points">({{.Points}})</span>{{if .Instruction}} <span class="instruction">{{.Instruction}}</span>{{end}}</p>
+{{- if .Choices}}
+<ul class="choices">
+{{- range .Choices}}
+<li><span class="bubble">{{.Letter}}</span>{{.Text}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- range .BlankLines}}
+<div class="answer-line"></div>
+{{- end}}
+</li>
+{{- end}}
+</ol>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// BlankLines lists the answer lines of an item for ranging over
+func (i printItem) BlankLines() []struct{} {
+	return make([]struct{}, i.AnswerLines)
+}
+
+// WritePrintHTML writes a print sheet as a standalone HTML page with one
+// paper per variant, each starting on a new page when printed
+func WritePrintHTML(w io.Writer, sheet PrintSheet) error {
+	return printHTML.Execute(w, struct {
+		Title       string
+		Description string
+		AnswerKey   bool
+		Papers      []printPaper
+	}{sheet.printTitle(""), sheet.Description, sheet.AnswerKey, sheet.papers()})
+}
+
+// WritePrintText writes a print sheet as plain text, with a form feed
+// between variants so each starts on a new page
+func WritePrintText(w io.Writer, sheet PrintSheet) error {
+	var b strings.Builder
+	for p, paper := range sheet.papers() {
+		if p > 0 {
+			b.WriteString("\f")
+		}
+		b.WriteString(paper.Title + "\n")
+		if sheet.Description != "" {
+			b.WriteString(sheet.Description + "\n")
+		}
+		b.WriteString(paper.Summary + "\n\n")
+		if !sheet.AnswerKey {
+			b.WriteString("Name: ______________________________  Date: ______________\n\n")
+		}
+
+		for _, item := range paper.Items {
+			if sheet.AnswerKey {
+				fmt.Fprintf(&b, "%d. %s (%s)\n", item.Number, item.Answer, item.Points)
+				for _, line := range item.Explanation {
+					b.WriteString("   " + line + "\n")
+				}
+				continue
+			}
+
+			fmt.Fprintf(&b, "%d. %s", item.Number, item.Lines[0])
+			for _, line := range item.Lines[1:] {
+				b.WriteString("\n   " + line)
+			}
+			fmt.Fprintf(&b, " (%s)", item.Points)
+			if item.Instruction != "" {
+				b.WriteString(" " + item.Instruction)
+			}
+			b.WriteString("\n")
+			for _, choice := range item.Choices {
+				fmt.Fprintf(&b, "   (%s) %s\n", choice.Letter, choice.Text)
+			}
+			for i := 0; i < item.AnswerLines; i++ {
+				b.WriteString("   ________________________________________________\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
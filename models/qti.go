@@ -0,0 +1,427 @@
+package models
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// IMS QTI 2.1 packages are zip files holding a manifest, an assessment test
+// and one assessment item per question. Exports place them at these paths.
+const (
+	qtiManifestPath = "imsmanifest.xml"
+	qtiTestPath     = "assessment.xml"
+	qtiItemDir      = "items/"
+)
+
+// Namespaces, resource types and response processing templates of QTI 2.1
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	imscpNamespace    = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	lomNamespace      = "http://ltsc.ieee.org/xsd/LOM"
+	qtiTestResource   = "imsqti_test_xmlv2p1"
+	qtiItemResource   = "imsqti_item_xmlv2p1"
+	qtiMatchCorrect   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	qtiMapResponse    = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+	qtiResponse       = "RESPONSE"
+	qtiScore          = "SCORE"
+	qtiMaxScore       = "MAXSCORE" // holds the points of a question
+	qtiFeedback       = "FEEDBACK"
+	qtiTrueChoice     = "true"
+	qtiFalseChoice    = "false"
+	qtiItemTitleRunes = 80
+)
+
+type qtiManifest struct {
+	XMLName    xml.Name            `xml:"manifest"`
+	Xmlns      string              `xml:"xmlns,attr,omitempty"`
+	Identifier string              `xml:"identifier,attr"`
+	Metadata   qtiManifestMetadata `xml:"metadata"`
+	// Packages of questions have no organizations, but the element is required
+	Organizations struct{}      `xml:"organizations"`
+	Resources     []qtiResource `xml:"resources>resource"`
+}
+
+type qtiManifestMetadata struct {
+	Schema        string  `xml:"schema"`
+	SchemaVersion string  `xml:"schemaversion"`
+	LOM           *qtiLOM `xml:"lom"`
+}
+
+// qtiLOM is the part of the IEEE LOM metadata holding the tryout's title,
+// description and, as its keyword, category
+type qtiLOM struct {
+	Xmlns       string          `xml:"xmlns,attr,omitempty"`
+	Title       qtiLangString   `xml:"general>title"`
+	Description qtiLangString   `xml:"general>description"`
+	Keywords    []qtiLangString `xml:"general>keyword"`
+}
+
+type qtiLangString struct {
+	String string `xml:"string"`
+}
+
+type qtiResource struct {
+	Identifier   string          `xml:"identifier,attr"`
+	Type         string          `xml:"type,attr"`
+	Href         string          `xml:"href,attr"`
+	Files        []qtiFile       `xml:"file"`
+	Dependencies []qtiDependency `xml:"dependency"`
+}
+
+type qtiFile struct {
+	Href string `xml:"href,attr"`
+}
+
+type qtiDependency struct {
+	IdentifierRef string `xml:"identifierref,attr"`
+}
+
+type qtiAssessmentTest struct {
+	XMLName    xml.Name       `xml:"assessmentTest"`
+	Xmlns      string         `xml:"xmlns,attr,omitempty"`
+	Identifier string         `xml:"identifier,attr"`
+	Title      string         `xml:"title,attr"`
+	TimeLimits *qtiTimeLimits `xml:"timeLimits"`
+	Parts      []qtiTestPart  `xml:"testPart"`
+}
+
+type qtiTimeLimits struct {
+	MaxTime float64 `xml:"maxTime,attr"` // seconds
+}
+
+type qtiTestPart struct {
+	Identifier     string       `xml:"identifier,attr"`
+	NavigationMode string       `xml:"navigationMode,attr"`
+	SubmissionMode string       `xml:"submissionMode,attr"`
+	Sections       []qtiSection `xml:"assessmentSection"`
+}
+
+type qtiSection struct {
+	Identifier string       `xml:"identifier,attr"`
+	Title      string       `xml:"title,attr"`
+	Visible    bool         `xml:"visible,attr"`
+	Ordering   *qtiOrdering `xml:"ordering"`
+	Sections   []qtiSection `xml:"assessmentSection"`
+	ItemRefs   []qtiItemRef `xml:"assessmentItemRef"`
+}
+
+type qtiOrdering struct {
+	Shuffle bool `xml:"shuffle,attr"`
+}
+
+type qtiItemRef struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+}
+
+// qtiAssessmentItem is an exported question. Imports read items with
+// qtiItemSource instead, since their bodies can hold any markup.
+type qtiAssessmentItem struct {
+	XMLName            xml.Name                 `xml:"assessmentItem"`
+	Xmlns              string                   `xml:"xmlns,attr"`
+	Identifier         string                   `xml:"identifier,attr"`
+	Title              string                   `xml:"title,attr"`
+	Adaptive           bool                     `xml:"adaptive,attr"`
+	TimeDependent      bool                     `xml:"timeDependent,attr"`
+	Responses          []qtiResponseDeclaration `xml:"responseDeclaration"`
+	Outcomes           []qtiOutcomeDeclaration  `xml:"outcomeDeclaration"`
+	Body               qtiItemBody              `xml:"itemBody"`
+	ResponseProcessing *qtiResponseProcessing   `xml:"responseProcessing"`
+	Feedback           []qtiModalFeedback       `xml:"modalFeedback"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier  string      `xml:"identifier,attr"`
+	Cardinality string      `xml:"cardinality,attr"`
+	BaseType    string      `xml:"baseType,attr"`
+	Correct     *qtiValues  `xml:"correctResponse"`
+	Mapping     *qtiMapping `xml:"mapping"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier    string     `xml:"identifier,attr"`
+	Cardinality   string     `xml:"cardinality,attr"`
+	BaseType      string     `xml:"baseType,attr"`
+	NormalMaximum float64    `xml:"normalMaximum,attr,omitempty"`
+	Default       *qtiValues `xml:"defaultValue"`
+}
+
+type qtiValues struct {
+	Values []string `xml:"value"`
+}
+
+type qtiMapping struct {
+	DefaultValue float64       `xml:"defaultValue,attr"`
+	Entries      []qtiMapEntry `xml:"mapEntry"`
+}
+
+type qtiMapEntry struct {
+	MapKey        string  `xml:"mapKey,attr"`
+	MappedValue   float64 `xml:"mappedValue,attr"`
+	CaseSensitive bool    `xml:"caseSensitive,attr"`
+}
+
+type qtiItemBody struct {
+	Paragraphs   []string              `xml:"p"`
+	Choice       *qtiChoiceInteraction `xml:"choiceInteraction"`
+	TextEntry    *qtiTextEntry         `xml:"div>textEntryInteraction"` // an inline interaction
+	ExtendedText *qtiExtendedTextEntry `xml:"extendedTextInteraction"`
+}
+
+type qtiChoiceInteraction struct {
+	ResponseIdentifier string            `xml:"responseIdentifier,attr"`
+	Shuffle            bool              `xml:"shuffle,attr"`
+	MaxChoices         int               `xml:"maxChoices,attr"` // 0 for any number
+	Choices            []qtiSimpleChoice `xml:"simpleChoice"`
+}
+
+type qtiSimpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Text       string `xml:",chardata"`
+}
+
+type qtiTextEntry struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+}
+
+type qtiExtendedTextEntry struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+}
+
+type qtiResponseProcessing struct {
+	Template  string                `xml:"template,attr,omitempty"`
+	Condition *qtiResponseCondition `xml:"responseCondition"`
+}
+
+// qtiResponseCondition awards the points of a numeric question when the
+// response equals the correct one within the tolerance
+type qtiResponseCondition struct {
+	Equal struct {
+		ToleranceMode string      `xml:"toleranceMode,attr"`
+		Tolerance     string      `xml:"tolerance,attr,omitempty"`
+		Variable      qtiVariable `xml:"variable"`
+		Correct       qtiVariable `xml:"correct"`
+	} `xml:"responseIf>equal"`
+	SetScore struct {
+		Identifier string      `xml:"identifier,attr"`
+		Variable   qtiVariable `xml:"variable"`
+	} `xml:"responseIf>setOutcomeValue"`
+}
+
+type qtiVariable struct {
+	Identifier string `xml:"identifier,attr"`
+}
+
+type qtiModalFeedback struct {
+	OutcomeIdentifier string   `xml:"outcomeIdentifier,attr"`
+	ShowHide          string   `xml:"showHide,attr"`
+	Identifier        string   `xml:"identifier,attr"`
+	Paragraphs        []string `xml:"p"`
+}
+
+// WriteQTI writes a bundle as a QTI 2.1 package: a manifest with the
+// tryout's title, description and category, an assessment test with its
+// duration and question shuffling, and one item per question. True/false
+// and choice questions become choiceInteraction items, short answer and
+// numeric ones textEntryInteraction items and essays
+// extendedTextInteraction items. Accepted patterns have no QTI equivalent,
+// so short answer questions with nothing but patterns are skipped. It
+// returns the numbers of the skipped questions, counting from 1.
+func WriteQTI(w io.Writer, bundle TryoutBundle) ([]int, error) {
+	manifest := qtiManifest{
+		Xmlns:      imscpNamespace,
+		Identifier: "manifest",
+		Metadata: qtiManifestMetadata{
+			Schema:        "QTIv2.1 Package",
+			SchemaVersion: "1.0.0",
+			LOM: &qtiLOM{
+				Xmlns:       lomNamespace,
+				Title:       qtiLangString{bundle.Tryout.Title},
+				Description: qtiLangString{bundle.Tryout.Description},
+				Keywords:    []qtiLangString{{bundle.Tryout.Category}},
+			},
+		},
+	}
+	section := qtiSection{Identifier: "section", Title: bundle.Tryout.Title, Visible: true}
+	if bundle.Tryout.Shuffle.Questions {
+		section.Ordering = &qtiOrdering{Shuffle: true}
+	}
+	test := qtiAssessmentTest{
+		Xmlns:      qtiNamespace,
+		Identifier: "test",
+		Title:      bundle.Tryout.Title,
+		TimeLimits: &qtiTimeLimits{MaxTime: float64(bundle.Tryout.Duration * 60)},
+	}
+	testResource := qtiResource{Identifier: "test", Type: qtiTestResource, Href: qtiTestPath, Files: []qtiFile{{qtiTestPath}}}
+
+	files := map[string]interface{}{}
+	var itemResources []qtiResource
+	skipped := []int{}
+	for i, question := range bundle.Questions {
+		item, ok := qtiItem(&question, bundle.Tryout.Shuffle.Options)
+		if !ok {
+			skipped = append(skipped, i+1)
+			continue
+		}
+		href := qtiItemDir + question.ID + ".xml"
+		files[href] = item
+		section.ItemRefs = append(section.ItemRefs, qtiItemRef{Identifier: question.ID, Href: href})
+		itemResources = append(itemResources, qtiResource{Identifier: question.ID, Type: qtiItemResource, Href: href, Files: []qtiFile{{href}}})
+		testResource.Dependencies = append(testResource.Dependencies, qtiDependency{question.ID})
+	}
+	test.Parts = []qtiTestPart{{Identifier: "part", NavigationMode: "nonlinear", SubmissionMode: "simultaneous", Sections: []qtiSection{section}}}
+	manifest.Resources = append([]qtiResource{testResource}, itemResources...)
+
+	archive := zip.NewWriter(w)
+	if err := writeQTIFile(archive, qtiManifestPath, manifest, bundle); err != nil {
+		return nil, err
+	}
+	if err := writeQTIFile(archive, qtiTestPath, test, bundle); err != nil {
+		return nil, err
+	}
+	for _, ref := range section.ItemRefs {
+		if err := writeQTIFile(archive, ref.Href, files[ref.Href], bundle); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, archive.Close()
+}
+
+func writeQTIFile(archive *zip.Writer, name string, document interface{}, bundle TryoutBundle) error {
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: bundle.ExportedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("writing %s: %v", name, err)
+	}
+	_, err = io.WriteString(file, "\n")
+	return err
+}
+
+// qtiItem builds the assessment item of a question, or reports that QTI
+// cannot express it
+func qtiItem(q *BundleQuestion, shuffle bool) (qtiAssessmentItem, bool) {
+	item := qtiAssessmentItem{
+		Xmlns:      qtiNamespace,
+		Identifier: q.ID,
+		Title:      qtiItemTitle(q.Text),
+		Outcomes: []qtiOutcomeDeclaration{
+			{Identifier: qtiScore, Cardinality: "single", BaseType: "float"},
+			{Identifier: qtiMaxScore, Cardinality: "single", BaseType: "float", Default: &qtiValues{[]string{formatNumber(q.points())}}},
+		},
+		Body: qtiItemBody{Paragraphs: strings.Split(q.Text, "\n")},
+	}
+	if q.Explanation != "" {
+		item.Outcomes = append(item.Outcomes, qtiOutcomeDeclaration{Identifier: qtiFeedback, Cardinality: "single", BaseType: "identifier"})
+		// Hidden only when FEEDBACK names it, which it never does
+		item.Feedback = []qtiModalFeedback{{
+			OutcomeIdentifier: qtiFeedback,
+			ShowHide:          "hide",
+			Identifier:        "explanation",
+			Paragraphs:        strings.Split(q.Explanation, "\n"),
+		}}
+	}
+
+	response := qtiResponseDeclaration{Identifier: qtiResponse, Cardinality: "single", BaseType: "identifier"}
+	switch q.Type {
+	case QuestionTrueFalse:
+		answer := qtiFalseChoice
+		if q.IsTrue {
+			answer = qtiTrueChoice
+		}
+		response.Correct = &qtiValues{[]string{answer}}
+		item.Body.Choice = &qtiChoiceInteraction{
+			ResponseIdentifier: qtiResponse,
+			MaxChoices:         1,
+			Choices:            []qtiSimpleChoice{{qtiTrueChoice, aikenTrue}, {qtiFalseChoice, aikenFalse}},
+		}
+		item.ResponseProcessing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+	case QuestionMultipleChoice, QuestionMultiSelect:
+		interaction := &qtiChoiceInteraction{ResponseIdentifier: qtiResponse, Shuffle: shuffle, MaxChoices: 1}
+		if q.Type == QuestionMultiSelect {
+			response.Cardinality, interaction.MaxChoices = "multiple", 0
+		}
+		response.Correct = &qtiValues{}
+		for i, option := range q.Options {
+			id := fmt.Sprintf("choice%d", i+1)
+			interaction.Choices = append(interaction.Choices, qtiSimpleChoice{id, option.Text})
+			if option.IsCorrect {
+				response.Correct.Values = append(response.Correct.Values, id)
+			}
+		}
+		item.Body.Choice = interaction
+		item.ResponseProcessing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+	case QuestionShortAnswer:
+		if len(q.AcceptedAnswers) == 0 {
+			return item, false
+		}
+		response.BaseType = "string"
+		response.Correct = &qtiValues{[]string{q.AcceptedAnswers[0]}}
+		response.Mapping = &qtiMapping{}
+		for _, answer := range q.AcceptedAnswers {
+			response.Mapping.Entries = append(response.Mapping.Entries, qtiMapEntry{MapKey: answer, MappedValue: q.points(), CaseSensitive: q.CaseSensitive})
+		}
+		item.Body.TextEntry = &qtiTextEntry{ResponseIdentifier: qtiResponse}
+		item.ResponseProcessing = &qtiResponseProcessing{Template: qtiMapResponse}
+	case QuestionNumeric:
+		if q.NumericAnswer == nil {
+			return item, false
+		}
+		response.BaseType = "float"
+		response.Correct = &qtiValues{[]string{formatNumber(*q.NumericAnswer)}}
+		item.Body.TextEntry = &qtiTextEntry{ResponseIdentifier: qtiResponse}
+		item.ResponseProcessing = &qtiResponseProcessing{Condition: qtiNumericCondition(q)}
+	case QuestionEssay:
+		response.BaseType = "string"
+		item.Body.ExtendedText = &qtiExtendedTextEntry{ResponseIdentifier: qtiResponse}
+	default:
+		return item, false
+	}
+	item.Responses = []qtiResponseDeclaration{response}
+	return item, true
+}
+
+// qtiNumericCondition compares numeric responses with the answer. QTI has one
+// tolerance per comparison, so a question with both kinds gets the absolute
+// tolerance that accepts the same responses.
+func qtiNumericCondition(q *BundleQuestion) *qtiResponseCondition {
+	condition := &qtiResponseCondition{}
+	condition.Equal.Variable = qtiVariable{qtiResponse}
+	condition.Equal.Correct = qtiVariable{qtiResponse}
+	condition.SetScore.Identifier = qtiScore
+	condition.SetScore.Variable = qtiVariable{qtiMaxScore}
+
+	switch {
+	case q.Tolerance == 0 && q.RelativeTolerance == 0:
+		condition.Equal.ToleranceMode = "exact"
+	case q.Tolerance == 0:
+		condition.Equal.ToleranceMode = "relative"
+		percent := formatNumber(q.RelativeTolerance * 100)
+		condition.Equal.Tolerance = percent + " " + percent
+	default:
+		condition.Equal.ToleranceMode = "absolute"
+		tolerance := formatNumber(math.Max(q.Tolerance, q.RelativeTolerance*math.Abs(*q.NumericAnswer)))
+		condition.Equal.Tolerance = tolerance + " " + tolerance
+	}
+	return condition
+}
+
+// qtiItemTitle shortens the first line of a question to an item title
+func qtiItemTitle(text string) string {
+	title, _, _ := strings.Cut(text, "\n")
+	if utf8.RuneCountInString(title) <= qtiItemTitleRunes {
+		return title
+	}
+	return string([]rune(title)[:qtiItemTitleRunes-1]) + "…"
+}
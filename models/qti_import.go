@@ -0,0 +1,452 @@
+package models
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxQTIFileSize is the largest file a QTI package may hold
+const MaxQTIFileSize = 5 << 20
+
+// QTIPackage is what an import read from a QTI package
+type QTIPackage struct {
+	Tryout      BundleTryout      // as far as the package describes it
+	Questions   []QuestionInput   // of the supported items
+	Items       []int             // item number of each question, counting from 1 in package order
+	Unsupported []UnsupportedItem // items that were left out
+}
+
+// UnsupportedItem is an item of an imported package with no matching
+// question type
+type UnsupportedItem struct {
+	Row        int    `json:"row"` // item number, counting from 1 in package order
+	Identifier string `json:"identifier"`
+	Error      string `json:"error"`
+}
+
+// qtiItemSource is an assessment item as read from a package
+type qtiItemSource struct {
+	Identifier         string                   `xml:"identifier,attr"`
+	Responses          []qtiResponseDeclaration `xml:"responseDeclaration"`
+	Outcomes           []qtiOutcomeDeclaration  `xml:"outcomeDeclaration"`
+	Body               qtiMarkup                `xml:"itemBody"`
+	ResponseProcessing qtiMarkup                `xml:"responseProcessing"`
+	Feedback           []qtiMarkup              `xml:"modalFeedback"`
+}
+
+// qtiMarkup is the raw content of an element
+type qtiMarkup struct {
+	Inner string `xml:",innerxml"`
+}
+
+// qtiInline are the interactions that sit inside a line of text
+var qtiInline = map[string]bool{"textEntryInteraction": true, "inlineChoiceInteraction": true}
+
+// qtiInteraction is an interaction found in an item body
+type qtiInteraction struct {
+	name       string
+	response   string
+	maxChoices int
+	shuffle    bool
+	choices    []qtiSimpleChoice
+}
+
+// qtiBlocks are the elements that end a line of text
+var qtiBlocks = map[string]bool{
+	"div": true, "li": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"pre": true, "blockquote": true, "tr": true, "prompt": true, "simpleChoice": true,
+}
+
+// ReadQTI reads a QTI 2.1 package. The items are taken in the order of the
+// package's assessment test, or of its manifest when it has no test, and the
+// tryout is described by the test and the manifest's LOM metadata. Items
+// with exactly one choice, text entry or extended text interaction become
+// questions; other items are reported as unsupported. It returns errors
+// numbered by item for items that could not be read, and only fails as a
+// whole when the package itself is unreadable.
+func ReadQTI(archive *zip.Reader) (QTIPackage, []ImportError, error) {
+	var pkg QTIPackage
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var manifest qtiManifest
+	if err := readQTIFile(files, qtiManifestPath, &manifest); err != nil {
+		return pkg, nil, err
+	}
+	if lom := manifest.Metadata.LOM; lom != nil {
+		pkg.Tryout.Title = strings.TrimSpace(lom.Title.String)
+		pkg.Tryout.Description = strings.TrimSpace(lom.Description.String)
+		if len(lom.Keywords) > 0 {
+			pkg.Tryout.Category = strings.TrimSpace(lom.Keywords[0].String)
+		}
+	}
+
+	var hrefs []string
+	for _, resource := range manifest.Resources {
+		if !strings.HasPrefix(resource.Type, "imsqti_test_xmlv2p") {
+			continue
+		}
+		var test qtiAssessmentTest
+		if err := readQTIFile(files, resource.Href, &test); err != nil {
+			return pkg, nil, err
+		}
+		if title := strings.TrimSpace(test.Title); title != "" {
+			pkg.Tryout.Title = title
+		}
+		if test.TimeLimits != nil && test.TimeLimits.MaxTime > 0 {
+			pkg.Tryout.Duration = int(math.Ceil(test.TimeLimits.MaxTime / 60))
+		}
+		for _, part := range test.Parts {
+			hrefs = append(hrefs, qtiSectionItems(part.Sections, path.Dir(resource.Href), &pkg.Tryout.Shuffle)...)
+		}
+		break
+	}
+	if hrefs == nil {
+		for _, resource := range manifest.Resources {
+			if strings.HasPrefix(resource.Type, "imsqti_item_xmlv2p") {
+				hrefs = append(hrefs, resource.Href)
+			}
+		}
+	}
+
+	rowErrors := []ImportError{}
+	for i, href := range hrefs {
+		var item qtiItemSource
+		if err := readQTIFile(files, href, &item); err != nil {
+			rowErrors = append(rowErrors, ImportError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		input, shuffle, err := item.question()
+		if err != nil {
+			pkg.Unsupported = append(pkg.Unsupported, UnsupportedItem{Row: i + 1, Identifier: item.Identifier, Error: err.Error()})
+			continue
+		}
+		pkg.Tryout.Shuffle.Options = pkg.Tryout.Shuffle.Options || shuffle
+		pkg.Questions = append(pkg.Questions, input)
+		pkg.Items = append(pkg.Items, i+1)
+	}
+	return pkg, rowErrors, nil
+}
+
+// qtiSectionItems lists the items of sections in order, resolving their
+// paths against the directory of the test. Shuffled sections shuffle the
+// tryout's questions.
+func qtiSectionItems(sections []qtiSection, dir string, shuffle *ShuffleSettings) []string {
+	var hrefs []string
+	for _, section := range sections {
+		if section.Ordering != nil && section.Ordering.Shuffle {
+			shuffle.Questions = true
+		}
+		for _, ref := range section.ItemRefs {
+			hrefs = append(hrefs, path.Join(dir, ref.Href))
+		}
+		hrefs = append(hrefs, qtiSectionItems(section.Sections, dir, shuffle)...)
+	}
+	return hrefs
+}
+
+// readQTIFile decodes an XML file of a package
+func readQTIFile(files map[string]*zip.File, name string, out interface{}) error {
+	file, ok := files[path.Clean(name)]
+	if !ok {
+		return fmt.Errorf("%s is missing from the package", name)
+	}
+	if file.UncompressedSize64 > MaxQTIFileSize {
+		return fmt.Errorf("%s is larger than %d bytes", name, MaxQTIFileSize)
+	}
+	content, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	defer content.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(content, MaxQTIFileSize))
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// question maps the item to a question, reporting whether its choices are
+// shuffled
+func (item *qtiItemSource) question() (QuestionInput, bool, error) {
+	var input QuestionInput
+	text, interactions, err := readQTIBody(item.Body.Inner)
+	if err != nil {
+		return input, false, err
+	}
+	if len(interactions) != 1 {
+		return input, false, fmt.Errorf("items need exactly one interaction, this one has %d", len(interactions))
+	}
+	interaction := interactions[0]
+	input.Text = text
+	input.Points = item.points()
+
+	var explanations []string
+	for _, feedback := range item.Feedback {
+		explanation, _, err := readQTIBody(feedback.Inner)
+		if err != nil {
+			return input, false, err
+		}
+		if explanation != "" {
+			explanations = append(explanations, explanation)
+		}
+	}
+	input.Explanation = strings.Join(explanations, "\n")
+
+	var response qtiResponseDeclaration
+	for _, declaration := range item.Responses {
+		if declaration.Identifier == interaction.response {
+			response = declaration
+		}
+	}
+	var correct []string
+	if response.Correct != nil {
+		correct = response.Correct.Values
+	}
+
+	switch interaction.name {
+	case "choiceInteraction":
+		isCorrect := make(map[string]bool, len(correct))
+		for _, value := range correct {
+			isCorrect[strings.TrimSpace(value)] = true
+		}
+		input.Type = QuestionMultipleChoice
+		if response.Cardinality == "multiple" || interaction.maxChoices != 1 {
+			input.Type = QuestionMultiSelect
+		}
+		for _, choice := range interaction.choices {
+			input.Options = append(input.Options, OptionInput{Text: choice.Text, IsCorrect: isCorrect[choice.Identifier]})
+		}
+
+		// True and False choices are a true/false question, as in Aiken
+		if input.Type == QuestionMultipleChoice && len(input.Options) == 2 &&
+			strings.EqualFold(input.Options[0].Text, aikenTrue) && strings.EqualFold(input.Options[1].Text, aikenFalse) {
+			input.Type, input.IsTrue, input.Options = QuestionTrueFalse, input.Options[0].IsCorrect, nil
+			return input, false, nil
+		}
+		return input, interaction.shuffle, nil
+	case "textEntryInteraction":
+		switch response.BaseType {
+		case "string":
+			input.Type = QuestionShortAnswer
+			input.AcceptedAnswers, input.CaseSensitive = response.acceptedAnswers()
+		case "float", "integer":
+			input.Type = QuestionNumeric
+			if len(correct) == 0 {
+				return input, false, errors.New("the numeric item has no correct response")
+			}
+			answer, err := strconv.ParseFloat(strings.TrimSpace(correct[0]), 64)
+			if err != nil || !isFinite(answer) {
+				return input, false, fmt.Errorf("%q is not a number", correct[0])
+			}
+			input.NumericAnswer = &answer
+			input.Tolerance, input.RelativeTolerance, err = item.tolerance()
+			if err != nil {
+				return input, false, err
+			}
+		default:
+			return input, false, fmt.Errorf("text entry items with %q responses are not supported", response.BaseType)
+		}
+		return input, false, nil
+	case "extendedTextInteraction":
+		input.Type = QuestionEssay
+		return input, false, nil
+	}
+	return input, false, fmt.Errorf("%s items are not supported", interaction.name)
+}
+
+// points returns the item's MAXSCORE, or else the normal maximum of its
+// SCORE; nil leaves the default
+func (item *qtiItemSource) points() *float64 {
+	for _, outcome := range item.Outcomes {
+		if outcome.Identifier == qtiMaxScore && outcome.Default != nil && len(outcome.Default.Values) > 0 {
+			if points, err := strconv.ParseFloat(strings.TrimSpace(outcome.Default.Values[0]), 64); err == nil && isFinite(points) && points >= 0 {
+				return &points
+			}
+		}
+	}
+	for _, outcome := range item.Outcomes {
+		if outcome.Identifier == qtiScore && outcome.NormalMaximum > 0 && isFinite(outcome.NormalMaximum) {
+			points := outcome.NormalMaximum
+			return &points
+		}
+	}
+	return nil
+}
+
+// tolerance reads the tolerance of the item's equal comparison: absolute, or
+// relative as a percentage
+func (item *qtiItemSource) tolerance() (absolute, relative float64, err error) {
+	decoder := xml.NewDecoder(strings.NewReader(item.ResponseProcessing.Inner))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return 0, 0, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "equal" {
+			continue
+		}
+
+		mode, tolerance := "exact", ""
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "toleranceMode":
+				mode = attr.Value
+			case "tolerance":
+				tolerance = attr.Value
+			}
+		}
+		if mode == "exact" {
+			return 0, 0, nil
+		}
+		// Of a lower and an upper tolerance, the narrower one is kept
+		var value float64
+		for i, field := range strings.Fields(tolerance) {
+			bound, err := strconv.ParseFloat(field, 64)
+			if err != nil || !isFinite(bound) || bound < 0 {
+				return 0, 0, fmt.Errorf("%q is not a tolerance", tolerance)
+			}
+			if i == 0 || bound < value {
+				value = bound
+			}
+		}
+		if mode == "relative" {
+			return 0, value / 100, nil
+		}
+		return value, 0, nil
+	}
+}
+
+// acceptedAnswers returns the responses a text entry accepts: those its
+// mapping awards points to, or else its correct responses
+func (r *qtiResponseDeclaration) acceptedAnswers() ([]string, bool) {
+	var answers []string
+	if r.Mapping != nil {
+		caseSensitive := len(r.Mapping.Entries) > 0
+		for _, entry := range r.Mapping.Entries {
+			if entry.MappedValue > 0 {
+				answers = append(answers, entry.MapKey)
+				caseSensitive = caseSensitive && entry.CaseSensitive
+			}
+		}
+		if len(answers) > 0 {
+			return answers, caseSensitive
+		}
+	}
+	if r.Correct != nil {
+		answers = r.Correct.Values
+	}
+	return answers, false
+}
+
+// readQTIBody reads the text of item markup, a line per paragraph, and the
+// interactions in it. Prompts and choices are read into the interactions.
+func readQTIBody(markup string) (string, []qtiInteraction, error) {
+	decoder := xml.NewDecoder(strings.NewReader(markup))
+	decoder.Entity = xml.HTMLEntity
+
+	var lines []string
+	var line, choice strings.Builder
+	var interactions []qtiInteraction
+	var current *qtiInteraction
+	inChoice := false
+	endLine := func(always bool) {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" || always {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			name := token.Name.Local
+			switch {
+			case current == nil && strings.HasSuffix(name, "Interaction"):
+				if !qtiInline[name] {
+					endLine(false)
+				}
+				interaction := qtiInteraction{name: name, maxChoices: 1}
+				for _, attr := range token.Attr {
+					switch attr.Name.Local {
+					case "responseIdentifier":
+						interaction.response = attr.Value
+					case "maxChoices":
+						interaction.maxChoices, _ = strconv.Atoi(attr.Value)
+					case "shuffle":
+						interaction.shuffle = attr.Value == "true"
+					}
+				}
+				interactions = append(interactions, interaction)
+				current = &interactions[len(interactions)-1]
+			case current != nil && name == "simpleChoice":
+				inChoice = true
+				choice.Reset()
+				identifier := ""
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "identifier" {
+						identifier = attr.Value
+					}
+				}
+				current.choices = append(current.choices, qtiSimpleChoice{Identifier: identifier})
+			case name == "br":
+				endLine(true)
+			}
+		case xml.EndElement:
+			name := token.Name.Local
+			switch {
+			case current != nil && name == current.name:
+				if !qtiInline[name] {
+					endLine(false)
+				}
+				current = nil
+			case inChoice && name == "simpleChoice":
+				inChoice = false
+				current.choices[len(current.choices)-1].Text = strings.Join(strings.Fields(choice.String()), " ")
+			case name == "p":
+				endLine(true)
+			case qtiBlocks[name]:
+				endLine(false)
+			}
+		case xml.CharData:
+			if inChoice {
+				choice.Write(token)
+			} else {
+				// Outside choices, prompts and text read as the question
+				line.Write(token)
+			}
+		}
+	}
+	endLine(false)
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n"), interactions, nil
+}
@@ -1,23 +1,367 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Question types
+const (
+	QuestionTrueFalse      = "true_false"
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionMultiSelect    = "multi_select"
+	QuestionShortAnswer    = "short_answer"
+	QuestionNumeric        = "numeric"
+	QuestionEssay          = "essay"
+)
+
+// defaultPoints is the value of a question when no points are set
+const defaultPoints = 1
+
 // Question represents a question in a tryout
 type Question struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TryoutID primitive.ObjectID `json:"tryoutId" bson:"tryoutId"`
+	Position int                `json:"position" bson:"position"` // 1 for the first question; 0 for questions created before ordering
+	Type     string             `json:"type" bson:"type"`
+	Text     string             `json:"text" bson:"text"`
+	Points   float64            `json:"points" bson:"points"`
+	IsTrue   bool               `json:"isTrue" bson:"isTrue"`                       // true_false only
+	Options  []Option           `json:"options,omitempty" bson:"options,omitempty"` // choice types only
+
+	// short_answer only
+	AcceptedAnswers  []string `json:"acceptedAnswers,omitempty" bson:"acceptedAnswers,omitempty"`
+	AcceptedPatterns []string `json:"acceptedPatterns,omitempty" bson:"acceptedPatterns,omitempty"`
+	CaseSensitive    bool     `json:"caseSensitive,omitempty" bson:"caseSensitive,omitempty"`
+
+	// numeric only
+	NumericAnswer     *float64 `json:"numericAnswer,omitempty" bson:"numericAnswer,omitempty"`
+	Tolerance         float64  `json:"tolerance,omitempty" bson:"tolerance,omitempty"`                 // absolute
+	RelativeTolerance float64  `json:"relativeTolerance,omitempty" bson:"relativeTolerance,omitempty"` // fraction of the answer, e.g. 0.01 for 1%
+
+	Explanation string `json:"explanation,omitempty" bson:"explanation,omitempty"` // why the answer is correct
+
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"` // set while in the trash
+}
+
+// Option is a selectable answer of a choice question
+type Option struct {
+	ID        primitive.ObjectID `json:"id" bson:"id"`
+	Text      string             `json:"text" bson:"text"`
+	IsCorrect bool               `json:"isCorrect" bson:"isCorrect"`
+}
+
+// TakerQuestion is the view of a question shown to quiz takers. It leaves out
+// everything that gives the answer away: correctness, accepted answers,
+// numeric answers and explanations.
+type TakerQuestion struct {
 	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	TryoutID  primitive.ObjectID `json:"tryoutId" bson:"tryoutId"`
+	Position  int                `json:"position" bson:"position"`
+	Type      string             `json:"type" bson:"type"`
 	Text      string             `json:"text" bson:"text"`
-	IsTrue    bool               `json:"isTrue" bson:"isTrue"`
+	Points    float64            `json:"points" bson:"points"`
+	Options   []TakerOption      `json:"options,omitempty" bson:"options,omitempty"`
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
 
+// TakerOption is a choice option without its correctness
+type TakerOption struct {
+	ID   primitive.ObjectID `json:"id" bson:"id"`
+	Text string             `json:"text" bson:"text"`
+}
+
 // QuestionInput is used for creating or updating a question
 type QuestionInput struct {
-	Text   string `json:"text" binding:"required"`
-	IsTrue bool   `json:"isTrue"`
+	Type    string        `json:"type"` // defaults to true_false
+	Text    string        `json:"text" binding:"required"`
+	Points  *float64      `json:"points" binding:"omitempty,min=0"` // defaults to 1; 0 for unscored questions
+	IsTrue  bool          `json:"isTrue"`
+	Options []OptionInput `json:"options"`
+
+	AcceptedAnswers  []string `json:"acceptedAnswers"`
+	AcceptedPatterns []string `json:"acceptedPatterns"`
+	CaseSensitive    bool     `json:"caseSensitive"`
+
+	NumericAnswer     *float64 `json:"numericAnswer"`
+	Tolerance         float64  `json:"tolerance"`
+	RelativeTolerance float64  `json:"relativeTolerance"`
+
+	Explanation string `json:"explanation"`
+
+	// Position inserts a new question at that place, moving the questions
+	// from there on down. New questions go last by default; updates ignore it.
+	Position *int `json:"position" binding:"omitempty,min=1"`
+}
+
+// QuestionOrder lists the questions of a tryout in the order takers get them
+type QuestionOrder struct {
+	QuestionIDs []primitive.ObjectID `json:"questionIds" binding:"required"`
+}
+
+// OptionInput is used for creating the options of a choice question
+type OptionInput struct {
+	Text      string `json:"text"`
+	IsCorrect bool   `json:"isCorrect"`
+}
+
+// UnmarshalBSON decodes a question, treating documents stored before
+// question types and points existed as one-point true/false questions
+func (q *Question) UnmarshalBSON(data []byte) error {
+	type rawQuestion Question
+	if err := bson.Unmarshal(data, (*rawQuestion)(q)); err != nil {
+		return err
+	}
+	if q.Type == "" {
+		q.Type = QuestionTrueFalse
+	}
+	if !hasPoints(data) {
+		q.Points = defaultPoints
+	}
+	return nil
+}
+
+// UnmarshalBSON decodes the taker view of a question with the same defaults
+// as Question
+func (q *TakerQuestion) UnmarshalBSON(data []byte) error {
+	type rawTakerQuestion TakerQuestion
+	if err := bson.Unmarshal(data, (*rawTakerQuestion)(q)); err != nil {
+		return err
+	}
+	if q.Type == "" {
+		q.Type = QuestionTrueFalse
+	}
+	if !hasPoints(data) {
+		q.Points = defaultPoints
+	}
+	return nil
+}
+
+// hasPoints reports whether a question document has its points set. Only
+// documents stored before points existed lack them; 0 is a valid value.
+func hasPoints(data []byte) bool {
+	_, err := bson.Raw(data).LookupErr("points")
+	return err == nil
+}
+
+// TakerView returns the question as shown to quiz takers
+func (q *Question) TakerView() TakerQuestion {
+	view := TakerQuestion{
+		ID:        q.ID,
+		TryoutID:  q.TryoutID,
+		Position:  q.Position,
+		Type:      q.Type,
+		Text:      q.Text,
+		Points:    q.Points,
+		CreatedAt: q.CreatedAt,
+		UpdatedAt: q.UpdatedAt,
+	}
+	for _, option := range q.Options {
+		view.Options = append(view.Options, TakerOption{ID: option.ID, Text: option.Text})
+	}
+	return view
+}
+
+// IsManuallyGraded reports whether answers to the question need a grader
+func (q *Question) IsManuallyGraded() bool {
+	return q.Type == QuestionEssay
+}
+
+// Validate checks that the input is consistent with its question type
+func (in *QuestionInput) Validate() error {
+	if in.Type == "" {
+		in.Type = QuestionTrueFalse
+	}
+	if in.Points == nil {
+		points := float64(defaultPoints)
+		in.Points = &points
+	}
+	if !isFinite(*in.Points) {
+		return errors.New("points must be a finite number")
+	}
+
+	if in.Type != QuestionMultipleChoice && in.Type != QuestionMultiSelect && len(in.Options) > 0 {
+		return errors.New("only choice questions can have options")
+	}
+	if in.Type != QuestionTrueFalse {
+		in.IsTrue = false
+	}
+	if in.Type != QuestionShortAnswer {
+		in.AcceptedAnswers, in.AcceptedPatterns, in.CaseSensitive = nil, nil, false
+	}
+	if in.Type != QuestionNumeric {
+		in.NumericAnswer, in.Tolerance, in.RelativeTolerance = nil, 0, 0
+	}
+
+	switch in.Type {
+	case QuestionTrueFalse, QuestionEssay:
+	case QuestionShortAnswer:
+		return in.validateShortAnswer()
+	case QuestionNumeric:
+		if in.NumericAnswer == nil {
+			return errors.New("numeric questions need a numericAnswer")
+		}
+		if !isFinite(*in.NumericAnswer) || !isFinite(in.Tolerance) || !isFinite(in.RelativeTolerance) {
+			return errors.New("numericAnswer and tolerances must be finite numbers")
+		}
+		if in.Tolerance < 0 || in.RelativeTolerance < 0 {
+			return errors.New("tolerances cannot be negative")
+		}
+	case QuestionMultipleChoice, QuestionMultiSelect:
+		if err := validateOptions(in.Options); err != nil {
+			return err
+		}
+		correct := 0
+		for _, option := range in.Options {
+			if option.IsCorrect {
+				correct++
+			}
+		}
+		if in.Type == QuestionMultipleChoice && correct != 1 {
+			return errors.New("multiple choice questions must have exactly one correct option")
+		}
+		if in.Type == QuestionMultiSelect && correct == 0 {
+			return errors.New("multi-select questions must have at least one correct option")
+		}
+	default:
+		return fmt.Errorf("unknown question type %q", in.Type)
+	}
+	return nil
+}
+
+// isFinite reports whether value is neither NaN nor infinite. Such values
+// cannot be graded against or encoded as JSON.
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// ToQuestion builds a question holding the input's content. The input must
+// have been validated first.
+func (in *QuestionInput) ToQuestion() Question {
+	question := Question{
+		Type:    in.Type,
+		Text:    in.Text,
+		Points:  *in.Points,
+		IsTrue:  in.IsTrue,
+		Options: in.BuildOptions(),
+
+		AcceptedAnswers:  in.AcceptedAnswers,
+		AcceptedPatterns: in.AcceptedPatterns,
+		CaseSensitive:    in.CaseSensitive,
+
+		NumericAnswer:     in.NumericAnswer,
+		Tolerance:         in.Tolerance,
+		RelativeTolerance: in.RelativeTolerance,
+
+		Explanation: strings.TrimSpace(in.Explanation),
+	}
+	if in.Position != nil {
+		question.Position = *in.Position
+	}
+	return question
+}
+
+// Input returns the content of the question as input for creating a copy
+// of it
+func (q *Question) Input() QuestionInput {
+	points := q.Points
+	input := QuestionInput{
+		Type:   q.Type,
+		Text:   q.Text,
+		Points: &points,
+		IsTrue: q.IsTrue,
+
+		AcceptedAnswers:  q.AcceptedAnswers,
+		AcceptedPatterns: q.AcceptedPatterns,
+		CaseSensitive:    q.CaseSensitive,
+
+		NumericAnswer:     q.NumericAnswer,
+		Tolerance:         q.Tolerance,
+		RelativeTolerance: q.RelativeTolerance,
+
+		Explanation: q.Explanation,
+	}
+	for _, option := range q.Options {
+		input.Options = append(input.Options, OptionInput{Text: option.Text, IsCorrect: option.IsCorrect})
+	}
+	return input
+}
+
+// BuildOptions converts the input options into stored options with fresh IDs
+func (in *QuestionInput) BuildOptions() []Option {
+	if len(in.Options) == 0 {
+		return nil
+	}
+	options := make([]Option, len(in.Options))
+	for i, option := range in.Options {
+		options[i] = Option{
+			ID:        primitive.NewObjectID(),
+			Text:      strings.TrimSpace(option.Text),
+			IsCorrect: option.IsCorrect,
+		}
+	}
+	return options
+}
+
+// KeepOptionIDs gives the options of the question the IDs of the previous
+// options with the same text, so editing a question only gives new options
+// new IDs
+func (q *Question) KeepOptionIDs(previous []Option) {
+	ids := make(map[string]primitive.ObjectID, len(previous))
+	for _, option := range previous {
+		ids[option.Text] = option.ID
+	}
+	for i := range q.Options {
+		if id, ok := ids[q.Options[i].Text]; ok {
+			q.Options[i].ID = id
+		}
+	}
+}
+
+func (in *QuestionInput) validateShortAnswer() error {
+	answers := make([]string, 0, len(in.AcceptedAnswers))
+	for _, answer := range in.AcceptedAnswers {
+		if answer = strings.TrimSpace(answer); answer != "" {
+			answers = append(answers, answer)
+		}
+	}
+	in.AcceptedAnswers = answers
+
+	for _, pattern := range in.AcceptedPatterns {
+		if _, err := compileAnswerPattern(pattern, in.CaseSensitive); err != nil {
+			return fmt.Errorf("invalid accepted pattern %q: %v", pattern, err)
+		}
+	}
+
+	if len(in.AcceptedAnswers) == 0 && len(in.AcceptedPatterns) == 0 {
+		return errors.New("short answer questions need at least one accepted answer or pattern")
+	}
+	return nil
+}
+
+func validateOptions(options []OptionInput) error {
+	if len(options) < 2 {
+		return errors.New("choice questions need at least two options")
+	}
+	seen := make(map[string]bool, len(options))
+	for i, option := range options {
+		text := strings.TrimSpace(option.Text)
+		if text == "" {
+			return fmt.Errorf("option %d has no text", i+1)
+		}
+		if seen[text] {
+			return fmt.Errorf("option %q is listed more than once", text)
+		}
+		seen[text] = true
+	}
+	return nil
 }
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxImportRows is the largest number of questions a single import accepts
+const MaxImportRows = 500
+
+// listSeparator separates the values of list columns in CSV imports
+const listSeparator = "|"
+
+// ImportError is a problem with one row of a question import. Rows are
+// numbered from 1, not counting the CSV header.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// QuestionImport reports the outcome of a question import
+type QuestionImport struct {
+	DryRun    bool       `json:"dryRun"`
+	Imported  int        `json:"imported"`  // 0 for dry runs
+	Questions []Question `json:"questions"` // as stored, or as they would be without IDs for dry runs
+}
+
+// csvColumns are the CSV columns a question import understands, named after
+// the QuestionInput fields they fill
+var csvColumns = map[string]func(in *QuestionInput, value string) error{
+	"type": func(in *QuestionInput, value string) error {
+		in.Type = value
+		return nil
+	},
+	"text": func(in *QuestionInput, value string) error {
+		in.Text = value
+		return nil
+	},
+	"points": func(in *QuestionInput, value string) error {
+		if value == "" {
+			return nil
+		}
+		var points float64
+		if err := parseFloatCell(value, &points); err != nil {
+			return err
+		}
+		in.Points = &points
+		return nil
+	},
+	"isTrue": func(in *QuestionInput, value string) error {
+		return parseBoolCell(value, &in.IsTrue)
+	},
+	"options": func(in *QuestionInput, value string) error {
+		for _, text := range splitList(value) {
+			in.Options = append(in.Options, OptionInput{Text: text})
+		}
+		return nil
+	},
+	"acceptedAnswers": func(in *QuestionInput, value string) error {
+		in.AcceptedAnswers = splitList(value)
+		return nil
+	},
+	// Patterns may contain the list separator themselves, so a cell holds one
+	"acceptedPatterns": func(in *QuestionInput, value string) error {
+		if value != "" {
+			in.AcceptedPatterns = []string{value}
+		}
+		return nil
+	},
+	"caseSensitive": func(in *QuestionInput, value string) error {
+		return parseBoolCell(value, &in.CaseSensitive)
+	},
+	"numericAnswer": func(in *QuestionInput, value string) error {
+		if value == "" {
+			return nil
+		}
+		var answer float64
+		if err := parseFloatCell(value, &answer); err != nil {
+			return err
+		}
+		in.NumericAnswer = &answer
+		return nil
+	},
+	"tolerance": func(in *QuestionInput, value string) error {
+		return parseFloatCell(value, &in.Tolerance)
+	},
+	"relativeTolerance": func(in *QuestionInput, value string) error {
+		return parseFloatCell(value, &in.RelativeTolerance)
+	},
+	"explanation": func(in *QuestionInput, value string) error {
+		in.Explanation = value
+		return nil
+	},
+}
+
+// correctColumn lists the 1-based numbers of the correct options. It is read
+// after the options column, wherever it appears.
+const correctColumn = "correct"
+
+// ParseQuestionCSV reads the questions of a CSV import. The header row names
+// the columns, in any order: the QuestionInput fields plus correct for the
+// numbers of the correct options. List cells separate their values with |.
+// It returns one input per row, with errors for the rows that could not be
+// read; it only fails as a whole when the CSV itself is unreadable.
+func ParseQuestionCSV(r io.Reader) ([]QuestionInput, []ImportError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	hasText := false
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		header[i] = name
+		if _, ok := csvColumns[name]; !ok && name != correctColumn {
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+		hasText = hasText || name == "text"
+	}
+	if !hasText {
+		return nil, nil, errors.New("CSV needs a text column")
+	}
+
+	inputs := []QuestionInput{}
+	rowErrors := []ImportError{}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || parseErr.Err != csv.ErrFieldCount {
+				return nil, nil, err
+			}
+			rowErrors = append(rowErrors, ImportError{Row: row, Error: fmt.Sprintf("expected %d columns, got %d", len(header), len(record))})
+			inputs = append(inputs, QuestionInput{})
+			continue
+		}
+
+		input, err := parseCSVRecord(header, record)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Row: row, Error: err.Error()})
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, rowErrors, nil
+}
+
+// parseCSVRecord fills a question input from one CSV row
+func parseCSVRecord(header, record []string) (QuestionInput, error) {
+	var input QuestionInput
+	correct := ""
+	for i, name := range header {
+		value := strings.TrimSpace(record[i])
+		if name == correctColumn {
+			correct = value
+			continue
+		}
+		if err := csvColumns[name](&input, value); err != nil {
+			return input, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	for _, number := range splitList(correct) {
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 || n > len(input.Options) {
+			return input, fmt.Errorf("correct: %q is not the number of an option", number)
+		}
+		input.Options[n-1].IsCorrect = true
+	}
+	return input, nil
+}
+
+// splitList splits a list cell into its trimmed, non-empty values
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func parseFloatCell(value string, out *float64) error {
+	if value == "" {
+		return nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	*out = number
+	return nil
+}
+
+func parseBoolCell(value string, out *bool) error {
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", value)
+	}
+	*out = b
+	return nil
+}
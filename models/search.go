@@ -0,0 +1,164 @@
+package models
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Search limits
+const (
+	MaxSearchQueryLength = 200
+	maxSearchTerms       = 10
+	snippetRadius        = 60 // bytes of context on each side of the first match
+)
+
+// Highlighted fields of a search result
+const (
+	HighlightTitle       = "title"
+	HighlightDescription = "description"
+	HighlightQuestion    = "question"
+)
+
+// stopWords are too common to be worth searching for. MongoDB's text index
+// ignores them as well.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "what": true, "when": true,
+	"where": true, "which": true, "who": true, "why": true, "with": true,
+}
+
+// SearchResult is a tryout matching a search
+type SearchResult struct {
+	Tryout     Tryout      `json:"tryout"`
+	Score      float64     `json:"score"` // relevance, higher is better
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Highlight is an excerpt of a matching field with the search terms wrapped in
+// <mark> tags. The rest of the excerpt is HTML-escaped.
+type Highlight struct {
+	Field      string              `json:"field"`
+	QuestionID *primitive.ObjectID `json:"questionId,omitempty"`
+	Snippet    string              `json:"snippet"`
+}
+
+// SearchTerms splits a query into lowercase words, dropping punctuation,
+// duplicates and stop words, so no search syntax from the query survives
+func SearchTerms(query string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, span := range wordSpans(query) {
+		term := strings.ToLower(query[span[0]:span[1]])
+		if stopWords[term] || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// CountMatches returns how many words of text match one of the terms
+func CountMatches(text string, terms []string) int {
+	count := 0
+	for _, span := range wordSpans(text) {
+		if matchesTerm(text[span[0]:span[1]], terms) {
+			count++
+		}
+	}
+	return count
+}
+
+// HighlightSnippet returns an excerpt of text around its first matching word,
+// with every matching word in the excerpt highlighted. It reports false when
+// no word matches.
+func HighlightSnippet(text string, terms []string) (string, bool) {
+	spans := wordSpans(text)
+	first := -1
+	for i, span := range spans {
+		if matchesTerm(text[span[0]:span[1]], terms) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	// Widen the excerpt to whole words within the radius of the match
+	from, to := first, first
+	for from > 0 && spans[first][0]-spans[from-1][0] <= snippetRadius {
+		from--
+	}
+	for to < len(spans)-1 && spans[to+1][1]-spans[first][1] <= snippetRadius {
+		to++
+	}
+	start, end := spans[from][0], spans[to][1]
+	if from == 0 {
+		start = 0
+	}
+	if to == len(spans)-1 {
+		end = len(text)
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	position := start
+	for _, span := range spans[from : to+1] {
+		word := text[span[0]:span[1]]
+		if !matchesTerm(word, terms) {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(text[position:span[0]]))
+		snippet.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		position = span[1]
+	}
+	snippet.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+	return strings.TrimSpace(snippet.String()), true
+}
+
+// matchesTerm reports whether a word matches a term. Terms of three or more
+// letters also match longer words, roughly following the stemming of
+// MongoDB's text index ("equation" matches "equations").
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if word == term || (len(term) >= 3 && strings.HasPrefix(word, term)) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordSpans returns the byte ranges of the words in text
+func wordSpans(text string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
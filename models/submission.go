@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Submission statuses
+const (
+	SubmissionPending = "pending" // waiting for manual grading
+	SubmissionGraded  = "graded"
+)
+
+// Answer statuses
+const (
+	AnswerAutoGraded = "auto"
+	AnswerPending    = "pending"
+	AnswerGraded     = "graded" // manually graded
+)
+
+// Submission represents a taker's graded answers for a tryout
+type Submission struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TryoutID    primitive.ObjectID `json:"tryoutId" bson:"tryoutId"`
+	AttemptID   primitive.ObjectID `json:"attemptId" bson:"attemptId"`
+	UserID      primitive.ObjectID `json:"userId" bson:"userId,omitempty"`
+	TakerName   string             `json:"takerName" bson:"takerName"`
+	Version     int                `json:"version" bson:"version,omitempty"` // tryout version graded against; 0 before versions existed
+	Answers     []Answer           `json:"answers" bson:"answers"`
+	Status      string             `json:"status" bson:"status"`
+	Score       *float64           `json:"score" bson:"score"` // nil while pending
+	MaxScore    float64            `json:"maxScore" bson:"maxScore"`
+	IsLate      bool               `json:"isLate" bson:"isLate"` // submitted within the grace period
+	SubmittedAt time.Time          `json:"submittedAt" bson:"submittedAt"`
+}
+
+// Answer is a single graded answer within a submission
+type Answer struct {
+	QuestionID      primitive.ObjectID   `json:"questionId" bson:"questionId"`
+	Answer          *bool                `json:"answer" bson:"answer"` // nil when left unanswered
+	SelectedOptions []primitive.ObjectID `json:"selectedOptions,omitempty" bson:"selectedOptions,omitempty"`
+	Text            string               `json:"text,omitempty" bson:"text,omitempty"`
+	IsCorrect       bool                 `json:"isCorrect" bson:"isCorrect"`
+	Status          string               `json:"status" bson:"status"`
+	Points          float64              `json:"points" bson:"points"`
+	MaxPoints       float64              `json:"maxPoints" bson:"maxPoints"`
+	Feedback        string               `json:"feedback,omitempty" bson:"feedback,omitempty"`
+	GradedAt        *time.Time           `json:"gradedAt,omitempty" bson:"gradedAt,omitempty"`
+}
+
+// SubmissionInput is used for submitting the answers of an attempt
+type SubmissionInput struct {
+	AttemptID string        `json:"attemptId" binding:"required"`
+	Answers   []AnswerInput `json:"answers" binding:"required,dive"`
+}
+
+// AnswerInput is a single answer in a submission request
+type AnswerInput struct {
+	QuestionID      string   `json:"questionId" binding:"required_without=Position"`
+	Position        int      `json:"position" binding:"omitempty,min=1"` // position in the attempt, instead of questionId
+	Answer          *bool    `json:"answer"`                             // true_false
+	SelectedOptions []string `json:"selectedOptions"`                    // multiple_choice and multi_select
+	Text            string   `json:"text"`                               // short_answer, numeric and essay
+}
+
+// GradeInput is used by a grader to score a manually graded answer
+type GradeInput struct {
+	Points   *float64 `json:"points" binding:"required,min=0"`
+	Feedback string   `json:"feedback"`
+}
+
+// GradingQueueItem is an answer waiting for manual grading
+type GradingQueueItem struct {
+	SubmissionID primitive.ObjectID `json:"submissionId"`
+	TakerName    string             `json:"takerName"`
+	QuestionID   primitive.ObjectID `json:"questionId"`
+	QuestionText string             `json:"questionText"`
+	Text         string             `json:"text"`
+	MaxPoints    float64            `json:"maxPoints"`
+	SubmittedAt  time.Time          `json:"submittedAt"`
+}
+
+// UnmarshalBSON decodes a submission, treating documents stored before
+// manual grading existed as fully graded
+func (s *Submission) UnmarshalBSON(data []byte) error {
+	type rawSubmission Submission
+	if err := bson.Unmarshal(data, (*rawSubmission)(s)); err != nil {
+		return err
+	}
+	if s.Status == "" {
+		s.Status = SubmissionGraded
+	}
+	for i := range s.Answers {
+		if s.Answers[i].Status == "" {
+			s.Answers[i].Status = AnswerAutoGraded
+		}
+	}
+	return nil
+}
+
+// Recalculate derives the submission's status and score from its answers.
+// The score stays nil until every answer has been graded.
+func (s *Submission) Recalculate() {
+	var score, maxScore float64
+	pending := false
+	for _, answer := range s.Answers {
+		if answer.Status == AnswerPending {
+			pending = true
+		}
+		score += answer.Points
+		maxScore += answer.MaxPoints
+	}
+
+	s.MaxScore = maxScore
+	if pending {
+		s.Status = SubmissionPending
+		s.Score = nil
+		return
+	}
+	s.Status = SubmissionGraded
+	s.Score = &score
+}
@@ -1,21 +1,62 @@
 package models
 
 import (
+	"errors"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Tryout statuses. Tryouts move from draft to published to archived.
+const (
+	TryoutDraft     = "draft"     // being written, only visible to those who manage it
+	TryoutPublished = "published" // listed and open for attempts
+	TryoutArchived  = "archived"  // no longer listed or open for attempts
+)
+
+// Availability windows of a tryout relative to a point in time
+const (
+	WindowUpcoming = "upcoming" // opensAt is still ahead
+	WindowOpen     = "open"     // published and inside its window, so attempts can start
+	WindowClosed   = "closed"   // closesAt has passed or the tryout is archived
+)
+
+// tryoutTransitions maps each status to the status a tryout can move to next
+var tryoutTransitions = map[string]string{
+	TryoutDraft:     TryoutPublished,
+	TryoutPublished: TryoutArchived,
+}
+
 // Tryout represents a quiz/tryout in the platform
 type Tryout struct {
 	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Title         string             `json:"title" bson:"title"`
 	Description   string             `json:"description" bson:"description"`
 	Category      string             `json:"category" bson:"category"`
-	Duration      int                `json:"duration" bson:"duration"` // in minutes
+	Duration      int                `json:"duration" bson:"duration"`                     // in minutes
+	OpensAt       *time.Time         `json:"opensAt,omitempty" bson:"opensAt,omitempty"`   // attempts cannot start before; drafts are published then
+	ClosesAt      *time.Time         `json:"closesAt,omitempty" bson:"closesAt,omitempty"` // attempts cannot start after; published tryouts are archived then
+	Shuffle       ShuffleSettings    `json:"shuffle" bson:"shuffle"`
 	HasSubmission bool               `json:"hasSubmission" bson:"hasSubmission"`
+	OwnerID       primitive.ObjectID `json:"ownerId" bson:"ownerId,omitempty"`       // author who created it; unset on tryouts created before accounts
+	Status        string             `json:"status" bson:"status,omitempty"`         // unset on tryouts created before statuses
+	Version       int                `json:"version" bson:"version,omitempty"`       // published version takers get; 0 until published
+	Draft         *TryoutDetails     `json:"draft,omitempty" bson:"draft,omitempty"` // edited details waiting for the next version
+	PublishedAt   *time.Time         `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	ArchivedAt    *time.Time         `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"`
 	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"` // set while in the trash
+}
+
+// TryoutDetails are the fields of a tryout that its versions record besides
+// its questions
+type TryoutDetails struct {
+	Title       string `json:"title" bson:"title"`
+	Description string `json:"description" bson:"description"`
+	Category    string `json:"category" bson:"category"`
+	Duration    int    `json:"duration" bson:"duration"` // in minutes
 }
 
 // TryoutInput is used for creating or updating a tryout
@@ -24,4 +65,106 @@ type TryoutInput struct {
 	Description string `json:"description" binding:"required"`
 	Category    string `json:"category" binding:"required"`
 	Duration    int    `json:"duration" binding:"required,min=1"`
+
+	OpensAt  *time.Time `json:"opensAt"`
+	ClosesAt *time.Time `json:"closesAt"`
+
+	Shuffle ShuffleSettings `json:"shuffle"`
+}
+
+// ShuffleSettings choose what each attempt of a tryout sees in its own order
+type ShuffleSettings struct {
+	Questions bool `json:"questions" bson:"questions"`
+	Options   bool `json:"options" bson:"options"` // of choice questions
+}
+
+// StatusInput is used for moving a tryout to another status
+type StatusInput struct {
+	Status string `json:"status" binding:"required,oneof=draft published archived"`
+}
+
+// UnmarshalBSON decodes a tryout, treating tryouts created before statuses
+// existed as published, since they were already listed
+func (t *Tryout) UnmarshalBSON(data []byte) error {
+	type rawTryout Tryout
+	if err := bson.Unmarshal(data, (*rawTryout)(t)); err != nil {
+		return err
+	}
+	if t.Status == "" {
+		t.Status = TryoutPublished
+	}
+	return nil
+}
+
+// Validate checks that the availability window of the input is not empty
+func (in *TryoutInput) Validate() error {
+	if in.OpensAt != nil && in.ClosesAt != nil && !in.ClosesAt.After(*in.OpensAt) {
+		return errors.New("closesAt must be after opensAt")
+	}
+	return nil
+}
+
+// Window returns whether the tryout is upcoming, open or closed at now
+func (t *Tryout) Window(now time.Time) string {
+	switch {
+	case t.Status == TryoutArchived || (t.ClosesAt != nil && !now.Before(*t.ClosesAt)):
+		return WindowClosed
+	case t.OpensAt != nil && now.Before(*t.OpensAt):
+		return WindowUpcoming
+	case t.Status == TryoutPublished:
+		return WindowOpen
+	}
+	// A draft inside its window is neither open nor closed yet
+	return ""
+}
+
+// Details returns the details of the tryout as takers get them
+func (t *Tryout) Details() TryoutDetails {
+	return TryoutDetails{Title: t.Title, Description: t.Description, Category: t.Category, Duration: t.Duration}
+}
+
+// WorkingDetails returns the details of the working copy: the draft, if
+// there are edits waiting for the next version, or else the tryout's own
+func (t *Tryout) WorkingDetails() TryoutDetails {
+	if t.Draft != nil {
+		return *t.Draft
+	}
+	return t.Details()
+}
+
+// SetDetails edits the details of the tryout. Like its questions, the
+// details of a tryout with a published version are only edited in its
+// working copy, the draft, so takers keep getting the published ones until
+// the next version is published.
+func (t *Tryout) SetDetails(details TryoutDetails) {
+	switch {
+	case t.Version == 0:
+		t.Title, t.Description, t.Category, t.Duration = details.Title, details.Description, details.Category, details.Duration
+	case details == t.Details():
+		t.Draft = nil
+	default:
+		t.Draft = &details
+	}
+}
+
+// QuestionsLocked reports whether the questions of the tryout must not change.
+// Tryouts published before versions existed have no version, so takers get
+// and are graded against the working copy itself; it stays as it is until a
+// version is published.
+func (t *Tryout) QuestionsLocked() bool {
+	return t.Version == 0 && (t.Status != TryoutDraft || t.HasSubmission)
+}
+
+// CanMoveTo reports whether the tryout can move from its status to status
+func (t *Tryout) CanMoveTo(status string) bool {
+	return tryoutTransitions[t.Status] == status
+}
+
+// IsValidTryoutStatus reports whether status is one of the tryout statuses
+func IsValidTryoutStatus(status string) bool {
+	switch status {
+	case TryoutDraft, TryoutPublished, TryoutArchived:
+		return true
+	}
+	return false
 }
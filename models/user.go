@@ -0,0 +1,86 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// User roles
+const (
+	RoleAdmin   = "admin"   // can do everything
+	RoleAuthor  = "author"  // creates tryouts and manages their own
+	RoleGrader  = "grader"  // grades the submissions of any tryout
+	RoleStudent = "student" // takes tryouts and sees their own submissions
+)
+
+// User is an account that can sign in to the platform
+type User struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email        string             `json:"email" bson:"email"` // normalized with NormalizeEmail
+	Name         string             `json:"name" bson:"name"`
+	Role         string             `json:"role" bson:"role"`
+	PasswordHash string             `json:"-" bson:"passwordHash"` // bcrypt
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// RegisterInput is used for creating an account
+type RegisterInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // bcrypt ignores bytes past 72
+}
+
+// RoleInput is used by admins for changing the role of a user
+type RoleInput struct {
+	Role string `json:"role" binding:"required,oneof=admin author grader student"`
+}
+
+// LoginInput is used for signing in
+type LoginInput struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshInput is used for exchanging a refresh token for new tokens
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// TokenPair is returned when signing in or refreshing
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"` // seconds until the access token expires
+}
+
+// UnmarshalBSON decodes a user, treating accounts created before roles
+// existed as students
+func (u *User) UnmarshalBSON(data []byte) error {
+	type rawUser User
+	if err := bson.Unmarshal(data, (*rawUser)(u)); err != nil {
+		return err
+	}
+	if u.Role == "" {
+		u.Role = RoleStudent
+	}
+	return nil
+}
+
+// IsValidRole reports whether role is one of the user roles
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleAuthor, RoleGrader, RoleStudent:
+		return true
+	}
+	return false
+}
+
+// NormalizeEmail returns the form in which emails are stored and looked up
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DraftVersion names the unpublished working copy of a tryout wherever a
+// version number is expected
+const DraftVersion = "draft"
+
+// TryoutVersion is an immutable snapshot of a tryout's content, taken each
+// time the tryout is published. Attempts and submissions refer to the version
+// they were taken against by its number.
+type TryoutVersion struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TryoutID      primitive.ObjectID `json:"tryoutId" bson:"tryoutId"`
+	Number        int                `json:"number" bson:"number"` // 1 for the first published version
+	Title         string             `json:"title" bson:"title"`
+	Description   string             `json:"description" bson:"description"`
+	Category      string             `json:"category" bson:"category"`
+	Duration      int                `json:"duration" bson:"duration"` // in minutes
+	Questions     []Question         `json:"questions,omitempty" bson:"questions"`
+	QuestionCount int                `json:"questionCount" bson:"questionCount"`
+	Submissions   int                `json:"submissions" bson:"-"`                     // computed for version listings
+	PublishedBy   primitive.ObjectID `json:"publishedBy" bson:"publishedBy,omitempty"` // unset when published by the scheduler
+	PublishedAt   time.Time          `json:"publishedAt" bson:"publishedAt"`
+}
+
+// VersionHistory lists the published versions of a tryout
+type VersionHistory struct {
+	Current         int             `json:"current"`         // version takers get; 0 until first published
+	HasDraftChanges bool            `json:"hasDraftChanges"` // the working copy differs from the current version
+	Versions        []TryoutVersion `json:"versions"`        // oldest first, without questions
+}
+
+// VersionDiff lists the changes between two versions of a tryout. Questions
+// are matched by ID, so an edited question shows up as changed.
+type VersionDiff struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Fields  []FieldChange    `json:"fields"` // changed tryout fields
+	Added   []Question       `json:"added"`
+	Removed []Question       `json:"removed"`
+	Changed []QuestionChange `json:"changed"`
+}
+
+// QuestionChange is a question present in both versions of a diff
+type QuestionChange struct {
+	QuestionID primitive.ObjectID `json:"questionId"`
+	Fields     []string           `json:"fields"` // JSON names of the changed fields
+	From       Question           `json:"from"`
+	To         Question           `json:"to"`
+}
+
+// NewTryoutVersion snapshots the working copy of a tryout and its questions
+// as the version that follows the tryout's current one
+func NewTryoutVersion(tryout Tryout, questions []Question, publishedBy primitive.ObjectID, at time.Time) TryoutVersion {
+	details := tryout.WorkingDetails()
+	return TryoutVersion{
+		TryoutID:      tryout.ID,
+		Number:        tryout.Version + 1,
+		Title:         details.Title,
+		Description:   details.Description,
+		Category:      details.Category,
+		Duration:      details.Duration,
+		Questions:     questions,
+		QuestionCount: len(questions),
+		PublishedBy:   publishedBy,
+		PublishedAt:   at,
+	}
+}
+
+// Label names the version in diffs: its number, or draft for a working copy
+// that has not been published. The empty version 0 stands for a tryout that
+// has never been published.
+func (v *TryoutVersion) Label() string {
+	if v.PublishedAt.IsZero() && v.Number > 0 {
+		return DraftVersion
+	}
+	return strconv.Itoa(v.Number)
+}
+
+// FindQuestion returns the question of the version with the given ID
+func (v *TryoutVersion) FindQuestion(id primitive.ObjectID) (Question, bool) {
+	for _, question := range v.Questions {
+		if question.ID == id {
+			return question, true
+		}
+	}
+	return Question{}, false
+}
+
+// IsEmpty reports whether the two versions of the diff have the same content
+func (d *VersionDiff) IsEmpty() bool {
+	return len(d.Fields) == 0 && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffVersions compares the content of two versions of a tryout
+func DiffVersions(from, to TryoutVersion) (VersionDiff, error) {
+	diff := VersionDiff{
+		From:    from.Label(),
+		To:      to.Label(),
+		Fields:  []FieldChange{},
+		Added:   []Question{},
+		Removed: []Question{},
+		Changed: []QuestionChange{},
+	}
+
+	var err error
+	if diff.Fields, err = diffFields(from.details(), to.details(), nil); err != nil {
+		return diff, err
+	}
+
+	for _, question := range to.Questions {
+		previous, ok := from.FindQuestion(question.ID)
+		if !ok {
+			diff.Added = append(diff.Added, question)
+			continue
+		}
+		changed, err := changedQuestionFields(previous, question)
+		if err != nil {
+			return diff, err
+		}
+		if len(changed) > 0 {
+			diff.Changed = append(diff.Changed, QuestionChange{QuestionID: question.ID, Fields: changed, From: previous, To: question})
+		}
+	}
+	for _, question := range from.Questions {
+		if _, ok := to.FindQuestion(question.ID); !ok {
+			diff.Removed = append(diff.Removed, question)
+		}
+	}
+	return diff, nil
+}
+
+func (v *TryoutVersion) details() TryoutDetails {
+	return TryoutDetails{Title: v.Title, Description: v.Description, Category: v.Category, Duration: v.Duration}
+}
+
+// unversionedQuestionFields are the JSON fields of a question that do not
+// count as changes to its content
+var unversionedQuestionFields = []string{"id", "tryoutId", "createdAt", "updatedAt", "deletedAt"}
+
+// changedQuestionFields returns the sorted JSON names of the content fields
+// that differ between two versions of a question
+func changedQuestionFields(from, to Question) ([]string, error) {
+	changes, err := diffFields(from, to, unversionedQuestionFields)
+	if err != nil {
+		return nil, err
+	}
+	changed := []string{}
+	for _, change := range changes {
+		changed = append(changed, change.Field)
+	}
+	return changed, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttemptRepository stores timed attempts
+type AttemptRepository interface {
+	Create(ctx context.Context, attempt *models.Attempt) error
+	Get(ctx context.Context, id primitive.ObjectID) (models.Attempt, error)
+	// Expire closes an attempt if it is still in progress
+	Expire(ctx context.Context, id primitive.ObjectID) error
+}
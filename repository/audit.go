@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditFilter narrows down the audit log of a tryout. Zero values are ignored.
+type AuditFilter struct {
+	EntityID *primitive.ObjectID
+	Action   string
+}
+
+// AuditRepository reads the append-only audit log of content changes. The
+// mutations of the tryout, question and version repositories append the
+// entries of the changes they make, by the actor they are given, as part of
+// the change itself: either both are stored or neither is. Entries outlive
+// the tryouts they belong to, even once purged from the trash.
+type AuditRepository interface {
+	// ListByTryout returns the entries of a tryout, newest first
+	ListByTryout(ctx context.Context, tryoutID primitive.ObjectID, filter AuditFilter) ([]models.AuditEntry, error)
+}
+
+// statusAction is the audited action of moving a tryout to a status
+func statusAction(status string) string {
+	switch status {
+	case models.TryoutPublished:
+		return models.ActionPublished
+	case models.TryoutArchived:
+		return models.ActionArchived
+	}
+	return models.ActionUpdated
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrphanReport lists the IDs of documents whose tryout no longer exists
+type OrphanReport struct {
+	Questions   []primitive.ObjectID
+	Attempts    []primitive.ObjectID
+	Submissions []primitive.ObjectID
+	Versions    []primitive.ObjectID
+}
+
+// Total returns the number of orphaned documents in the report
+func (r OrphanReport) Total() int {
+	return len(r.Questions) + len(r.Attempts) + len(r.Submissions) + len(r.Versions)
+}
+
+// PurgeReport counts the documents permanently removed from the trash
+type PurgeReport struct {
+	Tryouts   int
+	Questions int // including the questions of purged tryouts
+}
+
+// MaintenanceRepository finds and repairs inconsistencies across collections
+type MaintenanceRepository interface {
+	FindOrphans(ctx context.Context) (OrphanReport, error)
+	// DeleteOrphans removes the documents listed in a report
+	DeleteOrphans(ctx context.Context, report OrphanReport) error
+	// PurgeDeleted permanently removes tryouts and questions moved to the
+	// trash before the given time. Purged tryouts take their questions,
+	// versions, attempts and submissions with them.
+	PurgeDeleted(ctx context.Context, before time.Time) (PurgeReport, error)
+}
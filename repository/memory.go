@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"sync"
+
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// memoryStore holds every collection of the in-memory backend behind a single
+// lock, so multi-collection operations are as atomic as MongoDB transactions
+type memoryStore struct {
+	mu          sync.RWMutex
+	tryouts     []models.Tryout
+	questions   []models.Question
+	submissions []models.Submission
+	attempts    []models.Attempt
+	users       []models.User
+	versions    []models.TryoutVersion
+	audit       []models.AuditEntry
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+// clone deep-copies a document through a BSON round trip, so documents read
+// from memory behave exactly like documents decoded from MongoDB
+func clone[T any](value T) T {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		panic("repository: cannot encode document: " + err.Error())
+	}
+
+	var copied T
+	if err := bson.Unmarshal(data, &copied); err != nil {
+		panic("repository: cannot decode document: " + err.Error())
+	}
+	return copied
+}
+
+// indexWhere returns the index of the first item matching match, or -1
+func indexWhere[T any](items []T, match func(*T) bool) int {
+	for i := range items {
+		if match(&items[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterClones returns copies of the items matching match
+func filterClones[T any](items []T, match func(*T) bool) []T {
+	matched := []T{}
+	for i := range items {
+		if match(&items[i]) {
+			matched = append(matched, clone(items[i]))
+		}
+	}
+	return matched
+}
+
+// removeWhere returns the items not matching match, reusing the backing array
+func removeWhere[T any](items []T, match func(*T) bool) []T {
+	kept := items[:0]
+	for i := range items {
+		if !match(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+	return kept
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAttemptRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAttemptRepository) Create(ctx context.Context, attempt *models.Attempt) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	r.store.attempts = append(r.store.attempts, clone(*attempt))
+	return nil
+}
+
+func (r *memoryAttemptRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Attempt, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.attemptIndex(id)
+	if i < 0 {
+		return models.Attempt{}, ErrNotFound
+	}
+	return clone(r.store.attempts[i]), nil
+}
+
+func (r *memoryAttemptRepository) Expire(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.attemptIndex(id)
+	if i >= 0 && r.store.attempts[i].Status == models.AttemptInProgress {
+		r.store.attempts[i].Status = models.AttemptExpired
+	}
+	return nil
+}
+
+// attemptIndex must be called with the lock held
+func (s *memoryStore) attemptIndex(id primitive.ObjectID) int {
+	return indexWhere(s.attempts, func(attempt *models.Attempt) bool { return attempt.ID == id })
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAuditRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAuditRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID, filter AuditFilter) ([]models.AuditEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entries := filterClones(r.store.audit, func(entry *models.AuditEntry) bool {
+		return entry.TryoutID == tryoutID &&
+			(filter.EntityID == nil || entry.EntityID == *filter.EntityID) &&
+			(filter.Action == "" || entry.Action == filter.Action)
+	})
+
+	// Entries are appended in order, so later ones win ties
+	slices.Reverse(entries)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.After(entries[j].At) })
+	return entries, nil
+}
+
+// appendAudit records entries in the audit log. It must be called with the
+// lock held, by the mutation whose change the entries describe.
+func (s *memoryStore) appendAudit(entries ...models.AuditEntry) {
+	for _, entry := range entries {
+		entry.ID = primitive.NewObjectID()
+		s.audit = append(s.audit, clone(entry))
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryMaintenanceRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryMaintenanceRepository) FindOrphans(ctx context.Context) (OrphanReport, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	orphaned := func(tryoutID primitive.ObjectID) bool { return r.store.tryoutIndex(tryoutID) < 0 }
+
+	var report OrphanReport
+	for _, question := range r.store.questions {
+		if orphaned(question.TryoutID) {
+			report.Questions = append(report.Questions, question.ID)
+		}
+	}
+	for _, attempt := range r.store.attempts {
+		if orphaned(attempt.TryoutID) {
+			report.Attempts = append(report.Attempts, attempt.ID)
+		}
+	}
+	for _, submission := range r.store.submissions {
+		if orphaned(submission.TryoutID) {
+			report.Submissions = append(report.Submissions, submission.ID)
+		}
+	}
+	for _, version := range r.store.versions {
+		if orphaned(version.TryoutID) {
+			report.Versions = append(report.Versions, version.ID)
+		}
+	}
+	return report, nil
+}
+
+func (r *memoryMaintenanceRepository) DeleteOrphans(ctx context.Context, report OrphanReport) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.questions = removeWhere(r.store.questions, func(question *models.Question) bool { return slices.Contains(report.Questions, question.ID) })
+	r.store.attempts = removeWhere(r.store.attempts, func(attempt *models.Attempt) bool { return slices.Contains(report.Attempts, attempt.ID) })
+	r.store.submissions = removeWhere(r.store.submissions, func(submission *models.Submission) bool { return slices.Contains(report.Submissions, submission.ID) })
+	r.store.versions = removeWhere(r.store.versions, func(version *models.TryoutVersion) bool { return slices.Contains(report.Versions, version.ID) })
+	return nil
+}
+
+func (r *memoryMaintenanceRepository) PurgeDeleted(ctx context.Context, before time.Time) (PurgeReport, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := map[primitive.ObjectID]bool{}
+	r.store.tryouts = removeWhere(r.store.tryouts, func(tryout *models.Tryout) bool {
+		if tryout.DeletedAt != nil && tryout.DeletedAt.Before(before) {
+			purged[tryout.ID] = true
+		}
+		return purged[tryout.ID]
+	})
+
+	var report PurgeReport
+	report.Tryouts = len(purged)
+	r.store.questions = removeWhere(r.store.questions, func(question *models.Question) bool {
+		remove := purged[question.TryoutID] || (question.DeletedAt != nil && question.DeletedAt.Before(before))
+		if remove {
+			report.Questions++
+		}
+		return remove
+	})
+	r.store.attempts = removeWhere(r.store.attempts, func(attempt *models.Attempt) bool { return purged[attempt.TryoutID] })
+	r.store.submissions = removeWhere(r.store.submissions, func(submission *models.Submission) bool { return purged[submission.TryoutID] })
+	r.store.versions = removeWhere(r.store.versions, func(version *models.TryoutVersion) bool { return purged[version.TryoutID] })
+	return report, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"quiz-platform/models"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryQuestionRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryQuestionRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Question, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	questions := []models.Question{}
+	for _, i := range r.store.orderedQuestionIndexes(tryoutID) {
+		questions = append(questions, clone(r.store.questions[i]))
+	}
+	return questions, nil
+}
+
+func (r *memoryQuestionRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Question, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.activeQuestionIndex(id)
+	if i < 0 {
+		return models.Question{}, ErrNotFound
+	}
+	return clone(r.store.questions[i]), nil
+}
+
+func (r *memoryQuestionRepository) ListForTaker(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TakerQuestion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	questions := []models.TakerQuestion{}
+	for _, i := range r.store.orderedQuestionIndexes(tryoutID) {
+		questions = append(questions, clone(r.store.questions[i].TakerView()))
+	}
+	return questions, nil
+}
+
+func (r *memoryQuestionRepository) GetForTaker(ctx context.Context, id primitive.ObjectID) (models.TakerQuestion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.activeQuestionIndex(id)
+	if i < 0 {
+		return models.TakerQuestion{}, ErrNotFound
+	}
+	return clone(r.store.questions[i].TakerView()), nil
+}
+
+func (r *memoryQuestionRepository) Create(ctx context.Context, question *models.Question, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if question.ID.IsZero() {
+		question.ID = primitive.NewObjectID()
+	}
+
+	order := r.store.orderedQuestionIndexes(question.TryoutID)
+	insert := question.Position >= 1 && question.Position <= len(order)
+	if !insert {
+		question.Position = 1
+		if len(order) > 0 {
+			question.Position = r.store.questions[order[len(order)-1]].Position + 1
+		}
+	}
+	entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, question.ID, question.TryoutID, nil, question, question.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if insert {
+		for n, i := range order {
+			position := n + 1
+			if position >= question.Position {
+				position++
+			}
+			r.store.questions[i].Position = position
+		}
+	}
+	r.store.questions = append(r.store.questions, clone(*question))
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryQuestionRepository) CreateMany(ctx context.Context, tryoutID primitive.ObjectID, questions []models.Question, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	next := 1
+	if order := r.store.orderedQuestionIndexes(tryoutID); len(order) > 0 {
+		next = r.store.questions[order[len(order)-1]].Position + 1
+	}
+	entries := make([]models.AuditEntry, len(questions))
+	for i := range questions {
+		question := &questions[i]
+		if question.ID.IsZero() {
+			question.ID = primitive.NewObjectID()
+		}
+		question.TryoutID = tryoutID
+		question.Position = next + i
+		entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, question.ID, tryoutID, nil, question, question.CreatedAt)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry
+	}
+
+	for _, question := range questions {
+		r.store.questions = append(r.store.questions, clone(question))
+	}
+	r.store.appendAudit(entries...)
+	return nil
+}
+
+func (r *memoryQuestionRepository) Update(ctx context.Context, question *models.Question, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeQuestionIndex(question.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	// Only the editable fields are overwritten
+	updated := clone(*question)
+	stored := &r.store.questions[i]
+	updated.TryoutID = stored.TryoutID
+	updated.Position = stored.Position
+	updated.CreatedAt = stored.CreatedAt
+	entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityQuestion, stored.ID, stored.TryoutID, stored, updated, updated.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	r.store.appendAudit(entry)
+	*stored = updated
+	return nil
+}
+
+func (r *memoryQuestionRepository) Reorder(ctx context.Context, tryoutID primitive.ObjectID, ids []primitive.ObjectID, at time.Time, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	order := r.store.orderedQuestionIndexes(tryoutID)
+	positions := make(map[primitive.ObjectID]int, len(ids))
+	for n, id := range ids {
+		positions[id] = n + 1
+	}
+	if len(positions) != len(ids) || len(ids) != len(order) {
+		return ErrOrderMismatch
+	}
+	before := models.QuestionOrder{QuestionIDs: []primitive.ObjectID{}}
+	for _, i := range order {
+		if _, ok := positions[r.store.questions[i].ID]; !ok {
+			return ErrOrderMismatch
+		}
+		before.QuestionIDs = append(before.QuestionIDs, r.store.questions[i].ID)
+	}
+
+	entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityTryout, tryoutID, tryoutID, before, models.QuestionOrder{QuestionIDs: ids}, at)
+	if err != nil {
+		return err
+	}
+	r.store.appendAudit(entry)
+	for _, i := range order {
+		question := &r.store.questions[i]
+		if position := positions[question.ID]; question.Position != position {
+			question.Position = position
+			question.UpdatedAt = at
+		}
+	}
+	return nil
+}
+
+func (r *memoryQuestionRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeQuestionIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	question := &r.store.questions[i]
+	entry, err := models.NewAuditEntry(actor, models.ActionDeleted, models.EntityQuestion, id, question.TryoutID, question, nil, at)
+	if err != nil {
+		return err
+	}
+	r.store.appendAudit(entry)
+	question.DeletedAt = &at
+	return nil
+}
+
+func (r *memoryQuestionRepository) Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.questionIndex(id)
+	if i < 0 || r.store.questions[i].DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	tryout := r.store.tryoutIndex(r.store.questions[i].TryoutID)
+	if tryout < 0 {
+		return ErrNotFound
+	}
+	if err := check(clone(r.store.tryouts[tryout])); err != nil {
+		return err
+	}
+	if r.store.tryouts[tryout].DeletedAt != nil {
+		return ErrTryoutDeleted
+	}
+
+	question := clone(r.store.questions[i])
+	question.DeletedAt = nil
+	entry, err := models.NewAuditEntry(actor, models.ActionRestored, models.EntityQuestion, id, question.TryoutID, nil, question, at)
+	if err != nil {
+		return err
+	}
+	r.store.questions[i] = question
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryQuestionRepository) ListDeleted(ctx context.Context) ([]models.Question, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	questions := filterClones(r.store.questions, func(question *models.Question) bool {
+		return question.DeletedAt != nil && r.store.activeTryoutIndex(question.TryoutID) >= 0
+	})
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].DeletedAt.After(*questions[j].DeletedAt) })
+	return questions, nil
+}
+
+// orderedQuestionIndexes returns the indexes of the questions of a tryout
+// outside the trash in listing order. It must be called with the lock held.
+func (s *memoryStore) orderedQuestionIndexes(tryoutID primitive.ObjectID) []int {
+	order := []int{}
+	for i := range s.questions {
+		if s.questions[i].TryoutID == tryoutID && s.questions[i].DeletedAt == nil {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return compareQuestions(&s.questions[order[a]], &s.questions[order[b]]) < 0
+	})
+	return order
+}
+
+// compareQuestions orders two questions by position, then by creation and ID
+func compareQuestions(a, b *models.Question) int {
+	if a.Position != b.Position {
+		return a.Position - b.Position
+	}
+	if order := a.CreatedAt.Compare(b.CreatedAt); order != 0 {
+		return order
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// questionIndex must be called with the lock held
+func (s *memoryStore) questionIndex(id primitive.ObjectID) int {
+	return indexWhere(s.questions, func(question *models.Question) bool { return question.ID == id })
+}
+
+// activeQuestionIndex is like questionIndex but skips questions in the trash
+func (s *memoryStore) activeQuestionIndex(id primitive.ObjectID) int {
+	return indexWhere(s.questions, func(question *models.Question) bool { return question.ID == id && question.DeletedAt == nil })
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySubmissionRepository struct {
+	store *memoryStore
+}
+
+func (r *memorySubmissionRepository) Create(ctx context.Context, submission *models.Submission) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	attempt := r.store.attemptIndex(submission.AttemptID)
+	if attempt < 0 || r.store.attempts[attempt].Status != models.AttemptInProgress {
+		return ErrAttemptClosed
+	}
+
+	if submission.ID.IsZero() {
+		submission.ID = primitive.NewObjectID()
+	}
+	r.store.submissions = append(r.store.submissions, clone(*submission))
+
+	submissionID := submission.ID
+	r.store.attempts[attempt].Status = models.AttemptSubmitted
+	r.store.attempts[attempt].SubmissionID = &submissionID
+
+	if tryout := r.store.tryoutIndex(submission.TryoutID); tryout >= 0 {
+		r.store.tryouts[tryout].HasSubmission = true
+	}
+	return nil
+}
+
+func (r *memorySubmissionRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error) {
+	submissions := r.find(func(submission *models.Submission) bool {
+		return submission.TryoutID == tryoutID
+	})
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].SubmittedAt.After(submissions[j].SubmittedAt)
+	})
+	return submissions, nil
+}
+
+func (r *memorySubmissionRepository) ListPending(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error) {
+	submissions := r.find(func(submission *models.Submission) bool {
+		return submission.TryoutID == tryoutID && submission.Status == models.SubmissionPending
+	})
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].SubmittedAt.Before(submissions[j].SubmittedAt)
+	})
+	return submissions, nil
+}
+
+func (r *memorySubmissionRepository) find(match func(*models.Submission) bool) []models.Submission {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return filterClones(r.store.submissions, match)
+}
+
+func (r *memorySubmissionRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Submission, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.submissionIndex(id)
+	if i < 0 {
+		return models.Submission{}, ErrNotFound
+	}
+	return clone(r.store.submissions[i]), nil
+}
+
+func (r *memorySubmissionRepository) Modify(ctx context.Context, id primitive.ObjectID, change func(*models.Submission) error) (models.Submission, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.submissionIndex(id)
+	if i < 0 {
+		return models.Submission{}, ErrNotFound
+	}
+
+	submission := clone(r.store.submissions[i])
+	if err := change(&submission); err != nil {
+		return submission, err
+	}
+	r.store.submissions[i] = clone(submission)
+	return submission, nil
+}
+
+// submissionIndex must be called with the lock held
+func (s *memoryStore) submissionIndex(id primitive.ObjectID) int {
+	return indexWhere(s.submissions, func(submission *models.Submission) bool { return submission.ID == id })
+}
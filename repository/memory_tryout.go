@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"quiz-platform/models"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryTryoutRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryTryoutRepository) List(ctx context.Context, filter TryoutFilter, opts ListOptions) (TryoutPage, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tryouts := filterClones(r.store.tryouts, func(tryout *models.Tryout) bool { return matchesFilter(tryout, filter) })
+	total := int64(len(tryouts))
+
+	field := opts.sortField()
+	less := func(a, b *models.Tryout) bool {
+		order := compareTryouts(a, b, field)
+		if opts.Descending {
+			order = -order
+		}
+		return order < 0
+	}
+	sort.SliceStable(tryouts, func(i, j int) bool { return less(&tryouts[i], &tryouts[j]) })
+
+	if opts.After != nil {
+		i := r.store.tryoutIndex(*opts.After)
+		if i < 0 {
+			return TryoutPage{}, ErrInvalidCursor
+		}
+		cursor := r.store.tryouts[i]
+		start := sort.Search(len(tryouts), func(j int) bool { return less(&cursor, &tryouts[j]) })
+		tryouts = tryouts[start:]
+	}
+
+	if opts.Skip > 0 {
+		tryouts = tryouts[min(opts.Skip, int64(len(tryouts))):]
+	}
+
+	page := TryoutPage{Tryouts: tryouts, Total: total}
+	if opts.Limit > 0 && int64(len(tryouts)) > opts.Limit {
+		page.Tryouts = tryouts[:opts.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// matchesFilter reports whether a tryout is listed under a filter
+func matchesFilter(tryout *models.Tryout, filter TryoutFilter) bool {
+	if tryout.DeletedAt != nil {
+		return false
+	}
+	if filter.Title != "" && !strings.Contains(strings.ToLower(tryout.Title), strings.ToLower(filter.Title)) {
+		return false
+	}
+	if filter.Category != "" && tryout.Category != filter.Category {
+		return false
+	}
+	if filter.CreatedFrom != nil && tryout.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && tryout.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, tryout.Status) {
+		return false
+	}
+	if filter.VisibleTo != nil && tryout.Status != models.TryoutPublished &&
+		(filter.VisibleTo.IsZero() || tryout.OwnerID != *filter.VisibleTo) {
+		return false
+	}
+	if filter.Window != "" && tryout.Window(filter.WindowAt) != filter.Window {
+		return false
+	}
+	return true
+}
+
+// compareTryouts orders two tryouts by a sort field, breaking ties by ID
+func compareTryouts(a, b *models.Tryout, field string) int {
+	order := 0
+	switch field {
+	case SortByTitle:
+		order = strings.Compare(a.Title, b.Title)
+	case SortByDuration:
+		order = a.Duration - b.Duration
+	default:
+		order = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if order != 0 {
+		return order
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+func (r *memoryTryoutRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Tryout, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.activeTryoutIndex(id)
+	if i < 0 {
+		return models.Tryout{}, ErrNotFound
+	}
+	return clone(r.store.tryouts[i]), nil
+}
+
+func (r *memoryTryoutRepository) Create(ctx context.Context, tryout *models.Tryout, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if tryout.ID.IsZero() {
+		tryout.ID = primitive.NewObjectID()
+	}
+	entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityTryout, tryout.ID, tryout.ID, nil, tryout, tryout.CreatedAt)
+	if err != nil {
+		return err
+	}
+	r.store.tryouts = append(r.store.tryouts, clone(*tryout))
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryTryoutRepository) CreateWithQuestions(ctx context.Context, tryout *models.Tryout, questions []models.Question, rename bool, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	active := filterClones(r.store.tryouts, func(tryout *models.Tryout) bool { return tryout.DeletedAt == nil })
+	title := freeTitle(tryout.Title, active)
+	if title != tryout.Title && !rename {
+		return ErrTitleTaken
+	}
+	tryout.Title = title
+
+	if tryout.ID.IsZero() {
+		tryout.ID = primitive.NewObjectID()
+	}
+	prepareQuestions(tryout.ID, questions)
+	entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityTryout, tryout.ID, tryout.ID, nil, tryout, tryout.CreatedAt)
+	if err != nil {
+		return err
+	}
+	entries := []models.AuditEntry{entry}
+	for _, question := range questions {
+		entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, question.ID, tryout.ID, nil, question, question.CreatedAt)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	r.store.tryouts = append(r.store.tryouts, clone(*tryout))
+	for _, question := range questions {
+		r.store.questions = append(r.store.questions, clone(question))
+	}
+	r.store.appendAudit(entries...)
+	return nil
+}
+
+func (r *memoryTryoutRepository) Update(ctx context.Context, tryout *models.Tryout, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeTryoutIndex(tryout.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	updated := clone(*tryout)
+	stored := clone(r.store.tryouts[i])
+	stored.SetDetails(updated.Details())
+	stored.OpensAt = updated.OpensAt
+	stored.ClosesAt = updated.ClosesAt
+	stored.Shuffle = updated.Shuffle
+	stored.UpdatedAt = updated.UpdatedAt
+	entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityTryout, stored.ID, stored.ID, r.store.tryouts[i], stored, updated.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	r.store.tryouts[i] = stored
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryTryoutRepository) SetStatus(ctx context.Context, id primitive.ObjectID, from, to string, at time.Time, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeTryoutIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if r.store.tryouts[i].Status != from {
+		return ErrStatusChanged
+	}
+
+	stored := clone(r.store.tryouts[i])
+	stored.Status = to
+	stored.UpdatedAt = at
+	switch to {
+	case models.TryoutPublished:
+		stored.PublishedAt = &at
+	case models.TryoutArchived:
+		stored.ArchivedAt = &at
+	}
+	entry, err := models.NewAuditEntry(actor, statusAction(to), models.EntityTryout, id, id, r.store.tryouts[i], stored, at)
+	if err != nil {
+		return err
+	}
+	r.store.tryouts[i] = stored
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryTryoutRepository) ListDue(ctx context.Context, now time.Time) ([]models.Tryout, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return filterClones(r.store.tryouts, func(tryout *models.Tryout) bool {
+		if tryout.DeletedAt != nil {
+			return false
+		}
+		switch tryout.Status {
+		case models.TryoutDraft:
+			return tryout.OpensAt != nil && !now.Before(*tryout.OpensAt) && tryout.Window(now) != models.WindowClosed
+		case models.TryoutPublished:
+			return tryout.ClosesAt != nil && !now.Before(*tryout.ClosesAt)
+		}
+		return false
+	}), nil
+}
+
+func (r *memoryTryoutRepository) Delete(ctx context.Context, id primitive.ObjectID, force bool, at time.Time, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeTryoutIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if r.store.tryouts[i].HasSubmission && !force {
+		return ErrHasSubmissions
+	}
+
+	entry, err := models.NewAuditEntry(actor, models.ActionDeleted, models.EntityTryout, id, id, r.store.tryouts[i], nil, at)
+	if err != nil {
+		return err
+	}
+	r.store.appendAudit(entry)
+	r.store.tryouts[i].DeletedAt = &at
+	for j := range r.store.questions {
+		question := &r.store.questions[j]
+		if question.TryoutID == id && question.DeletedAt == nil {
+			question.DeletedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *memoryTryoutRepository) Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.tryoutIndex(id)
+	if i < 0 || r.store.tryouts[i].DeletedAt == nil {
+		return ErrNotFound
+	}
+	if err := check(clone(r.store.tryouts[i])); err != nil {
+		return err
+	}
+
+	restored := clone(r.store.tryouts[i])
+	restored.DeletedAt = nil
+	entry, err := models.NewAuditEntry(actor, models.ActionRestored, models.EntityTryout, id, id, nil, restored, at)
+	if err != nil {
+		return err
+	}
+
+	// Questions deleted on their own before the tryout stay in the trash
+	deletedAt := *r.store.tryouts[i].DeletedAt
+	for j := range r.store.questions {
+		question := &r.store.questions[j]
+		if question.TryoutID == id && question.DeletedAt != nil && question.DeletedAt.Equal(deletedAt) {
+			question.DeletedAt = nil
+		}
+	}
+	r.store.tryouts[i] = restored
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryTryoutRepository) ListDeleted(ctx context.Context) ([]models.Tryout, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tryouts := filterClones(r.store.tryouts, func(tryout *models.Tryout) bool { return tryout.DeletedAt != nil })
+	sort.SliceStable(tryouts, func(i, j int) bool { return tryouts[i].DeletedAt.After(*tryouts[j].DeletedAt) })
+	return tryouts, nil
+}
+
+func (r *memoryTryoutRepository) Categories(ctx context.Context) ([]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	categories := []string{}
+	for _, tryout := range r.store.tryouts {
+		if tryout.DeletedAt == nil && !seen[tryout.Category] {
+			seen[tryout.Category] = true
+			categories = append(categories, tryout.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+// tryoutIndex must be called with the lock held
+func (s *memoryStore) tryoutIndex(id primitive.ObjectID) int {
+	return indexWhere(s.tryouts, func(tryout *models.Tryout) bool { return tryout.ID == id })
+}
+
+// activeTryoutIndex is like tryoutIndex but skips tryouts in the trash
+func (s *memoryStore) activeTryoutIndex(id primitive.ObjectID) int {
+	return indexWhere(s.tryouts, func(tryout *models.Tryout) bool { return tryout.ID == id && tryout.DeletedAt == nil })
+}
+
+func (r *memoryTryoutRepository) Search(ctx context.Context, terms []string, filter TryoutFilter, limit int64) ([]SearchHit, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	hits := map[primitive.ObjectID]*SearchHit{}
+	for i := range r.store.tryouts {
+		tryout := &r.store.tryouts[i]
+		if !matchesFilter(tryout, filter) {
+			continue
+		}
+		score := titleWeight*models.CountMatches(tryout.Title, terms) +
+			descriptionWeight*models.CountMatches(tryout.Description, terms)
+		hits[tryout.ID] = &SearchHit{Tryout: *tryout, Score: float64(score)}
+	}
+
+	for i := range r.store.questions {
+		question := &r.store.questions[i]
+		hit, ok := hits[question.TryoutID]
+		if !ok || question.DeletedAt != nil {
+			continue
+		}
+		if matches := models.CountMatches(question.Text, terms); matches > 0 {
+			hit.Score += float64(questionWeight * matches)
+			hit.Questions = append(hit.Questions, clone(*question))
+		}
+	}
+
+	matched := []SearchHit{}
+	for _, hit := range hits {
+		if hit.Score > 0 {
+			hit.Tryout = clone(hit.Tryout)
+			matched = append(matched, *hit)
+		}
+	}
+	return rankHits(matched, limit), nil
+}
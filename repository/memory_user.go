@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if indexWhere(r.store.users, func(existing *models.User) bool { return existing.Email == user.Email }) >= 0 {
+		return ErrEmailTaken
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.store.users = append(r.store.users, clone(*user))
+	return nil
+}
+
+func (r *memoryUserRepository) Get(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.find(func(user *models.User) bool { return user.ID == id })
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Email == email })
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := indexWhere(r.store.users, func(user *models.User) bool { return user.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.store.users[i].Role = role
+	r.store.users[i].UpdatedAt = at
+	return nil
+}
+
+func (r *memoryUserRepository) find(match func(*models.User) bool) (models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := indexWhere(r.store.users, match)
+	if i < 0 {
+		return models.User{}, ErrNotFound
+	}
+	return clone(r.store.users[i]), nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryVersionRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryVersionRepository) Publish(ctx context.Context, version *models.TryoutVersion, from string, actor models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.activeTryoutIndex(version.TryoutID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if r.store.tryouts[i].Status != from || r.store.tryouts[i].Version != version.Number-1 {
+		return ErrStatusChanged
+	}
+
+	if version.ID.IsZero() {
+		version.ID = primitive.NewObjectID()
+	}
+
+	stored := clone(r.store.tryouts[i])
+	at := version.PublishedAt
+	stored.Title = version.Title
+	stored.Description = version.Description
+	stored.Category = version.Category
+	stored.Duration = version.Duration
+	stored.Draft = nil
+	stored.Status = models.TryoutPublished
+	stored.Version = version.Number
+	stored.UpdatedAt = at
+	if from != models.TryoutPublished {
+		stored.PublishedAt = &at
+	}
+	entry, err := models.NewAuditEntry(actor, models.ActionPublished, models.EntityTryout, stored.ID, stored.ID, r.store.tryouts[i], stored, at)
+	if err != nil {
+		return err
+	}
+	r.store.versions = append(r.store.versions, clone(*version))
+	r.store.tryouts[i] = stored
+	r.store.appendAudit(entry)
+	return nil
+}
+
+func (r *memoryVersionRepository) List(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TryoutVersion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	versions := filterClones(r.store.versions, func(version *models.TryoutVersion) bool { return version.TryoutID == tryoutID })
+	for i := range versions {
+		versions[i].Questions = nil
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Number < versions[j].Number })
+	return versions, nil
+}
+
+func (r *memoryVersionRepository) Get(ctx context.Context, tryoutID primitive.ObjectID, number int) (models.TryoutVersion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.versionIndex(tryoutID, number)
+	if i < 0 {
+		return models.TryoutVersion{}, ErrNotFound
+	}
+	return clone(r.store.versions[i]), nil
+}
+
+func (r *memoryVersionRepository) ListQuestionsForTaker(ctx context.Context, tryoutID primitive.ObjectID, number int) ([]models.TakerQuestion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.versionIndex(tryoutID, number)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	questions := []models.TakerQuestion{}
+	for _, question := range r.store.versions[i].Questions {
+		questions = append(questions, question.TakerView())
+	}
+	return questions, nil
+}
+
+// versionIndex must be called with the lock held
+func (s *memoryStore) versionIndex(tryoutID primitive.ObjectID, number int) int {
+	return indexWhere(s.versions, func(version *models.TryoutVersion) bool {
+		return version.TryoutID == tryoutID && version.Number == number
+	})
+}
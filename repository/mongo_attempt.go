@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const attemptCollection = "attempts"
+
+type mongoAttemptRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoAttemptRepository) collection() *mongo.Collection {
+	return r.db.Collection(attemptCollection)
+}
+
+func (r *mongoAttemptRepository) Create(ctx context.Context, attempt *models.Attempt) error {
+	result, err := r.collection().InsertOne(ctx, attempt)
+	if err != nil {
+		return err
+	}
+	attempt.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *mongoAttemptRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Attempt, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var attempt models.Attempt
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}, findOneOptions).Decode(&attempt)
+	return attempt, translateError(err)
+}
+
+func (r *mongoAttemptRepository) Expire(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection().UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": models.AttemptInProgress},
+		bson.M{"$set": bson.M{"status": models.AttemptExpired}},
+	)
+	return err
+}
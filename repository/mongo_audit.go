@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCollection = "audit"
+
+type mongoAuditRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoAuditRepository) collection() *mongo.Collection {
+	return r.db.Collection(auditCollection)
+}
+
+func (r *mongoAuditRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := bson.M{"tryoutId": tryoutID}
+	if filter.EntityID != nil {
+		query["entityId"] = *filter.EntityID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection().Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// appendAudit records entries in the audit log inside the transaction of the
+// change they describe
+func appendAudit(sc mongo.SessionContext, db *mongo.Database, entries ...models.AuditEntry) error {
+	documents := make([]interface{}, len(entries))
+	for i := range entries {
+		documents[i] = entries[i]
+	}
+	_, err := db.Collection(auditCollection).InsertMany(sc, documents)
+	return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the MongoDB repositories rely on. Creating
+// an index that already exists is a no-op.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(tryoutCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName("tryout_text").
+			SetWeights(bson.D{{Key: "title", Value: titleWeight}, {Key: "description", Value: descriptionWeight}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(questionCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "text", Value: "text"}},
+		Options: options.Index().
+			SetName("question_text").
+			SetWeights(bson.D{{Key: "text", Value: questionWeight}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(questionCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tryoutId", Value: 1}, {Key: "position", Value: 1}},
+		Options: options.Index().SetName("question_order"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(userCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetName("user_email").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(versionCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tryoutId", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetName("version_number").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(auditCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tryoutId", Value: 1}, {Key: "at", Value: -1}},
+		Options: options.Index().SetName("audit_tryout"),
+	})
+	return err
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoMaintenanceRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoMaintenanceRepository) FindOrphans(ctx context.Context) (OrphanReport, error) {
+	var report OrphanReport
+	var err error
+	if report.Questions, err = r.orphans(ctx, questionCollection); err != nil {
+		return OrphanReport{}, err
+	}
+	if report.Attempts, err = r.orphans(ctx, attemptCollection); err != nil {
+		return OrphanReport{}, err
+	}
+	if report.Submissions, err = r.orphans(ctx, submissionCollection); err != nil {
+		return OrphanReport{}, err
+	}
+	if report.Versions, err = r.orphans(ctx, versionCollection); err != nil {
+		return OrphanReport{}, err
+	}
+	return report, nil
+}
+
+// orphans returns the IDs of documents in a collection whose tryoutId does not
+// match any tryout
+func (r *mongoMaintenanceRepository) orphans(ctx context.Context, collection string) ([]primitive.ObjectID, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         tryoutCollection,
+			"localField":   "tryoutId",
+			"foreignField": "_id",
+			"as":           "tryout",
+		}}},
+		{{Key: "$match", Value: bson.M{"tryout": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(documents))
+	for i, document := range documents {
+		ids[i] = document.ID
+	}
+	return ids, nil
+}
+
+func (r *mongoMaintenanceRepository) DeleteOrphans(ctx context.Context, report OrphanReport) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		for collection, ids := range map[string][]primitive.ObjectID{
+			questionCollection:   report.Questions,
+			attemptCollection:    report.Attempts,
+			submissionCollection: report.Submissions,
+			versionCollection:    report.Versions,
+		} {
+			if len(ids) == 0 {
+				continue
+			}
+			if _, err := r.db.Collection(collection).DeleteMany(sc, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *mongoMaintenanceRepository) PurgeDeleted(ctx context.Context, before time.Time) (PurgeReport, error) {
+	var report PurgeReport
+	err := withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		report = PurgeReport{}
+		expired := bson.M{"deletedAt": bson.M{"$ne": nil, "$lt": before}}
+
+		values, err := r.db.Collection(tryoutCollection).Distinct(sc, "_id", expired)
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			values = []interface{}{} // $in needs an array
+		}
+		tryouts, err := r.db.Collection(tryoutCollection).DeleteMany(sc, bson.M{"_id": bson.M{"$in": values}})
+		if err != nil {
+			return err
+		}
+		report.Tryouts = int(tryouts.DeletedCount)
+
+		questions, err := r.db.Collection(questionCollection).DeleteMany(sc, bson.M{"$or": []bson.M{
+			{"tryoutId": bson.M{"$in": values}},
+			expired,
+		}})
+		if err != nil {
+			return err
+		}
+		report.Questions = int(questions.DeletedCount)
+
+		for _, name := range []string{versionCollection, attemptCollection, submissionCollection} {
+			if _, err := r.db.Collection(name).DeleteMany(sc, bson.M{"tryoutId": bson.M{"$in": values}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
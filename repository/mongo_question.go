@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const questionCollection = "questions"
+
+// takerProjection selects the fields of models.TakerQuestion. It lists the
+// fields to keep, so fields added to questions later stay hidden from takers
+// until they are added here.
+var takerProjection = bson.M{
+	"tryoutId":     1,
+	"position":     1,
+	"type":         1,
+	"text":         1,
+	"points":       1,
+	"options.id":   1,
+	"options.text": 1,
+	"createdAt":    1,
+	"updatedAt":    1,
+}
+
+// questionOrder sorts questions in listing order. Questions created before
+// ordering existed have no position and come first, in creation order.
+var questionOrder = bson.D{{Key: "position", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}
+
+type mongoQuestionRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoQuestionRepository) collection() *mongo.Collection {
+	return r.db.Collection(questionCollection)
+}
+
+func (r *mongoQuestionRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Question, error) {
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(questionOrder)
+
+	cursor, err := r.collection().Find(ctx, bson.M{"tryoutId": tryoutID, "deletedAt": nil}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []models.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *mongoQuestionRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Question, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var question models.Question
+	err := r.collection().FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}, findOneOptions).Decode(&question)
+	return question, translateError(err)
+}
+
+func (r *mongoQuestionRepository) ListForTaker(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TakerQuestion, error) {
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(questionOrder)
+	findOptions.SetProjection(takerProjection)
+
+	cursor, err := r.collection().Find(ctx, bson.M{"tryoutId": tryoutID, "deletedAt": nil}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []models.TakerQuestion{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *mongoQuestionRepository) GetForTaker(ctx context.Context, id primitive.ObjectID) (models.TakerQuestion, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+	findOneOptions.SetProjection(takerProjection)
+
+	var question models.TakerQuestion
+	err := r.collection().FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}, findOneOptions).Decode(&question)
+	return question, translateError(err)
+}
+
+func (r *mongoQuestionRepository) Create(ctx context.Context, question *models.Question, actor models.User) error {
+	if question.ID.IsZero() {
+		question.ID = primitive.NewObjectID()
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		if err := r.lockOrder(sc, question.TryoutID); err != nil {
+			return err
+		}
+		order, err := r.listOrder(sc, question.TryoutID)
+		if err != nil {
+			return err
+		}
+
+		if question.Position < 1 || question.Position > len(order) {
+			question.Position = 1
+			if len(order) > 0 {
+				question.Position = order[len(order)-1].Position + 1
+			}
+		} else {
+			positions := make(map[primitive.ObjectID]int, len(order))
+			for n, existing := range order {
+				position := n + 1
+				if position >= question.Position {
+					position++
+				}
+				positions[existing.ID] = position
+			}
+			if err := r.setPositions(sc, order, positions, nil); err != nil {
+				return err
+			}
+		}
+
+		if _, err := r.collection().InsertOne(sc, question); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, question.ID, question.TryoutID, nil, question, question.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoQuestionRepository) CreateMany(ctx context.Context, tryoutID primitive.ObjectID, questions []models.Question, actor models.User) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(questions))
+	for i := range questions {
+		if questions[i].ID.IsZero() {
+			questions[i].ID = primitive.NewObjectID()
+		}
+		questions[i].TryoutID = tryoutID
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		if err := r.lockOrder(sc, tryoutID); err != nil {
+			return err
+		}
+		order, err := r.listOrder(sc, tryoutID)
+		if err != nil {
+			return err
+		}
+		next := 1
+		if len(order) > 0 {
+			next = order[len(order)-1].Position + 1
+		}
+		entries := make([]models.AuditEntry, len(questions))
+		for i := range questions {
+			questions[i].Position = next + i
+			documents[i] = questions[i]
+			if entries[i], err = models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, questions[i].ID, tryoutID, nil, questions[i], questions[i].CreatedAt); err != nil {
+				return err
+			}
+		}
+
+		if _, err := r.collection().InsertMany(sc, documents); err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entries...)
+	})
+}
+
+func (r *mongoQuestionRepository) Update(ctx context.Context, question *models.Question, actor models.User) error {
+	update := bson.M{
+		"$set": bson.M{
+			"type":    question.Type,
+			"text":    question.Text,
+			"points":  question.Points,
+			"isTrue":  question.IsTrue,
+			"options": question.Options,
+
+			"acceptedAnswers":  question.AcceptedAnswers,
+			"acceptedPatterns": question.AcceptedPatterns,
+			"caseSensitive":    question.CaseSensitive,
+
+			"numericAnswer":     question.NumericAnswer,
+			"tolerance":         question.Tolerance,
+			"relativeTolerance": question.RelativeTolerance,
+
+			"explanation": question.Explanation,
+
+			"updatedAt": question.UpdatedAt,
+		},
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var before models.Question
+		if err := r.collection().FindOneAndUpdate(sc, bson.M{"_id": question.ID, "deletedAt": nil}, update).Decode(&before); err != nil {
+			return translateError(err)
+		}
+
+		var after models.Question
+		if err := r.collection().FindOne(sc, bson.M{"_id": question.ID}).Decode(&after); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityQuestion, question.ID, before.TryoutID, before, after, question.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoQuestionRepository) Reorder(ctx context.Context, tryoutID primitive.ObjectID, ids []primitive.ObjectID, at time.Time, actor models.User) error {
+	positions := make(map[primitive.ObjectID]int, len(ids))
+	for n, id := range ids {
+		positions[id] = n + 1
+	}
+	if len(positions) != len(ids) {
+		return ErrOrderMismatch
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		if err := r.lockOrder(sc, tryoutID); err != nil {
+			return err
+		}
+		order, err := r.listOrder(sc, tryoutID)
+		if err != nil {
+			return err
+		}
+		if len(order) != len(ids) {
+			return ErrOrderMismatch
+		}
+		before := models.QuestionOrder{QuestionIDs: []primitive.ObjectID{}}
+		for _, question := range order {
+			if _, ok := positions[question.ID]; !ok {
+				return ErrOrderMismatch
+			}
+			before.QuestionIDs = append(before.QuestionIDs, question.ID)
+		}
+
+		if err := r.setPositions(sc, order, positions, &at); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityTryout, tryoutID, tryoutID, before, models.QuestionOrder{QuestionIDs: ids}, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+// lockOrder bumps a counter on the tryout, so concurrent transactions that
+// number its questions conflict and are retried instead of numbering them
+// from the same snapshot. It must come before reading the order.
+func (r *mongoQuestionRepository) lockOrder(ctx context.Context, tryoutID primitive.ObjectID) error {
+	_, err := r.db.Collection(tryoutCollection).UpdateOne(ctx, bson.M{"_id": tryoutID}, bson.M{"$inc": bson.M{"orderRevision": 1}})
+	return err
+}
+
+// listOrder returns the IDs and positions of the questions of a tryout
+// outside the trash in listing order
+func (r *mongoQuestionRepository) listOrder(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Question, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(questionOrder)
+	findOptions.SetProjection(bson.M{"position": 1})
+
+	cursor, err := r.collection().Find(ctx, bson.M{"tryoutId": tryoutID, "deletedAt": nil}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []models.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// setPositions moves the questions whose position changes to their new
+// positions, also setting updatedAt when at is given
+func (r *mongoQuestionRepository) setPositions(ctx context.Context, questions []models.Question, positions map[primitive.ObjectID]int, at *time.Time) error {
+	writes := []mongo.WriteModel{}
+	for _, question := range questions {
+		position := positions[question.ID]
+		if position == question.Position {
+			continue
+		}
+		set := bson.M{"position": position}
+		if at != nil {
+			set["updatedAt"] = *at
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": question.ID}).SetUpdate(bson.M{"$set": set}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err := r.collection().BulkWrite(ctx, writes)
+	return err
+}
+
+func (r *mongoQuestionRepository) Delete(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var question models.Question
+		if err := r.collection().FindOneAndUpdate(sc, bson.M{"_id": id, "deletedAt": nil}, bson.M{"$set": bson.M{"deletedAt": at}}).Decode(&question); err != nil {
+			return translateError(err)
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionDeleted, models.EntityQuestion, id, question.TryoutID, question, nil, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoQuestionRepository) Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var question models.Question
+		if err := r.collection().FindOne(sc, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}).Decode(&question); err != nil {
+			return translateError(err)
+		}
+
+		var tryout models.Tryout
+		if err := r.db.Collection(tryoutCollection).FindOne(sc, bson.M{"_id": question.TryoutID}).Decode(&tryout); err != nil {
+			return translateError(err)
+		}
+		if err := check(tryout); err != nil {
+			return err
+		}
+		if tryout.DeletedAt != nil {
+			return ErrTryoutDeleted
+		}
+
+		if _, err := r.collection().UpdateOne(sc, bson.M{"_id": id}, bson.M{"$unset": bson.M{"deletedAt": ""}}); err != nil {
+			return err
+		}
+		question.DeletedAt = nil
+		entry, err := models.NewAuditEntry(actor, models.ActionRestored, models.EntityQuestion, id, question.TryoutID, nil, question, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoQuestionRepository) ListDeleted(ctx context.Context) ([]models.Question, error) {
+	// Questions trashed with their tryout are listed under the tryout instead
+	trashedTryouts, err := r.db.Collection(tryoutCollection).Distinct(ctx, "_id", bson.M{"deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	if trashedTryouts == nil {
+		trashedTryouts = []interface{}{} // $nin needs an array
+	}
+
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: "deletedAt", Value: -1}})
+
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}, "tryoutId": bson.M{"$nin": trashedTryouts}}
+	cursor, err := r.collection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []models.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const submissionCollection = "submissions"
+
+type mongoSubmissionRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoSubmissionRepository) collection() *mongo.Collection {
+	return r.db.Collection(submissionCollection)
+}
+
+func (r *mongoSubmissionRepository) Create(ctx context.Context, submission *models.Submission) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		result, err := r.collection().InsertOne(sc, submission)
+		if err != nil {
+			return err
+		}
+		submission.ID = result.InsertedID.(primitive.ObjectID)
+
+		closed, err := r.db.Collection(attemptCollection).UpdateOne(
+			sc,
+			bson.M{"_id": submission.AttemptID, "status": models.AttemptInProgress},
+			bson.M{"$set": bson.M{"status": models.AttemptSubmitted, "submissionId": submission.ID}},
+		)
+		if err != nil {
+			return err
+		}
+		if closed.MatchedCount == 0 {
+			return ErrAttemptClosed
+		}
+
+		_, err = r.db.Collection(tryoutCollection).UpdateOne(
+			sc,
+			bson.M{"_id": submission.TryoutID},
+			bson.M{"$set": bson.M{"hasSubmission": true}},
+		)
+		return err
+	})
+}
+
+func (r *mongoSubmissionRepository) ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error) {
+	return r.find(ctx, bson.M{"tryoutId": tryoutID}, -1)
+}
+
+func (r *mongoSubmissionRepository) ListPending(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error) {
+	return r.find(ctx, bson.M{"tryoutId": tryoutID, "status": models.SubmissionPending}, 1)
+}
+
+func (r *mongoSubmissionRepository) find(ctx context.Context, filter bson.M, order int) ([]models.Submission, error) {
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: "submittedAt", Value: order}})
+
+	cursor, err := r.collection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := []models.Submission{}
+	if err = cursor.All(ctx, &submissions); err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+func (r *mongoSubmissionRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Submission, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var submission models.Submission
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}, findOneOptions).Decode(&submission)
+	return submission, translateError(err)
+}
+
+func (r *mongoSubmissionRepository) Modify(ctx context.Context, id primitive.ObjectID, change func(*models.Submission) error) (models.Submission, error) {
+	var submission models.Submission
+	err := withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		submission = models.Submission{}
+		if err := r.collection().FindOne(sc, bson.M{"_id": id}).Decode(&submission); err != nil {
+			return translateError(err)
+		}
+		if err := change(&submission); err != nil {
+			return err
+		}
+		_, err := r.collection().ReplaceOne(sc, bson.M{"_id": id}, submission)
+		return err
+	})
+	return submission, err
+}
+
+// withTransaction runs fn inside a MongoDB transaction, retrying on transient errors
+func withTransaction(ctx context.Context, db *mongo.Database, fn func(sc mongo.SessionContext) error) error {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
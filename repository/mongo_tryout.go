@@ -0,0 +1,563 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const tryoutCollection = "tryouts"
+
+// titleClaimCollection holds a counter per title tryouts have been given,
+// keyed by the lowercased title. Bumping it in every transaction that gives a
+// tryout a title makes those under the same title conflict.
+const titleClaimCollection = "titleClaims"
+
+type mongoTryoutRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoTryoutRepository) collection() *mongo.Collection {
+	return r.db.Collection(tryoutCollection)
+}
+
+func (r *mongoTryoutRepository) List(ctx context.Context, filter TryoutFilter, opts ListOptions) (TryoutPage, error) {
+	query := tryoutQuery(filter)
+
+	total, err := r.collection().CountDocuments(ctx, query)
+	if err != nil {
+		return TryoutPage{}, err
+	}
+
+	field := opts.sortField()
+	direction := 1
+	if opts.Descending {
+		direction = -1
+	}
+
+	if opts.After != nil {
+		// Continue after the cursor document's position in the sort order
+		var cursorDoc bson.M
+		err := r.collection().FindOne(ctx, bson.M{"_id": *opts.After}).Decode(&cursorDoc)
+		if err == mongo.ErrNoDocuments {
+			return TryoutPage{}, ErrInvalidCursor
+		}
+		if err != nil {
+			return TryoutPage{}, err
+		}
+
+		comparison := "$gt"
+		if opts.Descending {
+			comparison = "$lt"
+		}
+		query = bson.M{"$and": []bson.M{query, {"$or": []bson.M{
+			{field: bson.M{comparison: cursorDoc[field]}},
+			{field: cursorDoc[field], "_id": bson.M{comparison: *opts.After}},
+		}}}}
+	}
+
+	// Set options to handle potential timeout issues
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+	if opts.Skip > 0 {
+		findOptions.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		// Fetch one extra document to learn whether more follow
+		findOptions.SetLimit(opts.Limit + 1)
+	}
+
+	cursor, err := r.collection().Find(ctx, query, findOptions)
+	if err != nil {
+		return TryoutPage{}, err
+	}
+
+	tryouts := []models.Tryout{}
+	if err = cursor.All(ctx, &tryouts); err != nil {
+		return TryoutPage{}, err
+	}
+
+	page := TryoutPage{Tryouts: tryouts, Total: total}
+	if opts.Limit > 0 && int64(len(tryouts)) > opts.Limit {
+		page.Tryouts = tryouts[:opts.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// statusValues returns the stored values of a status. Tryouts created before
+// statuses existed have none and count as published.
+func statusValues(status string) bson.A {
+	if status == models.TryoutPublished {
+		return bson.A{status, nil}
+	}
+	return bson.A{status}
+}
+
+// windowQuery matches the tryouts in an availability window at now, mirroring
+// models.Tryout.Window
+func windowQuery(window string, now time.Time) bson.M {
+	switch window {
+	case models.WindowUpcoming:
+		return bson.M{"status": bson.M{"$ne": models.TryoutArchived}, "opensAt": bson.M{"$gt": now}}
+	case models.WindowOpen:
+		return bson.M{
+			"status": bson.M{"$in": statusValues(models.TryoutPublished)},
+			"$and": bson.A{
+				bson.M{"$or": bson.A{bson.M{"opensAt": nil}, bson.M{"opensAt": bson.M{"$lte": now}}}},
+				bson.M{"$or": bson.A{bson.M{"closesAt": nil}, bson.M{"closesAt": bson.M{"$gt": now}}}},
+			},
+		}
+	default:
+		return bson.M{"$or": bson.A{bson.M{"status": models.TryoutArchived}, bson.M{"closesAt": bson.M{"$lte": now}}}}
+	}
+}
+
+// tryoutQuery builds the MongoDB query for a filter
+func tryoutQuery(filter TryoutFilter) bson.M {
+	query := bson.M{"deletedAt": nil}
+
+	if filter.Title != "" {
+		query["title"] = bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(filter.Title), Options: "i"}}
+	}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+
+	// Conditions on the same fields are combined with $and so they do not
+	// overwrite each other
+	conditions := bson.A{}
+	if len(filter.Statuses) > 0 {
+		statuses := bson.A{}
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, statusValues(status)...)
+		}
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": statuses}})
+	}
+	if filter.VisibleTo != nil {
+		visible := bson.A{bson.M{"status": bson.M{"$in": statusValues(models.TryoutPublished)}}}
+		if !filter.VisibleTo.IsZero() {
+			visible = append(visible, bson.M{"ownerId": *filter.VisibleTo})
+		}
+		conditions = append(conditions, bson.M{"$or": visible})
+	}
+	if filter.Window != "" {
+		conditions = append(conditions, windowQuery(filter.Window, filter.WindowAt))
+	}
+	if len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lte"] = *filter.CreatedTo
+		}
+		query["createdAt"] = createdAt
+	}
+	return query
+}
+
+func (r *mongoTryoutRepository) Get(ctx context.Context, id primitive.ObjectID) (models.Tryout, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var tryout models.Tryout
+	err := r.collection().FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}, findOneOptions).Decode(&tryout)
+	return tryout, translateError(err)
+}
+
+func (r *mongoTryoutRepository) Create(ctx context.Context, tryout *models.Tryout, actor models.User) error {
+	if tryout.ID.IsZero() {
+		tryout.ID = primitive.NewObjectID()
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		if err := claimTitle(sc, r.db, tryout.Title); err != nil {
+			return err
+		}
+		if _, err := r.collection().InsertOne(sc, tryout); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityTryout, tryout.ID, tryout.ID, nil, tryout, tryout.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoTryoutRepository) CreateWithQuestions(ctx context.Context, tryout *models.Tryout, questions []models.Question, rename bool, actor models.User) error {
+	if tryout.ID.IsZero() {
+		tryout.ID = primitive.NewObjectID()
+	}
+	prepareQuestions(tryout.ID, questions)
+	title := tryout.Title
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		cursor, err := r.collection().Find(sc, tryoutQuery(TryoutFilter{Title: title}), options.Find().SetMaxTime(15*time.Second).SetProjection(bson.M{"title": 1}))
+		if err != nil {
+			return err
+		}
+		similar := []models.Tryout{}
+		if err := cursor.All(sc, &similar); err != nil {
+			return err
+		}
+		tryout.Title = freeTitle(title, similar)
+		if tryout.Title != title && !rename {
+			tryout.Title = title
+			return ErrTitleTaken
+		}
+
+		// Claiming the chosen title makes this transaction conflict with any
+		// other giving a tryout that title since the titles were read, so it
+		// is retried and sees that tryout
+		if err := claimTitle(sc, r.db, tryout.Title); err != nil {
+			return err
+		}
+		if _, err := r.collection().InsertOne(sc, tryout); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityTryout, tryout.ID, tryout.ID, nil, tryout, tryout.CreatedAt)
+		if err != nil {
+			return err
+		}
+		entries := []models.AuditEntry{entry}
+		if len(questions) > 0 {
+			documents := make([]interface{}, len(questions))
+			for i := range questions {
+				documents[i] = questions[i]
+				entry, err := models.NewAuditEntry(actor, models.ActionCreated, models.EntityQuestion, questions[i].ID, tryout.ID, nil, questions[i], questions[i].CreatedAt)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, entry)
+			}
+			if _, err := r.db.Collection(questionCollection).InsertMany(sc, documents); err != nil {
+				return err
+			}
+		}
+		return appendAudit(sc, r.db, entries...)
+	})
+}
+
+func (r *mongoTryoutRepository) Update(ctx context.Context, tryout *models.Tryout, actor models.User) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		// Where the details go depends on the stored version, which a
+		// concurrent publish changes with a conflicting write
+		var stored models.Tryout
+		if err := r.collection().FindOne(sc, bson.M{"_id": tryout.ID, "deletedAt": nil}).Decode(&stored); err != nil {
+			return translateError(err)
+		}
+		updated := stored
+		updated.SetDetails(tryout.Details())
+		updated.OpensAt = tryout.OpensAt
+		updated.ClosesAt = tryout.ClosesAt
+		updated.Shuffle = tryout.Shuffle
+		updated.UpdatedAt = tryout.UpdatedAt
+
+		set := bson.M{
+			"title":       updated.Title,
+			"description": updated.Description,
+			"category":    updated.Category,
+			"duration":    updated.Duration,
+			"opensAt":     updated.OpensAt,
+			"closesAt":    updated.ClosesAt,
+			"shuffle":     updated.Shuffle,
+			"updatedAt":   updated.UpdatedAt,
+		}
+		update := bson.M{"$set": set}
+		if updated.Draft != nil {
+			set["draft"] = updated.Draft
+		} else {
+			update["$unset"] = bson.M{"draft": ""}
+		}
+
+		if err := claimTitle(sc, r.db, updated.Title); err != nil {
+			return err
+		}
+		if _, err := r.collection().UpdateOne(sc, bson.M{"_id": tryout.ID}, update); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionUpdated, models.EntityTryout, tryout.ID, tryout.ID, stored, updated, updated.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoTryoutRepository) SetStatus(ctx context.Context, id primitive.ObjectID, from, to string, at time.Time, actor models.User) error {
+	set := bson.M{"status": to, "updatedAt": at}
+	switch to {
+	case models.TryoutPublished:
+		set["publishedAt"] = at
+	case models.TryoutArchived:
+		set["archivedAt"] = at
+	}
+
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var before models.Tryout
+		err := r.collection().FindOneAndUpdate(sc,
+			bson.M{"_id": id, "deletedAt": nil, "status": bson.M{"$in": statusValues(from)}},
+			bson.M{"$set": set}).Decode(&before)
+		if err == mongo.ErrNoDocuments {
+			if err := r.collection().FindOne(sc, bson.M{"_id": id, "deletedAt": nil}).Err(); err != nil {
+				return translateError(err)
+			}
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return err
+		}
+
+		var after models.Tryout
+		if err := r.collection().FindOne(sc, bson.M{"_id": id}).Decode(&after); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, statusAction(to), models.EntityTryout, id, id, before, after, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoTryoutRepository) ListDue(ctx context.Context, now time.Time) ([]models.Tryout, error) {
+	query := bson.M{
+		"deletedAt": nil,
+		"$or": bson.A{
+			bson.M{
+				"status":  models.TryoutDraft,
+				"opensAt": bson.M{"$lte": now},
+				"$or":     bson.A{bson.M{"closesAt": nil}, bson.M{"closesAt": bson.M{"$gt": now}}},
+			},
+			bson.M{
+				"status":   bson.M{"$in": statusValues(models.TryoutPublished)},
+				"closesAt": bson.M{"$lte": now},
+			},
+		},
+	}
+
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+
+	cursor, err := r.collection().Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tryouts := []models.Tryout{}
+	if err = cursor.All(ctx, &tryouts); err != nil {
+		return nil, err
+	}
+	return tryouts, nil
+}
+
+func (r *mongoTryoutRepository) Delete(ctx context.Context, id primitive.ObjectID, force bool, at time.Time, actor models.User) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var tryout models.Tryout
+		if err := r.collection().FindOne(sc, bson.M{"_id": id, "deletedAt": nil}).Decode(&tryout); err != nil {
+			return translateError(err)
+		}
+		if tryout.HasSubmission && !force {
+			return ErrHasSubmissions
+		}
+
+		trash := bson.M{"$set": bson.M{"deletedAt": at}}
+		if _, err := r.collection().UpdateOne(sc, bson.M{"_id": id}, trash); err != nil {
+			return err
+		}
+		if _, err := r.db.Collection(questionCollection).UpdateMany(sc, bson.M{"tryoutId": id, "deletedAt": nil}, trash); err != nil {
+			return err
+		}
+		entry, err := models.NewAuditEntry(actor, models.ActionDeleted, models.EntityTryout, id, id, tryout, nil, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoTryoutRepository) Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		var tryout models.Tryout
+		if err := r.collection().FindOne(sc, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}).Decode(&tryout); err != nil {
+			return translateError(err)
+		}
+		if err := check(tryout); err != nil {
+			return err
+		}
+		if err := claimTitle(sc, r.db, tryout.Title); err != nil {
+			return err
+		}
+
+		// Questions deleted on their own before the tryout stay in the trash
+		restore := bson.M{"$unset": bson.M{"deletedAt": ""}}
+		if _, err := r.db.Collection(questionCollection).UpdateMany(sc, bson.M{"tryoutId": id, "deletedAt": *tryout.DeletedAt}, restore); err != nil {
+			return err
+		}
+		if _, err := r.collection().UpdateOne(sc, bson.M{"_id": id}, restore); err != nil {
+			return err
+		}
+		tryout.DeletedAt = nil
+		entry, err := models.NewAuditEntry(actor, models.ActionRestored, models.EntityTryout, id, id, nil, tryout, at)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoTryoutRepository) ListDeleted(ctx context.Context) ([]models.Tryout, error) {
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: "deletedAt", Value: -1}})
+
+	cursor, err := r.collection().Find(ctx, bson.M{"deletedAt": bson.M{"$ne": nil}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tryouts := []models.Tryout{}
+	if err = cursor.All(ctx, &tryouts); err != nil {
+		return nil, err
+	}
+	return tryouts, nil
+}
+
+func (r *mongoTryoutRepository) Categories(ctx context.Context) ([]string, error) {
+	values, err := r.collection().Distinct(ctx, "category", bson.M{"deletedAt": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]string, 0, len(values))
+	for _, value := range values {
+		if category, ok := value.(string); ok {
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+// maxSearchCandidates bounds how many tryouts and questions a search reads
+// before ranking
+const maxSearchCandidates = 500
+
+func (r *mongoTryoutRepository) Search(ctx context.Context, terms []string, filter TryoutFilter, limit int64) ([]SearchHit, error) {
+	// The terms are plain words, so the search string cannot carry $text
+	// phrase or negation syntax
+	text := bson.M{"$search": strings.Join(terms, " ")}
+	query := tryoutQuery(filter)
+	query["$text"] = text
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+	findOptions.SetSort(bson.M{"score": bson.M{"$meta": "textScore"}})
+	findOptions.SetLimit(maxSearchCandidates)
+
+	hits := map[primitive.ObjectID]*SearchHit{}
+
+	cursor, err := r.collection().Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var tryout models.Tryout
+		if err := cursor.Decode(&tryout); err != nil {
+			return nil, err
+		}
+		hits[tryout.ID] = &SearchHit{Tryout: tryout, Score: textScore(cursor.Current)}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	questionQuery := bson.M{"$text": text, "deletedAt": nil}
+	questionCursor, err := r.db.Collection(questionCollection).Find(ctx, questionQuery, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer questionCursor.Close(ctx)
+
+	// Questions can match inside tryouts whose own fields did not
+	var missing []primitive.ObjectID
+	for questionCursor.Next(ctx) {
+		var question models.Question
+		if err := questionCursor.Decode(&question); err != nil {
+			return nil, err
+		}
+		hit, ok := hits[question.TryoutID]
+		if !ok {
+			hit = &SearchHit{}
+			hits[question.TryoutID] = hit
+			missing = append(missing, question.TryoutID)
+		}
+		hit.Score += textScore(questionCursor.Current)
+		hit.Questions = append(hit.Questions, question)
+	}
+	if err := questionCursor.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		missingQuery := tryoutQuery(filter)
+		missingQuery["_id"] = bson.M{"$in": missing}
+		cursor, err := r.collection().Find(ctx, missingQuery)
+		if err != nil {
+			return nil, err
+		}
+		var tryouts []models.Tryout
+		if err := cursor.All(ctx, &tryouts); err != nil {
+			return nil, err
+		}
+		for _, tryout := range tryouts {
+			hits[tryout.ID].Tryout = tryout
+		}
+	}
+
+	matched := []SearchHit{}
+	for _, hit := range hits {
+		// Skip questions of tryouts that are in the trash, filtered out or
+		// left behind by a deleted tryout
+		if !hit.Tryout.ID.IsZero() {
+			matched = append(matched, *hit)
+		}
+	}
+	return rankHits(matched, limit), nil
+}
+
+// textScore reads the relevance projected into a document by a $text search
+func textScore(document bson.Raw) float64 {
+	score, _ := document.Lookup("score").DoubleOK()
+	return score
+}
+
+// translateError maps driver errors onto repository errors
+func translateError(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	return err
+}
+
+// claimTitle bumps the claim counter of a title inside a transaction that
+// gives a tryout that title
+func claimTitle(sc mongo.SessionContext, db *mongo.Database, title string) error {
+	claim := bson.M{"$inc": bson.M{"claims": 1}}
+	_, err := db.Collection(titleClaimCollection).UpdateOne(sc, bson.M{"_id": strings.ToLower(title)}, claim, options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const userCollection = "users"
+
+type mongoUserRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoUserRepository) collection() *mongo.Collection {
+	return r.db.Collection(userCollection)
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	// The unique index on email rejects concurrent registrations
+	result, err := r.collection().InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *mongoUserRepository) Get(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string, at time.Time) error {
+	result, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"role": role, "updatedAt": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) findOne(ctx context.Context, filter bson.M) (models.User, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var user models.User
+	err := r.collection().FindOne(ctx, filter, findOneOptions).Decode(&user)
+	return user, translateError(err)
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const versionCollection = "versions"
+
+// versionTakerProjection applies takerProjection to the questions of a version
+var versionTakerProjection = func() bson.M {
+	projection := bson.M{"questions._id": 1}
+	for field := range takerProjection {
+		projection["questions."+field] = 1
+	}
+	return projection
+}()
+
+type mongoVersionRepository struct {
+	db *mongo.Database
+}
+
+func (r *mongoVersionRepository) collection() *mongo.Collection {
+	return r.db.Collection(versionCollection)
+}
+
+func (r *mongoVersionRepository) Publish(ctx context.Context, version *models.TryoutVersion, from string, actor models.User) error {
+	return withTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		// The version's details, which may come from the draft, reach takers
+		set := bson.M{
+			"title":       version.Title,
+			"description": version.Description,
+			"category":    version.Category,
+			"duration":    version.Duration,
+			"status":      models.TryoutPublished,
+			"version":     version.Number,
+			"updatedAt":   version.PublishedAt,
+		}
+		if from != models.TryoutPublished {
+			set["publishedAt"] = version.PublishedAt
+		}
+
+		// Tryouts that were never published have no version field
+		var previous interface{} = version.Number - 1
+		if version.Number == 1 {
+			previous = nil
+		}
+
+		tryouts := r.db.Collection(tryoutCollection)
+		var before models.Tryout
+		err := tryouts.FindOneAndUpdate(sc,
+			bson.M{"_id": version.TryoutID, "deletedAt": nil, "status": bson.M{"$in": statusValues(from)}, "version": previous},
+			bson.M{"$set": set, "$unset": bson.M{"draft": ""}}).Decode(&before)
+		if err == mongo.ErrNoDocuments {
+			if err := tryouts.FindOne(sc, bson.M{"_id": version.TryoutID, "deletedAt": nil}).Err(); err != nil {
+				return translateError(err)
+			}
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return err
+		}
+		var after models.Tryout
+		if err := tryouts.FindOne(sc, bson.M{"_id": version.TryoutID}).Decode(&after); err != nil {
+			return err
+		}
+
+		if err := claimTitle(sc, r.db, version.Title); err != nil {
+			return err
+		}
+		inserted, err := r.collection().InsertOne(sc, version)
+		if err != nil {
+			return err
+		}
+		version.ID = inserted.InsertedID.(primitive.ObjectID)
+		entry, err := models.NewAuditEntry(actor, models.ActionPublished, models.EntityTryout, version.TryoutID, version.TryoutID, before, after, version.PublishedAt)
+		if err != nil {
+			return err
+		}
+		return appendAudit(sc, r.db, entry)
+	})
+}
+
+func (r *mongoVersionRepository) List(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TryoutVersion, error) {
+	findOptions := options.Find()
+	findOptions.SetMaxTime(15 * time.Second)
+	findOptions.SetSort(bson.D{{Key: "number", Value: 1}})
+	findOptions.SetProjection(bson.M{"questions": 0})
+
+	cursor, err := r.collection().Find(ctx, bson.M{"tryoutId": tryoutID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []models.TryoutVersion{}
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *mongoVersionRepository) Get(ctx context.Context, tryoutID primitive.ObjectID, number int) (models.TryoutVersion, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+
+	var version models.TryoutVersion
+	err := r.collection().FindOne(ctx, bson.M{"tryoutId": tryoutID, "number": number}, findOneOptions).Decode(&version)
+	return version, translateError(err)
+}
+
+func (r *mongoVersionRepository) ListQuestionsForTaker(ctx context.Context, tryoutID primitive.ObjectID, number int) ([]models.TakerQuestion, error) {
+	findOneOptions := options.FindOne()
+	findOneOptions.SetMaxTime(15 * time.Second)
+	findOneOptions.SetProjection(versionTakerProjection)
+
+	var version struct {
+		Questions []models.TakerQuestion `bson:"questions"`
+	}
+	err := r.collection().FindOne(ctx, bson.M{"tryoutId": tryoutID, "number": number}, findOneOptions).Decode(&version)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if version.Questions == nil {
+		version.Questions = []models.TakerQuestion{}
+	}
+	return version.Questions, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrOrderMismatch is returned when reordering questions with a list that is
+// not exactly the questions of the tryout
+var ErrOrderMismatch = errors.New("order does not list every question of the tryout exactly once")
+
+// QuestionRepository stores the working copy of the questions of tryouts,
+// which takers get once it is published as a version. Questions in the trash
+// are only returned by ListDeleted. Listings are ordered by position, then by
+// creation for questions created before ordering existed. Mutations record
+// their change by actor in the audit log.
+type QuestionRepository interface {
+	ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Question, error)
+	Get(ctx context.Context, id primitive.ObjectID) (models.Question, error)
+	// ListForTaker is like ListByTryout but only loads the fields of the
+	// taker view, so answer keys never leave the storage backend
+	ListForTaker(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TakerQuestion, error)
+	// GetForTaker is like Get but only loads the fields of the taker view
+	GetForTaker(ctx context.Context, id primitive.ObjectID) (models.TakerQuestion, error)
+	// Create inserts a question at its Position, moving the questions from
+	// there on down, or after the last question when Position is 0 or past
+	// the end. Position is set to where the question went.
+	Create(ctx context.Context, question *models.Question, actor models.User) error
+	// CreateMany adds questions to a tryout after its last question, in order.
+	// Either all of them are stored or none are.
+	CreateMany(ctx context.Context, tryoutID primitive.ObjectID, questions []models.Question, actor models.User) error
+	// Update overwrites the editable fields of a question, which leaves out
+	// its position
+	Update(ctx context.Context, question *models.Question, actor models.User) error
+	// Reorder numbers the questions of a tryout in the order of ids, which
+	// must list each of them once, or fails with ErrOrderMismatch. It is
+	// audited as an update of the tryout.
+	Reorder(ctx context.Context, tryoutID primitive.ObjectID, ids []primitive.ObjectID, at time.Time, actor models.User) error
+	// Delete moves a question to the trash
+	Delete(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User) error
+	// Restore takes a question out of the trash. It fails with
+	// ErrTryoutDeleted while its tryout is in the trash. check is called with
+	// the question's tryout first and aborts the restore with its error. at
+	// is when the restore is recorded to have happened.
+	Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error
+	// ListDeleted returns the questions deleted on their own, most recently
+	// deleted first. Questions deleted with their tryout are left out.
+	ListDeleted(ctx context.Context) ([]models.Question, error)
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Storage drivers
+const (
+	DriverMongo  = "mongo"
+	DriverMemory = "memory"
+)
+
+var (
+	// ErrNotFound is returned when a document does not exist
+	ErrNotFound = errors.New("document not found")
+
+	// ErrAttemptClosed is returned when an attempt was submitted or expired concurrently
+	ErrAttemptClosed = errors.New("attempt is no longer in progress")
+
+	// ErrHasSubmissions is returned when deleting a tryout that has submissions without forcing it
+	ErrHasSubmissions = errors.New("tryout has submissions")
+
+	// ErrTryoutDeleted is returned when restoring a question whose tryout is in the trash
+	ErrTryoutDeleted = errors.New("tryout is in the trash")
+)
+
+// Active repositories used by the controllers
+var (
+	Tryouts     TryoutRepository
+	Questions   QuestionRepository
+	Submissions SubmissionRepository
+	Attempts    AttemptRepository
+	Users       UserRepository
+	Versions    VersionRepository
+	Audit       AuditRepository
+	Maintenance MaintenanceRepository
+)
+
+// UseMongo backs the repositories with a MongoDB database
+func UseMongo(db *mongo.Database) {
+	Tryouts = &mongoTryoutRepository{db: db}
+	Questions = &mongoQuestionRepository{db: db}
+	Submissions = &mongoSubmissionRepository{db: db}
+	Attempts = &mongoAttemptRepository{db: db}
+	Users = &mongoUserRepository{db: db}
+	Versions = &mongoVersionRepository{db: db}
+	Audit = &mongoAuditRepository{db: db}
+	Maintenance = &mongoMaintenanceRepository{db: db}
+}
+
+// UseMemory backs the repositories with a fresh, empty in-memory store
+func UseMemory() {
+	store := newMemoryStore()
+	Tryouts = &memoryTryoutRepository{store: store}
+	Questions = &memoryQuestionRepository{store: store}
+	Submissions = &memorySubmissionRepository{store: store}
+	Attempts = &memoryAttemptRepository{store: store}
+	Users = &memoryUserRepository{store: store}
+	Versions = &memoryVersionRepository{store: store}
+	Audit = &memoryAuditRepository{store: store}
+	Maintenance = &memoryMaintenanceRepository{store: store}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Relevance weights of the searchable fields, shared by the MongoDB text
+// indexes and the in-memory backend
+const (
+	titleWeight       = 10
+	descriptionWeight = 2
+	questionWeight    = 1
+)
+
+// rankHits sorts hits by descending score, breaking ties by tryout ID, and
+// keeps at most limit of them
+func rankHits(hits []SearchHit, limit int64) []SearchHit {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return bytes.Compare(hits[i].Tryout.ID[:], hits[j].Tryout.ID[:]) < 0
+	})
+	if limit > 0 && int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SubmissionRepository stores graded submissions
+type SubmissionRepository interface {
+	// Create records a submission, closes its attempt and flags the tryout as
+	// having submissions, all atomically. It returns ErrAttemptClosed if the
+	// attempt is no longer in progress.
+	Create(ctx context.Context, submission *models.Submission) error
+	// ListByTryout returns a tryout's submissions, newest first
+	ListByTryout(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error)
+	// ListPending returns a tryout's submissions awaiting manual grading, oldest first
+	ListPending(ctx context.Context, tryoutID primitive.ObjectID) ([]models.Submission, error)
+	Get(ctx context.Context, id primitive.ObjectID) (models.Submission, error)
+	// Modify atomically applies change to a submission and saves the result.
+	// An error from change aborts the modification and is returned as is.
+	Modify(ctx context.Context, id primitive.ObjectID, change func(*models.Submission) error) (models.Submission, error)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quiz-platform/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sortable tryout fields
+const (
+	SortByCreatedAt = "createdAt"
+	SortByTitle     = "title"
+	SortByDuration  = "duration"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor does not refer to an existing tryout
+var ErrInvalidCursor = errors.New("cursor does not refer to an existing tryout")
+
+// ErrTitleTaken is returned when creating a tryout under a title that is
+// already in use without renaming it
+var ErrTitleTaken = errors.New("tryout title is taken")
+
+// ErrStatusChanged is returned when a tryout is no longer in the status a
+// transition starts from, or no longer on the version a publish follows
+var ErrStatusChanged = errors.New("tryout status has changed")
+
+// TryoutFilter narrows down a tryout listing. Zero values are ignored. Tryouts
+// in the trash are never listed.
+type TryoutFilter struct {
+	Title       string // case-insensitive substring
+	Category    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Statuses    []string // any of these statuses
+	// VisibleTo limits the listing to published tryouts and those owned by
+	// this user; a zero ID only sees published tryouts
+	VisibleTo *primitive.ObjectID
+	Window    string    // one of the models.Window values, evaluated at WindowAt
+	WindowAt  time.Time // required with Window
+}
+
+// ListOptions controls the order and window of a listing. Ties in the sort
+// field are broken by ID, so the order is stable across pages.
+type ListOptions struct {
+	SortField  string // defaults to createdAt
+	Descending bool
+	After      *primitive.ObjectID // only return tryouts sorted after this one
+	Skip       int64
+	Limit      int64 // 0 means no limit
+}
+
+// TryoutPage is one window of a tryout listing
+type TryoutPage struct {
+	Tryouts []models.Tryout
+	Total   int64 // number of tryouts matching the filter, ignoring the window
+	HasMore bool  // more tryouts follow the window
+}
+
+// SearchHit is a tryout matching a full-text search
+type SearchHit struct {
+	Tryout    models.Tryout
+	Score     float64           // relevance of the tryout and its matching questions
+	Questions []models.Question // questions of the tryout whose text matched
+}
+
+// TryoutRepository stores tryouts. Its mutations record their change by
+// actor in the audit log, the tryout's questions included.
+type TryoutRepository interface {
+	List(ctx context.Context, filter TryoutFilter, opts ListOptions) (TryoutPage, error)
+	Get(ctx context.Context, id primitive.ObjectID) (models.Tryout, error)
+	Create(ctx context.Context, tryout *models.Tryout, actor models.User) error
+	// CreateWithQuestions creates a tryout and its questions, in that order,
+	// in one go. When a tryout outside the trash already has the title
+	// (ignoring case), the title gets the lowest free number appended, such
+	// as "Physics (2)", if rename is set and ErrTitleTaken is returned
+	// otherwise. The title it ends up with is not in use by any other tryout
+	// outside the trash, including ones given that title concurrently by
+	// another create, update, publish or restore. Create, Update and Restore
+	// themselves do not check titles, so they can still repeat one.
+	CreateWithQuestions(ctx context.Context, tryout *models.Tryout, questions []models.Question, rename bool, actor models.User) error
+	// Update overwrites the editable fields of a tryout. Its details are set
+	// with models.Tryout.SetDetails, so once it has a published version they
+	// go to its draft.
+	Update(ctx context.Context, tryout *models.Tryout, actor models.User) error
+	// SetStatus moves a tryout from one status to another, failing with
+	// ErrStatusChanged if it is no longer in the from status
+	SetStatus(ctx context.Context, id primitive.ObjectID, from, to string, at time.Time, actor models.User) error
+	// ListDue returns the drafts whose opensAt has come, unless their
+	// closesAt has passed too, and the published tryouts whose closesAt has
+	// come, for the scheduler to publish and archive
+	ListDue(ctx context.Context, now time.Time) ([]models.Tryout, error)
+	// Delete moves a tryout and its questions to the trash. Unless force is
+	// set, tryouts with submissions are kept and ErrHasSubmissions is returned.
+	// Attempts and submissions are left as they are; they are only reached
+	// through their tryout, and purged with it.
+	Delete(ctx context.Context, id primitive.ObjectID, force bool, at time.Time, actor models.User) error
+	// Restore takes a tryout out of the trash together with the questions
+	// that were deleted with it. check is called with the trashed tryout
+	// first and aborts the restore with its error. at is when the restore is
+	// recorded to have happened.
+	Restore(ctx context.Context, id primitive.ObjectID, at time.Time, actor models.User, check func(models.Tryout) error) error
+	// ListDeleted returns the tryouts in the trash, most recently deleted first
+	ListDeleted(ctx context.Context) ([]models.Tryout, error)
+	Categories(ctx context.Context) ([]string, error)
+	// Search finds tryouts matching the filter whose title, description or
+	// question text match any of the terms, most relevant first
+	Search(ctx context.Context, terms []string, filter TryoutFilter, limit int64) ([]SearchHit, error)
+}
+
+func (opts ListOptions) sortField() string {
+	if opts.SortField == "" {
+		return SortByCreatedAt
+	}
+	return opts.SortField
+}
+
+// freeTitle returns title if none of the tryouts has it, or else the title
+// with the lowest free number appended. Titles are compared ignoring case.
+func freeTitle(title string, tryouts []models.Tryout) string {
+	taken := make(map[string]bool, len(tryouts))
+	for _, tryout := range tryouts {
+		taken[strings.ToLower(tryout.Title)] = true
+	}
+
+	candidate := title
+	for n := 2; taken[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)", title, n)
+	}
+	return candidate
+}
+
+// prepareQuestions gives new questions of a tryout their IDs and positions
+func prepareQuestions(tryoutID primitive.ObjectID, questions []models.Question) {
+	for i := range questions {
+		if questions[i].ID.IsZero() {
+			questions[i].ID = primitive.NewObjectID()
+		}
+		questions[i].TryoutID = tryoutID
+		questions[i].Position = i + 1
+	}
+}
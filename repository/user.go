@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"quiz-platform/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrEmailTaken is returned when registering an email that already has an account
+var ErrEmailTaken = errors.New("email is already registered")
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	// Create stores a new user, failing with ErrEmailTaken if the email is in use
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id primitive.ObjectID) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	SetRole(ctx context.Context, id primitive.ObjectID, role string, at time.Time) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"quiz-platform/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VersionRepository stores the published versions of tryouts. Versions are
+// never changed once stored.
+type VersionRepository interface {
+	// Publish stores a new version and makes it the current version of its
+	// tryout, moving the tryout from the from status to published and giving
+	// it the version's details in place of any draft, and records the
+	// publish by actor in the audit log, all atomically. It fails with
+	// ErrStatusChanged if the tryout is no longer in the from status or
+	// another version was published in the meantime.
+	Publish(ctx context.Context, version *models.TryoutVersion, from string, actor models.User) error
+	// List returns the versions of a tryout without their questions, oldest first
+	List(ctx context.Context, tryoutID primitive.ObjectID) ([]models.TryoutVersion, error)
+	Get(ctx context.Context, tryoutID primitive.ObjectID, number int) (models.TryoutVersion, error)
+	// ListQuestionsForTaker returns the questions of a version, loading only
+	// the fields of the taker view
+	ListQuestionsForTaker(ctx context.Context, tryoutID primitive.ObjectID, number int) ([]models.TakerQuestion, error)
+}
@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"net/http"
+	"quiz-platform/models"
+	"testing"
+)
+
+func TestStudentsCannotAuthor(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Owned"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, "/api/v1/tryouts", sampleTryout("Mine")), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPut, base, sampleTryout("Edited")), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, base+"/questions", models.QuestionInput{Text: "q", IsTrue: true}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base+"/grading-queue", nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, "/api/v1/trash", nil), http.StatusForbidden)
+
+	if tryout.OwnerID != currentUser(t, router, testToken).ID {
+		t.Fatalf("expected the author to own the tryout, got owner %s", tryout.OwnerID.Hex())
+	}
+}
+
+func TestAuthorsManageOnlyTheirOwnTryouts(t *testing.T) {
+	router := newTestRouter(t)
+	other := registerAs(t, router, "other@example.com", models.RoleAuthor)
+	admin := registerAs(t, router, "admin@example.com", models.RoleAdmin)
+	tryout := createTryout(t, router, sampleTryout("Owned"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	questionPath := base + "/questions/" + question.ID.Hex()
+
+	expectStatus(t, doRequestAs(t, router, other, http.MethodPut, base, sampleTryout("Edited")), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodDelete, base, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodPost, base+"/questions", models.QuestionInput{Text: "q", IsTrue: true}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodPut, questionPath, models.QuestionInput{Text: "edited", IsTrue: true}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodDelete, questionPath, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodGet, base+"/grading-queue", nil), http.StatusForbidden)
+
+	// Admins manage every tryout
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, questionPath, models.QuestionInput{Text: "edited", IsTrue: true}), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, base, sampleTryout("Edited")), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodDelete, base, nil), http.StatusOK)
+
+	// The trash only lists and restores tryouts the user may manage
+	rec := doRequestAs(t, router, other, http.MethodGet, "/api/v1/trash", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var trash trashResponse
+	decode(t, rec, &trash)
+	if len(trash.Tryouts) != 0 {
+		t.Fatalf("expected another author's tryout to be hidden from the trash, got %+v", trash.Tryouts)
+	}
+	if trash = getTrash(t, router); len(trash.Tryouts) != 1 {
+		t.Fatalf("expected the owner to see the trashed tryout, got %+v", trash.Tryouts)
+	}
+	restorePath := "/api/v1/trash/tryouts/" + tryout.ID.Hex() + "/restore"
+	expectStatus(t, doRequestAs(t, router, other, http.MethodPost, restorePath, nil), http.StatusForbidden)
+	expectStatus(t, doRequest(t, router, http.MethodPost, restorePath, nil), http.StatusOK)
+}
+
+func TestSubmissionsAreVisibleToTheirTakerAndGraders(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	classmate := registerAs(t, router, "classmate@example.com", models.RoleStudent)
+	grader := registerAs(t, router, "grader@example.com", models.RoleGrader)
+	tryout := createTryout(t, router, sampleTryout("Graded"))
+	id := tryout.ID.Hex()
+	essay := createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionEssay, Text: "Discuss.", Points: floatPtr(5)})
+	publishTryout(t, router, id)
+	path := "/api/v1/tryouts/" + id + "/submissions"
+
+	rec := doRequestAs(t, router, student, http.MethodPost, "/api/v1/tryouts/"+id+"/attempts", models.AttemptInput{})
+	expectStatus(t, rec, http.StatusCreated)
+	var attempt models.Attempt
+	decode(t, rec, &attempt)
+	attemptPath := "/api/v1/tryouts/" + id + "/attempts/" + attempt.ID.Hex()
+
+	expectStatus(t, doRequestAs(t, router, classmate, http.MethodGet, attemptPath, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, grader, http.MethodGet, attemptPath, nil), http.StatusOK)
+
+	answer := models.AnswerInput{QuestionID: essay.ID.Hex(), Text: "An essay"}
+	expectStatus(t, doRequestAs(t, router, classmate, http.MethodPost, path, answersFor(attempt, answer)), http.StatusForbidden)
+	rec = doRequestAs(t, router, student, http.MethodPost, path, answersFor(attempt, answer))
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	submissionPath := path + "/" + submission.ID.Hex()
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, submissionPath, nil), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, classmate, http.MethodGet, submissionPath, nil), http.StatusForbidden)
+
+	for token, want := range map[string]int{student: 1, classmate: 0, grader: 1, testToken: 1} {
+		rec := doRequestAs(t, router, token, http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var submissions []models.Submission
+		decode(t, rec, &submissions)
+		if len(submissions) != want {
+			t.Fatalf("expected %d visible submissions, got %d", want, len(submissions))
+		}
+	}
+
+	// Graders grade any tryout without being able to edit it
+	expectStatus(t, doRequestAs(t, router, grader, http.MethodGet, "/api/v1/tryouts/"+id+"/grading-queue", nil), http.StatusOK)
+	gradePath := submissionPath + "/answers/" + essay.ID.Hex() + "/grade"
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPut, gradePath, models.GradeInput{Points: floatPtr(5)}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, grader, http.MethodPut, gradePath, models.GradeInput{Points: floatPtr(4)}), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, grader, http.MethodPut, "/api/v1/tryouts/"+id, sampleTryout("Edited")), http.StatusForbidden)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	router := newTestRouter(t)
+	admin := registerAs(t, router, "admin@example.com", models.RoleAdmin)
+	student := register(t, router, "student@example.com").AccessToken
+	studentID := currentUser(t, router, student).ID
+	path := "/api/v1/users/" + studentID.Hex() + "/role"
+
+	if role := currentUser(t, router, student).Role; role != models.RoleStudent {
+		t.Fatalf("expected new accounts to be students, got %q", role)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPut, path, models.RoleInput{Role: models.RoleAuthor}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPut, path, models.RoleInput{Role: models.RoleAdmin}), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, path, models.RoleInput{Role: "owner"}), http.StatusBadRequest)
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, "/api/v1/users/bad/role", models.RoleInput{Role: models.RoleAuthor}), http.StatusBadRequest)
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, "/api/v1/users/"+missingID+"/role", models.RoleInput{Role: models.RoleAuthor}), http.StatusNotFound)
+
+	adminPath := "/api/v1/users/" + currentUser(t, router, admin).ID.Hex() + "/role"
+	expectStatus(t, doRequestAs(t, router, admin, http.MethodPut, adminPath, models.RoleInput{Role: models.RoleStudent}), http.StatusBadRequest)
+
+	rec := doRequestAs(t, router, admin, http.MethodPut, path, models.RoleInput{Role: models.RoleAuthor})
+	expectStatus(t, rec, http.StatusOK)
+	var updated models.User
+	decode(t, rec, &updated)
+	if updated.Role != models.RoleAuthor {
+		t.Fatalf("expected the role to change, got %+v", updated)
+	}
+
+	// The new role applies to existing tokens
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, "/api/v1/tryouts", sampleTryout("Promoted")), http.StatusCreated)
+}
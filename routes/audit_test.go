@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"quiz-platform/jobs"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func getHistory(t *testing.T, router *gin.Engine, path string) []models.AuditEntry {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var entries []models.AuditEntry
+	decode(t, rec, &entries)
+	return entries
+}
+
+func TestHistoryRecordsChanges(t *testing.T) {
+	router := newTestRouter(t)
+	author := currentUser(t, router, testToken)
+	tryout := createTryout(t, router, sampleTryout("Audited"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "The sky is green.", IsTrue: false})
+
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/"+question.ID.Hex(), models.QuestionInput{Text: "The sky is green.", IsTrue: true}), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/versions", nil), http.StatusCreated)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/questions/"+question.ID.Hex(), nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/"+question.ID.Hex()+"/restore", nil), http.StatusOK)
+
+	entries := getHistory(t, router, base+"/history")
+	actions := []string{}
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	expected := []string{models.ActionRestored, models.ActionDeleted, models.ActionPublished, models.ActionUpdated, models.ActionCreated, models.ActionCreated}
+	if len(actions) != len(expected) {
+		t.Fatalf("expected actions %v, got %v", expected, actions)
+	}
+	for i := range expected {
+		if actions[i] != expected[i] {
+			t.Fatalf("expected actions %v, got %v", expected, actions)
+		}
+	}
+
+	// The answer key change records who made it and what it was before
+	updated := entries[3]
+	if updated.EntityType != models.EntityQuestion || updated.EntityID != question.ID || updated.TryoutID != tryout.ID ||
+		updated.ActorID != author.ID || updated.ActorName != author.Name || updated.At.IsZero() {
+		t.Fatalf("unexpected entry %+v", updated)
+	}
+	if len(updated.Changes) != 1 || updated.Changes[0].Field != "isTrue" ||
+		string(updated.Changes[0].From) != "false" || string(updated.Changes[0].To) != "true" {
+		t.Fatalf("unexpected changes %+v", updated.Changes)
+	}
+
+	created := entries[5]
+	if created.EntityType != models.EntityTryout || len(created.Changes) == 0 || string(created.Changes[0].From) != "null" {
+		t.Fatalf("unexpected created entry %+v", created)
+	}
+	// Deletes record the question as it was, restores as it came back
+	deleted, restored := entries[1], entries[0]
+	if len(deleted.Changes) == 0 || len(restored.Changes) == 0 {
+		t.Fatalf("expected deletes and restores to carry the question, got %+v and %+v", deleted.Changes, restored.Changes)
+	}
+	for _, change := range deleted.Changes {
+		if change.Field == "text" && (string(change.From) != `"The sky is green."` || string(change.To) != "null") {
+			t.Fatalf("unexpected deleted text %+v", change)
+		}
+	}
+	for _, change := range restored.Changes {
+		if change.Field == "text" && (string(change.From) != "null" || string(change.To) != `"The sky is green."`) {
+			t.Fatalf("unexpected restored text %+v", change)
+		}
+	}
+
+	published := entries[2]
+	if published.EntityType != models.EntityTryout || len(published.Changes) == 0 {
+		t.Fatalf("unexpected published entry %+v", published)
+	}
+
+	if filtered := getHistory(t, router, base+"/history?entityId="+question.ID.Hex()); len(filtered) != 4 {
+		t.Fatalf("expected 4 entries for the question, got %d", len(filtered))
+	}
+	if filtered := getHistory(t, router, base+"/history?action=updated"); len(filtered) != 1 || filtered[0].ID != updated.ID {
+		t.Fatalf("unexpected updated entries %+v", filtered)
+	}
+}
+
+func TestHistoryRecordsScheduledChanges(t *testing.T) {
+	router := newTestRouter(t)
+	opensAt := time.Now().Add(time.Hour)
+	input := sampleTryout("Scheduled")
+	input.OpensAt = &opensAt
+	tryout := createTryout(t, router, input)
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+
+	jobs.ApplyDueSchedules(context.Background(), opensAt)
+
+	entries := getHistory(t, router, "/api/v1/tryouts/"+tryout.ID.Hex()+"/history?action=published")
+	if len(entries) != 1 || entries[0].ActorName != models.SchedulerActor || !entries[0].ActorID.IsZero() || !entries[0].At.Equal(opensAt.Truncate(time.Millisecond)) {
+		t.Fatalf("unexpected scheduled entries %+v", entries)
+	}
+}
+
+func TestHistoryValidation(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	other := registerAs(t, router, "other@example.com", models.RoleAuthor)
+	tryout := createTryout(t, router, sampleTryout("Audited"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/history"
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"?entityId=nope", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"?action=renamed", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/not-an-id/history", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/history", nil), http.StatusNotFound)
+
+	// Only the tryout's managers browse its history
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodGet, base, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodGet, base, nil), http.StatusUnauthorized)
+}
+
+func TestRefusedChangesAreNotRecorded(t *testing.T) {
+	router := newTestRouter(t)
+	other := registerAs(t, router, "other@example.com", models.RoleAuthor)
+	tryout := createTryout(t, router, sampleTryout("Refused"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	first := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "First.", IsTrue: true})
+	second := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Second.", IsTrue: true})
+	lockTryout(t, router, tryout.ID.Hex())
+	recorded := len(getHistory(t, router, base+"/history"))
+
+	// Changes the repositories refuse leave no entry behind
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base, nil), http.StatusConflict)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/order", models.QuestionOrder{QuestionIDs: []primitive.ObjectID{first.ID}}), http.StatusBadRequest)
+	err := repository.Tryouts.SetStatus(context.Background(), tryout.ID, models.TryoutDraft, models.TryoutArchived, time.Now(), models.User{Name: models.SchedulerActor})
+	if err != repository.ErrStatusChanged {
+		t.Fatalf("expected ErrStatusChanged, got %v", err)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/questions/"+second.ID.Hex(), nil), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, other, http.MethodPost, "/api/v1/trash/questions/"+second.ID.Hex()+"/restore", nil), http.StatusForbidden)
+
+	entries := getHistory(t, router, base+"/history")
+	if len(entries) != recorded+1 || entries[0].Action != models.ActionDeleted || entries[0].EntityID != second.ID {
+		t.Fatalf("expected only the question delete to be recorded, got %+v", entries)
+	}
+}
+
+func TestUnrecordableChangesAreNotStored(t *testing.T) {
+	router := newTestRouter(t)
+	author := currentUser(t, router, testToken)
+	tryout := createTryout(t, router, sampleTryout("Unrecordable"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Fine.", IsTrue: true})
+	recorded := len(getHistory(t, router, base+"/history"))
+
+	// A change whose entry cannot be diffed fails as a whole instead of
+	// being stored without it
+	ctx := context.Background()
+	unencodable := models.Question{TryoutID: tryout.ID, Type: models.QuestionTrueFalse, Text: "NaN points", Points: math.NaN()}
+	if err := repository.Questions.Create(ctx, &unencodable, author); err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	fine := models.Question{TryoutID: tryout.ID, Type: models.QuestionTrueFalse, Text: "Fine too.", Points: 1}
+	if err := repository.Questions.CreateMany(ctx, tryout.ID, []models.Question{fine, unencodable}, author); err == nil {
+		t.Fatal("expected the import to fail")
+	}
+	question.Points = math.Inf(1)
+	if err := repository.Questions.Update(ctx, &question, author); err == nil {
+		t.Fatal("expected the update to fail")
+	}
+
+	questions := listQuestions(t, router, tryout.ID.Hex())
+	if len(questions) != 1 || questions[0].Points != 1 {
+		t.Fatalf("expected only the fine question to be stored, got %+v", questions)
+	}
+	if entries := getHistory(t, router, base+"/history"); len(entries) != recorded {
+		t.Fatalf("expected nothing to be recorded, got %+v", entries)
+	}
+}
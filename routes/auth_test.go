@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"quiz-platform/auth"
+	"quiz-platform/config"
+	"quiz-platform/models"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/register", models.RegisterInput{
+		Email:    "Student@Example.com",
+		Name:     "Student",
+		Password: "long enough password",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	if strings.Contains(rec.Body.String(), "passwordHash") || strings.Contains(rec.Body.String(), "long enough password") {
+		t.Fatalf("expected the password to stay private, got %s", rec.Body.String())
+	}
+	var registered struct {
+		User   models.User      `json:"user"`
+		Tokens models.TokenPair `json:"tokens"`
+	}
+	decode(t, rec, &registered)
+	if registered.User.Email != "student@example.com" || registered.Tokens.AccessToken == "" || registered.Tokens.TokenType != "Bearer" {
+		t.Fatalf("unexpected registration response %+v", registered)
+	}
+
+	// Emails are unique regardless of case
+	rec = doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/register", models.RegisterInput{
+		Email: "STUDENT@example.com", Name: "Copy", Password: "another password",
+	})
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/login", models.LoginInput{Email: "Student@example.com", Password: "long enough password"})
+	expectStatus(t, rec, http.StatusOK)
+	var loggedIn struct {
+		Tokens models.TokenPair `json:"tokens"`
+	}
+	decode(t, rec, &loggedIn)
+
+	rec = doRequestAs(t, router, loggedIn.Tokens.AccessToken, http.MethodGet, "/api/v1/auth/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var me models.User
+	decode(t, rec, &me)
+	if me.ID != registered.User.ID {
+		t.Fatalf("expected the signed-in user, got %+v", me)
+	}
+
+	for _, input := range []models.LoginInput{
+		{Email: "student@example.com", Password: "wrong password"},
+		{Email: "nobody@example.com", Password: "long enough password"},
+	} {
+		rec := doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/login", input)
+		expectStatus(t, rec, http.StatusUnauthorized)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, input := range []models.RegisterInput{
+		{Email: "not-an-email", Name: "Name", Password: "long enough password"},
+		{Email: "short@example.com", Name: "Name", Password: "short"},
+		{Email: "long@example.com", Name: "Name", Password: strings.Repeat("x", 73)},
+		{Email: "nameless@example.com", Password: "long enough password"},
+	} {
+		rec := doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/register", input)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("register %+v: expected status 400, got %d", input, rec.Code)
+		}
+	}
+}
+
+func TestRefreshTokens(t *testing.T) {
+	router := newTestRouter(t)
+	tokens := register(t, router, "refresh@example.com")
+
+	rec := doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/refresh", models.RefreshInput{RefreshToken: tokens.RefreshToken})
+	expectStatus(t, rec, http.StatusOK)
+	var refreshed models.TokenPair
+	decode(t, rec, &refreshed)
+	expectStatus(t, doRequestAs(t, router, refreshed.AccessToken, http.MethodGet, "/api/v1/auth/me", nil), http.StatusOK)
+
+	// Tokens only work for their own purpose
+	rec = doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/refresh", models.RefreshInput{RefreshToken: tokens.AccessToken})
+	expectStatus(t, rec, http.StatusUnauthorized)
+	expectStatus(t, doRequestAs(t, router, tokens.RefreshToken, http.MethodGet, "/api/v1/auth/me", nil), http.StatusUnauthorized)
+}
+
+func TestInvalidTokensAreRejected(t *testing.T) {
+	router := newTestRouter(t)
+	tokens := register(t, router, "tokens@example.com")
+
+	expired, err := auth.Sign(auth.Claims{
+		Subject:   primitive.NewObjectID().Hex(),
+		Type:      auth.TypeAccess,
+		IssuedAt:  time.Now().Add(-time.Hour).Unix(),
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	}, config.JWTSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := auth.Sign(auth.Claims{
+		Subject:   primitive.NewObjectID().Hex(),
+		Type:      auth.TypeAccess,
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, []byte("someone else's secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap in a payload for another user while keeping the original signature
+	parts := strings.Split(tokens.AccessToken, ".")
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + primitive.NewObjectID().Hex() + `","typ":"access","exp":9999999999}`))
+	tampered := parts[0] + "." + payload + "." + parts[2]
+
+	// Tokens of accounts that do not exist
+	unknownUser, err := auth.Sign(auth.Claims{
+		Subject:   primitive.NewObjectID().Hex(),
+		Type:      auth.TypeAccess,
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, config.JWTSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"expired":      expired,
+		"forged":       forged,
+		"tampered":     tampered,
+		"unknown user": unknownUser,
+		"garbage":      "not.a.token",
+	} {
+		rec := doRequestAs(t, router, token, http.MethodGet, "/api/v1/tryouts", nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s token: expected status 401, got %d", name, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tryouts", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestMutationsRequireSignIn(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Public"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Public question", IsTrue: true})
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	for _, request := range []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/v1/tryouts", sampleTryout("Anonymous")},
+		{http.MethodPut, base, sampleTryout("Anonymous")},
+		{http.MethodDelete, base, nil},
+		{http.MethodPost, base + "/questions", models.QuestionInput{Text: "Anonymous", IsTrue: true}},
+		{http.MethodPut, base + "/questions/" + question.ID.Hex(), models.QuestionInput{Text: "Anonymous", IsTrue: true}},
+		{http.MethodDelete, base + "/questions/" + question.ID.Hex(), nil},
+		{http.MethodGet, base + "/grading-queue", nil},
+		{http.MethodGet, "/api/v1/trash", nil},
+		{http.MethodGet, "/api/v1/auth/me", nil},
+	} {
+		rec := doRequestAs(t, router, "", request.method, request.path, request.body)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status 401, got %d", request.method, request.path, rec.Code)
+		}
+	}
+
+	// Reading published tryouts stays public
+	publishTryout(t, router, tryout.ID.Hex())
+	expectStatus(t, doRequestAs(t, router, "", http.MethodGet, "/api/v1/tryouts", nil), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodGet, base, nil), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodGet, base+"/questions", nil), http.StatusOK)
+}
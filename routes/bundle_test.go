@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"quiz-platform/models"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func exportTryout(t *testing.T, router *gin.Engine, path string) models.TryoutBundle {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var bundle models.TryoutBundle
+	decode(t, rec, &bundle)
+	return bundle
+}
+
+func TestExportAndImportTryout(t *testing.T) {
+	router := newTestRouter(t)
+	input := sampleTryout("Astronomy")
+	input.Shuffle = models.ShuffleSettings{Questions: true}
+	tryout := createTryout(t, router, input)
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "The sun is a star.", IsTrue: true, Explanation: "It is"})
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "Largest planet?",
+		Points:  floatPtr(2),
+		Options: []models.OptionInput{{Text: "Mars"}, {Text: "Jupiter", IsCorrect: true}},
+	})
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Type: models.QuestionNumeric, Text: "Planets?", NumericAnswer: floatPtr(8)})
+
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/export"
+	rec := doRequest(t, router, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "tryout-"+tryout.ID.Hex()+".json") {
+		t.Fatalf("unexpected Content-Disposition %q", disposition)
+	}
+	var bundle models.TryoutBundle
+	decode(t, rec, &bundle)
+	if bundle.Format != models.BundleFormat || bundle.SchemaVersion != models.BundleSchemaVersion || bundle.Version != models.DraftVersion {
+		t.Fatalf("unexpected bundle header %+v", bundle)
+	}
+	if bundle.Tryout.Title != "Astronomy" || !bundle.Tryout.Shuffle.Questions || len(bundle.Questions) != 3 {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	if q := bundle.Questions[1]; q.ID != "q2" || q.Points == nil || *q.Points != 2 || !q.Options[1].IsCorrect {
+		t.Fatalf("unexpected exported question %+v", q)
+	}
+	if strings.Contains(rec.Body.String(), tryout.ID.Hex()) {
+		t.Fatal("expected the bundle to hold no database IDs")
+	}
+
+	// The title is taken by the original, so the copy is renamed
+	rec = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", bundle)
+	expectStatus(t, rec, http.StatusCreated)
+	var imported models.BundleImport
+	decode(t, rec, &imported)
+	if imported.Tryout.ID == tryout.ID || imported.Tryout.Title != "Astronomy (2)" || imported.RenamedFrom != "Astronomy" {
+		t.Fatalf("unexpected import %+v", imported.Tryout)
+	}
+	if imported.Tryout.Status != models.TryoutDraft || !imported.Tryout.Shuffle.Questions || imported.Tryout.Duration != input.Duration {
+		t.Fatalf("unexpected imported tryout %+v", imported.Tryout)
+	}
+
+	questions := listQuestions(t, router, imported.Tryout.ID.Hex())
+	if len(questions) != 3 || questions[0].Explanation != "It is" || questions[2].NumericAnswer == nil || *questions[2].NumericAnswer != 8 {
+		t.Fatalf("unexpected imported questions %+v", questions)
+	}
+
+	// Exporting the copy gives back the same content
+	again := exportTryout(t, router, "/api/v1/tryouts/"+imported.Tryout.ID.Hex()+"/export")
+	again.Tryout.Title = bundle.Tryout.Title
+	again.ExportedAt = bundle.ExportedAt
+	if !reflect.DeepEqual(again, bundle) {
+		t.Fatalf("round trip changed the bundle:\n got %+v\nwant %+v", again, bundle)
+	}
+
+	// Titles are numbered on, ignoring case
+	bundle.Tryout.Title = "ASTRONOMY"
+	rec = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", bundle)
+	expectStatus(t, rec, http.StatusCreated)
+	decode(t, rec, &imported)
+	if imported.Tryout.Title != "ASTRONOMY (3)" {
+		t.Fatalf("expected the next free title, got %q", imported.Tryout.Title)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import?onConflict=fail", bundle), http.StatusConflict)
+	bundle.Tryout.Title = "Cosmology"
+	rec = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import?onConflict=fail", bundle)
+	expectStatus(t, rec, http.StatusCreated)
+	imported = models.BundleImport{}
+	decode(t, rec, &imported)
+	if imported.Tryout.Title != "Cosmology" || imported.RenamedFrom != "" {
+		t.Fatalf("expected a free title to be kept, got %+v", imported)
+	}
+}
+
+func TestExportPublishedVersion(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Versions"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "published", IsTrue: true})
+	publishTryout(t, router, tryout.ID.Hex())
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+tryout.ID.Hex()+"/questions/"+question.ID.Hex(),
+		models.QuestionInput{Text: "edited", IsTrue: true}), http.StatusOK)
+
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/export"
+	if bundle := exportTryout(t, router, path+"?version=1"); bundle.Version != "1" || bundle.Questions[0].Text != "published" {
+		t.Fatalf("unexpected export of version 1 %+v", bundle)
+	}
+	if bundle := exportTryout(t, router, path); bundle.Questions[0].Text != "edited" {
+		t.Fatalf("expected the working copy by default, got %+v", bundle)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodGet, path+"?version=2", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, path+"?version=latest", nil), http.StatusBadRequest)
+}
+
+func TestImportTryoutValidation(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Source"))
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	bundle := exportTryout(t, router, "/api/v1/tryouts/"+tryout.ID.Hex()+"/export")
+
+	newer := bundle
+	newer.SchemaVersion = models.BundleSchemaVersion + 1
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", newer)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), "schemaVersion") {
+		t.Fatalf("expected the schema version to be reported, got %s", rec.Body.String())
+	}
+
+	foreign := bundle
+	foreign.Format = "something/else"
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", foreign), http.StatusBadRequest)
+
+	untitled := bundle
+	untitled.Tryout.Title = ""
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", untitled), http.StatusBadRequest)
+
+	invalid := bundle
+	invalid.Questions = []models.BundleQuestion{bundle.Questions[0], {ID: "q2", Type: models.QuestionMultipleChoice, Text: "no options"}}
+	rec = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", invalid)
+	expectStatus(t, rec, http.StatusBadRequest)
+	var rejected importErrors
+	decode(t, rec, &rejected)
+	if len(rejected.Errors) != 1 || rejected.Errors[0].Row != 2 {
+		t.Fatalf("unexpected errors %+v", rejected)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import?onConflict=merge", bundle), http.StatusBadRequest)
+	expectStatus(t, doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import", "application/json", "{"), http.StatusBadRequest)
+
+	// Nothing was created by the rejected imports
+	if list := listTryouts(t, router, "/api/v1/tryouts"); len(list.Items) != 1 {
+		t.Fatalf("expected only the source tryout, got %v", titles(list.Items))
+	}
+}
+
+func TestExportAndImportAccess(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Private"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/export"
+	bundle := exportTryout(t, router, path)
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, path, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, "/api/v1/tryouts/import", bundle), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodPost, "/api/v1/tryouts/import", bundle), http.StatusUnauthorized)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/export", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/bad/export", nil), http.StatusBadRequest)
+
+	// Imports belong to whoever imported them
+	author := registerAs(t, router, "other@example.com", models.RoleAuthor)
+	rec := doRequestAs(t, router, author, http.MethodPost, "/api/v1/tryouts/import", bundle)
+	expectStatus(t, rec, http.StatusCreated)
+	var imported models.BundleImport
+	decode(t, rec, &imported)
+	if imported.Tryout.OwnerID != currentUser(t, router, author).ID {
+		t.Fatalf("expected the importing author to own the tryout, got %+v", imported.Tryout)
+	}
+}
+
+func TestConcurrentImportsGetDistinctTitles(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Popular"))
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Imported at once.", IsTrue: true})
+	bundle := exportTryout(t, router, "/api/v1/tryouts/"+tryout.ID.Hex()+"/export")
+	// Numbered titles in use are skipped too, ignoring case
+	createTryout(t, router, sampleTryout("popular (2)"))
+
+	const imports = 8
+	recs := make([]*httptest.ResponseRecorder, imports)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/import", bundle)
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, rec := range recs {
+		expectStatus(t, rec, http.StatusCreated)
+		var imported models.BundleImport
+		decode(t, rec, &imported)
+		if seen[imported.Tryout.Title] {
+			t.Fatalf("two imports got the title %q", imported.Tryout.Title)
+		}
+		seen[imported.Tryout.Title] = true
+		if questions := listQuestions(t, router, imported.Tryout.ID.Hex()); len(questions) != 1 || questions[0].Position != 1 {
+			t.Fatalf("unexpected questions of %q: %+v", imported.Tryout.Title, questions)
+		}
+	}
+	if seen["Popular (2)"] || !seen["Popular (3)"] || !seen["Popular (10)"] {
+		t.Fatalf("expected titles Popular (3) to Popular (10), got %v", seen)
+	}
+}
@@ -0,0 +1,190 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// testToken is the access token doRequest signs in with, issued to the
+// account registered by newTestRouter
+var testToken string
+
+// newTestRouter returns a router backed by a fresh in-memory store, with a
+// signed-in test author
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	repository.UseMemory()
+	router := SetupRouter()
+	testToken = registerAs(t, router, "author@example.com", models.RoleAuthor)
+	return router
+}
+
+// registerAs creates an account with the given role and returns its access token
+func registerAs(t *testing.T, router *gin.Engine, email, role string) string {
+	t.Helper()
+	tokens := register(t, router, email)
+	user := currentUser(t, router, tokens.AccessToken)
+	if err := repository.Users.SetRole(context.Background(), user.ID, role, time.Now()); err != nil {
+		t.Fatalf("setting role: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+// currentUser returns the account an access token belongs to
+func currentUser(t *testing.T, router *gin.Engine, token string) models.User {
+	t.Helper()
+	rec := doRequestAs(t, router, token, http.MethodGet, "/api/v1/auth/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var user models.User
+	decode(t, rec, &user)
+	return user
+}
+
+// register creates an account through the API and returns its tokens
+func register(t *testing.T, router *gin.Engine, email string) models.TokenPair {
+	t.Helper()
+	rec := doRequestAs(t, router, "", http.MethodPost, "/api/v1/auth/register", models.RegisterInput{
+		Email:    email,
+		Name:     "Test User",
+		Password: "correct horse battery",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var body struct {
+		Tokens models.TokenPair `json:"tokens"`
+	}
+	decode(t, rec, &body)
+	return body.Tokens
+}
+
+// doRequest sends a request with an optional JSON body through the router,
+// signed in as the test account
+func doRequest(t *testing.T, router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return doRequestAs(t, router, testToken, method, path, body)
+}
+
+// doRequestAs is like doRequest but sends the given access token, or none if it is empty
+func doRequestAs(t *testing.T, router *gin.Engine, token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewBuffer(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals a JSON response body into out
+func decode(t *testing.T, rec *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// expectStatus fails the test if the response has an unexpected status code
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+}
+
+// createTryout creates a tryout through the API
+func createTryout(t *testing.T, router *gin.Engine, input models.TryoutInput) models.Tryout {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts", input)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var tryout models.Tryout
+	decode(t, rec, &tryout)
+	return tryout
+}
+
+// sampleTryout returns valid tryout input with the given title
+func sampleTryout(title string) models.TryoutInput {
+	return models.TryoutInput{
+		Title:       title,
+		Description: "A tryout used in tests",
+		Category:    "Testing",
+		Duration:    30,
+	}
+}
+
+// createQuestion adds a question to a tryout through the API
+func createQuestion(t *testing.T, router *gin.Engine, tryoutID string, input models.QuestionInput) models.Question {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryoutID+"/questions", input)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var question models.Question
+	decode(t, rec, &question)
+	return question
+}
+
+// publishTryout publishes a tryout through the API. The tryout needs a question.
+func publishTryout(t *testing.T, router *gin.Engine, tryoutID string) {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+tryoutID+"/status", models.StatusInput{Status: models.TryoutPublished})
+	expectStatus(t, rec, http.StatusOK)
+}
+
+// startAttempt starts an attempt on a tryout through the API
+func startAttempt(t *testing.T, router *gin.Engine, tryoutID string) models.Attempt {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryoutID+"/attempts", models.AttemptInput{TakerName: "Test Taker"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var attempt models.Attempt
+	decode(t, rec, &attempt)
+	return attempt
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func intPtr(value int) *int {
+	return &value
+}
@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"quiz-platform/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doUpload sends a request body of the given content type, signed in as the
+// test account
+func doUpload(t *testing.T, router *gin.Engine, method, path, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// importErrors is the body of a rejected import
+type importErrors struct {
+	Error  string               `json:"error"`
+	Errors []models.ImportError `json:"errors"`
+}
+
+func listQuestions(t *testing.T, router *gin.Engine, tryoutID string) []models.Question {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryoutID+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var questions []models.Question
+	decode(t, rec, &questions)
+	return questions
+}
+
+func TestImportQuestionsFromJSON(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Imported"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "existing", IsTrue: true})
+
+	inputs := []models.QuestionInput{
+		{Text: "The earth is round.", IsTrue: true},
+		{Type: models.QuestionMultipleChoice, Text: "Largest planet?", Options: []models.OptionInput{{Text: "Jupiter", IsCorrect: true}, {Text: "Mars"}}},
+		{Type: models.QuestionNumeric, Text: "6 x 7?", NumericAnswer: floatPtr(42), Points: floatPtr(2)},
+	}
+
+	// A dry run validates without storing anything
+	rec := doRequest(t, router, http.MethodPost, path+"?dryRun=true", inputs)
+	expectStatus(t, rec, http.StatusOK)
+	var report models.QuestionImport
+	decode(t, rec, &report)
+	if !report.DryRun || report.Imported != 0 || len(report.Questions) != 3 || !report.Questions[0].ID.IsZero() {
+		t.Fatalf("unexpected dry run report %+v", report)
+	}
+	if questions := listQuestions(t, router, tryout.ID.Hex()); len(questions) != 1 {
+		t.Fatalf("expected a dry run to store nothing, got %d questions", len(questions))
+	}
+
+	rec = doRequest(t, router, http.MethodPost, path, inputs)
+	expectStatus(t, rec, http.StatusCreated)
+	decode(t, rec, &report)
+	if report.DryRun || report.Imported != 3 || report.Questions[2].Points != 2 {
+		t.Fatalf("unexpected import report %+v", report)
+	}
+
+	questions := listQuestions(t, router, tryout.ID.Hex())
+	if len(questions) != 4 || questions[0].Text != "existing" || questions[1].Text != "The earth is round." || questions[3].Position != 4 {
+		t.Fatalf("expected the imported questions after the existing one, got %+v", questions)
+	}
+	if questions[2].Type != models.QuestionMultipleChoice || !questions[2].Options[0].IsCorrect {
+		t.Fatalf("unexpected imported choice question %+v", questions[2])
+	}
+}
+
+func TestImportQuestionsFromCSV(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("CSV"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+
+	csv := "\ufefftype,text,points,isTrue,options,correct,acceptedAnswers,numericAnswer,tolerance,explanation\n" +
+		"true_false,The sky is blue.,,true,,,,,,Rayleigh scattering\n" +
+		"multi_select,\"Primes, pick all\",3,,2|3|4,1|2,,,,\n" +
+		"short_answer,Who wrote Hamlet?,,,,,Shakespeare|William Shakespeare,,,\n" +
+		"numeric,Value of pi?,,,,,,3.14,0.01,\n"
+	rec := doUpload(t, router, http.MethodPost, path, "text/csv", csv)
+	expectStatus(t, rec, http.StatusCreated)
+
+	questions := listQuestions(t, router, tryout.ID.Hex())
+	if len(questions) != 4 {
+		t.Fatalf("expected 4 questions, got %d", len(questions))
+	}
+	if q := questions[0]; !q.IsTrue || q.Points != 1 || q.Explanation != "Rayleigh scattering" {
+		t.Fatalf("unexpected true/false question %+v", q)
+	}
+	if q := questions[1]; q.Text != "Primes, pick all" || q.Points != 3 || len(q.Options) != 3 ||
+		!q.Options[0].IsCorrect || !q.Options[1].IsCorrect || q.Options[2].IsCorrect {
+		t.Fatalf("unexpected multi-select question %+v", q)
+	}
+	if q := questions[2]; len(q.AcceptedAnswers) != 2 || q.AcceptedAnswers[1] != "William Shakespeare" {
+		t.Fatalf("unexpected short answer question %+v", q)
+	}
+	if q := questions[3]; q.NumericAnswer == nil || *q.NumericAnswer != 3.14 || q.Tolerance != 0.01 {
+		t.Fatalf("unexpected numeric question %+v", q)
+	}
+}
+
+func TestImportQuestionsIsAllOrNothing(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Rejected"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+
+	csv := "text,type,points,options,correct\n" +
+		"Fine,,,,\n" +
+		",,,,\n" +
+		"Choice,multiple_choice,,A|B,3\n" +
+		"Points,,many,,\n" +
+		"Too,many,columns,here,,\n" +
+		"No key,multiple_choice,,A|B,\n"
+	rec := doUpload(t, router, http.MethodPost, path, "text/csv", csv)
+	expectStatus(t, rec, http.StatusBadRequest)
+	var rejected importErrors
+	decode(t, rec, &rejected)
+	rows := []int{}
+	for _, rowError := range rejected.Errors {
+		rows = append(rows, rowError.Row)
+	}
+	if len(rows) != 5 || rows[0] != 2 || rows[1] != 3 || rows[2] != 4 || rows[3] != 5 || rows[4] != 6 {
+		t.Fatalf("expected errors for rows 2 to 6, got %+v", rejected)
+	}
+	if questions := listQuestions(t, router, tryout.ID.Hex()); len(questions) != 0 {
+		t.Fatalf("expected nothing to be imported, got %d questions", len(questions))
+	}
+
+	// Dry runs report the same errors
+	rec = doRequest(t, router, http.MethodPost, path+"?dryRun=true", []models.QuestionInput{{Text: "ok"}, {Text: "bad", Points: floatPtr(-1)}})
+	expectStatus(t, rec, http.StatusBadRequest)
+	decode(t, rec, &rejected)
+	if len(rejected.Errors) != 1 || rejected.Errors[0].Row != 2 {
+		t.Fatalf("unexpected errors %+v", rejected)
+	}
+
+	cases := map[string]struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		"empty array":        {"application/json", "[]", http.StatusBadRequest},
+		"malformed JSON":     {"application/json", "{", http.StatusBadRequest},
+		"empty CSV":          {"text/csv", "", http.StatusBadRequest},
+		"unknown CSV column": {"text/csv", "text,answer\nq,yes\n", http.StatusBadRequest},
+		"CSV without text":   {"text/csv", "type\ntrue_false\n", http.StatusBadRequest},
+		"unsupported type":   {"application/xml", "<questions/>", http.StatusUnsupportedMediaType},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, doUpload(t, router, http.MethodPost, path, tc.contentType, tc.body), tc.status)
+		})
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, path+"?dryRun=maybe", []models.QuestionInput{{Text: "q"}}), http.StatusBadRequest)
+	tooMany := make([]models.QuestionInput, models.MaxImportRows+1)
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, tooMany), http.StatusBadRequest)
+}
+
+func TestImportRejectsNonFiniteNumbers(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Finite"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+
+	cases := map[string]string{
+		"NaN points":                 "type,text,points\ntrue_false,q,NaN\n",
+		"infinite points":            "type,text,points\ntrue_false,q,Inf\n",
+		"NaN numericAnswer":          "type,text,numericAnswer\nnumeric,q,NaN\n",
+		"infinite numericAnswer":     "type,text,numericAnswer\nnumeric,q,-Inf\n",
+		"NaN tolerance":              "type,text,numericAnswer,tolerance\nnumeric,q,1,NaN\n",
+		"infinite tolerance":         "type,text,numericAnswer,tolerance\nnumeric,q,1,+Inf\n",
+		"NaN relativeTolerance":      "type,text,numericAnswer,relativeTolerance\nnumeric,q,1,NaN\n",
+		"infinite relativeTolerance": "type,text,numericAnswer,relativeTolerance\nnumeric,q,1,Inf\n",
+	}
+	for name, csv := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, query := range []string{"", "?dryRun=true"} {
+				rec := doUpload(t, router, http.MethodPost, path+query, "text/csv", csv)
+				expectStatus(t, rec, http.StatusBadRequest)
+				var rejected importErrors
+				decode(t, rec, &rejected)
+				if len(rejected.Errors) != 1 || rejected.Errors[0].Row != 1 {
+					t.Fatalf("unexpected errors %+v", rejected)
+				}
+			}
+		})
+	}
+	if questions := listQuestions(t, router, tryout.ID.Hex()); len(questions) != 0 {
+		t.Fatalf("expected nothing to be imported, got %d questions", len(questions))
+	}
+}
+
+func TestImportQuestionsAccess(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Guarded"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+	body := []models.QuestionInput{{Text: "q", IsTrue: true}}
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, path, body), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodPost, path, body), http.StatusUnauthorized)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+missingID+"/questions/import", body), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/bad/questions/import", body), http.StatusBadRequest)
+
+	// Taken tryouts take imports into their draft version like any other edit
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "first", IsTrue: true})
+	publishTryout(t, router, tryout.ID.Hex())
+	startAttempt(t, router, tryout.ID.Hex())
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, body), http.StatusCreated)
+	var history models.VersionHistory
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryout.ID.Hex()+"/versions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &history)
+	if !history.HasDraftChanges {
+		t.Fatal("expected the import to be a draft change")
+	}
+}
@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"net/http"
+	"os"
+	"quiz-platform/models"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importMoodle imports questions in a Moodle format into a tryout
+func importMoodle(t *testing.T, router *gin.Engine, tryoutID, format, body string) []models.Question {
+	t.Helper()
+	rec := doUpload(t, router, http.MethodPost, "/api/v1/tryouts/"+tryoutID+"/questions/import?format="+format, "text/plain", body)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var report models.QuestionImport
+	decode(t, rec, &report)
+	return report.Questions
+}
+
+// exportMoodle exports the questions of a tryout in a Moodle format
+func exportMoodle(t *testing.T, router *gin.Engine, tryoutID, query string) (string, string) {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryoutID+"/questions/export?"+query, nil)
+	expectStatus(t, rec, http.StatusOK)
+	return rec.Body.String(), rec.Header().Get("X-Skipped-Questions")
+}
+
+func questionTypes(questions []models.Question) []string {
+	types := make([]string, len(questions))
+	for i, question := range questions {
+		types[i] = question.Type
+	}
+	return types
+}
+
+// TestMoodleRoundTrip imports each corpus and checks that exporting the
+// questions gives back the same file
+func TestMoodleRoundTrip(t *testing.T) {
+	corpora := map[string][]string{
+		"gift": {
+			models.QuestionTrueFalse, models.QuestionTrueFalse, models.QuestionMultipleChoice,
+			models.QuestionMultiSelect, models.QuestionMultiSelect, models.QuestionShortAnswer,
+			models.QuestionNumeric, models.QuestionNumeric, models.QuestionEssay, models.QuestionEssay,
+			models.QuestionTrueFalse, models.QuestionMultipleChoice,
+		},
+		"aiken": {
+			models.QuestionMultipleChoice, models.QuestionTrueFalse, models.QuestionTrueFalse,
+			models.QuestionMultipleChoice, models.QuestionMultipleChoice,
+		},
+	}
+	for format, types := range corpora {
+		t.Run(format, func(t *testing.T) {
+			corpus, err := os.ReadFile("testdata/corpus." + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router := newTestRouter(t)
+			tryout := createTryout(t, router, sampleTryout("Moodle"))
+
+			questions := importMoodle(t, router, tryout.ID.Hex(), format, string(corpus))
+			if got := questionTypes(questions); !slices.Equal(got, types) {
+				t.Fatalf("expected types %v, got %v", types, got)
+			}
+			exported, skipped := exportMoodle(t, router, tryout.ID.Hex(), "format="+format)
+			if exported != string(corpus) || skipped != "" {
+				t.Fatalf("round trip changed the corpus (skipped %q):\n%s", skipped, exported)
+			}
+		})
+	}
+}
+
+func TestImportGIFT(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("GIFT"))
+
+	gift := "\ufeff// Written by hand in Moodle\n" +
+		"$CATEGORY: $course$/Science\n\n" +
+		"::Q1:: [html]The earth is flat. {FALSE#Wrong!#Right!}\n\n" +
+		"::Q2::Which planet is\nthe largest? {\n  =Jupiter#Yes\n  ~Mars#No\n  ~%0%Venus\n  ####Jupiter is a gas giant.\n}\n\n" +
+		"The capital of France is {=Paris =paris =%50%Lyon}.\n\n" +
+		"::Range:: Pick a number between 1 and 5. {#1..5}\n\n" +
+		"Speed of light in km/s? {#\n  =300000:1000#Close enough\n  =%50%299792\n}\n\n" +
+		"Colours of the French flag? {~%33.3%Blue ~%33.3%White ~%33.4%Red ~%-100%Green}\n"
+	questions := importMoodle(t, router, tryout.ID.Hex(), "gift", gift)
+	if len(questions) != 6 {
+		t.Fatalf("expected 6 questions, got %d", len(questions))
+	}
+	if q := questions[0]; q.Type != models.QuestionTrueFalse || q.IsTrue || q.Text != "The earth is flat." {
+		t.Fatalf("unexpected true/false question %+v", q)
+	}
+	if q := questions[1]; q.Type != models.QuestionMultipleChoice || q.Text != "Which planet is the largest?" ||
+		len(q.Options) != 3 || !q.Options[0].IsCorrect || q.Options[2].Text != "Venus" || q.Explanation != "Jupiter is a gas giant." {
+		t.Fatalf("unexpected multiple choice question %+v", q)
+	}
+	if q := questions[2]; q.Type != models.QuestionShortAnswer || q.Text != "The capital of France is _____ ." || len(q.AcceptedAnswers) != 2 {
+		t.Fatalf("unexpected missing word question %+v", q)
+	}
+	if q := questions[3]; q.NumericAnswer == nil || *q.NumericAnswer != 3 || q.Tolerance != 2 {
+		t.Fatalf("unexpected numeric range question %+v", q)
+	}
+	if q := questions[4]; q.NumericAnswer == nil || *q.NumericAnswer != 300000 || q.Tolerance != 1000 {
+		t.Fatalf("unexpected numeric question %+v", q)
+	}
+	if q := questions[5]; q.Type != models.QuestionMultiSelect || !q.Options[2].IsCorrect || q.Options[3].IsCorrect {
+		t.Fatalf("unexpected multi-select question %+v", q)
+	}
+}
+
+func TestImportAiken(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Aiken"))
+
+	aiken := "Which is a mammal?\nA) Shark\nB) Whale\nANSWER: B\n" +
+		"Which is a bird?\n(choose one)\nA. Penguin\nB. Bat\nANSWER: A\n\n\n" +
+		"Is this true?\nA. true\nB. false\nANSWER: B\n"
+	questions := importMoodle(t, router, tryout.ID.Hex(), "aiken", aiken)
+	if len(questions) != 3 {
+		t.Fatalf("expected 3 questions, got %d", len(questions))
+	}
+	if q := questions[0]; q.Type != models.QuestionMultipleChoice || !q.Options[1].IsCorrect || q.Options[1].Text != "Whale" {
+		t.Fatalf("unexpected question %+v", q)
+	}
+	if q := questions[1]; q.Text != "Which is a bird?\n(choose one)" || !q.Options[0].IsCorrect {
+		t.Fatalf("unexpected question %+v", q)
+	}
+	if q := questions[2]; q.Type != models.QuestionTrueFalse || q.IsTrue || len(q.Options) != 0 {
+		t.Fatalf("unexpected true/false question %+v", q)
+	}
+}
+
+func TestMoodleImportErrors(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Broken"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/import"
+
+	cases := map[string]struct {
+		format string
+		body   string
+		rows   []int
+	}{
+		"gift": {"gift", "Fine {T}\n\n" +
+			"No answers here.\n\n" +
+			"Match the capitals. {=France -> Paris =Italy -> Rome}\n\n" +
+			"Unclosed {=a ~b\n\n" +
+			"Bad number {#many}\n\n" +
+			"Stray text {here =a ~b}\n\n" +
+			"Two correct with no wrong? Fine {=a =b}\n", []int{2, 3, 4, 5, 6}},
+		"gift non-finite": {"gift", "Fine {#1}\n\n" +
+			"Not a number {#NaN}\n\n" +
+			"Infinite {#Inf:1}\n\n" +
+			"Infinite tolerance {#1:-inf}\n\n" +
+			"Infinite range {#1..Inf}\n\n" +
+			"NaN weight {=%NaN%a ~b}\n", []int{2, 3, 4, 5, 6}},
+		"aiken": {"aiken", "Fine?\nA. yes\nB. no\nANSWER: A\n\n" +
+			"No answer line?\nA. yes\nB. no\n\n" +
+			"Out of order?\nA. yes\nC. no\nANSWER: A\n\n" +
+			"Answer out of range?\nA. yes\nB. no\nANSWER: D\n\n" +
+			"Single option?\nA. yes\nANSWER: A\n\n" +
+			"Last one?\nA. yes\nB. no\nANSWER: B\n", []int{2, 3, 4, 5}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := doUpload(t, router, http.MethodPost, path+"?format="+tc.format, "text/plain", tc.body)
+			expectStatus(t, rec, http.StatusBadRequest)
+			var rejected importErrors
+			decode(t, rec, &rejected)
+			rows := []int{}
+			for _, rowError := range rejected.Errors {
+				rows = append(rows, rowError.Row)
+			}
+			if !slices.Equal(rows, tc.rows) {
+				t.Fatalf("expected errors for questions %v, got %+v", tc.rows, rejected)
+			}
+		})
+	}
+
+	expectStatus(t, doUpload(t, router, http.MethodPost, path+"?format=docx", "text/plain", "Q {T}"), http.StatusBadRequest)
+	expectStatus(t, doUpload(t, router, http.MethodPost, path+"?format=gift", "text/plain", "// only a comment\n"), http.StatusBadRequest)
+	// The format decides over the content type
+	expectStatus(t, doUpload(t, router, http.MethodPost, path+"?format=gift&dryRun=true", "application/json", "Q {T}"), http.StatusOK)
+	if questions := listQuestions(t, router, tryout.ID.Hex()); len(questions) != 0 {
+		t.Fatalf("expected nothing to be imported, got %d questions", len(questions))
+	}
+}
+
+func TestMoodleExport(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Mixed"))
+	id := tryout.ID.Hex()
+	createQuestion(t, router, id, models.QuestionInput{Text: "Pluto is a planet.", Points: floatPtr(3)})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Chemical symbol of gold?", AcceptedPatterns: []string{"^au$"}})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionNumeric, Text: "Boiling point of water?", NumericAnswer: floatPtr(100), RelativeTolerance: 0.05})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Capital of Japan?", AcceptedAnswers: []string{"Tokyo"}, AcceptedPatterns: []string{"^tokio$"}})
+	publishTryout(t, router, id)
+	createQuestion(t, router, id, models.QuestionInput{Text: "Added after publishing.", IsTrue: true})
+
+	// Patterns cannot be written in GIFT, and relative tolerances become absolute
+	gift, skipped := exportMoodle(t, router, id, "format=gift")
+	want := "Pluto is a planet. {F}\n\n" +
+		"Boiling point of water? {#100:5}\n\n" +
+		"Capital of Japan? {=Tokyo}\n\n" +
+		"Added after publishing. {T}\n\n"
+	if gift != want || skipped != "2" {
+		t.Fatalf("unexpected GIFT export (skipped %q):\n%s", skipped, gift)
+	}
+
+	// Aiken only holds choice questions
+	aiken, skipped := exportMoodle(t, router, id, "format=aiken&version=1")
+	if aiken != "Pluto is a planet.\nA. True\nB. False\nANSWER: B\n\n" || skipped != "2,3,4" {
+		t.Fatalf("unexpected Aiken export of version 1 (skipped %q):\n%s", skipped, aiken)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/questions/export?format=gift", nil)
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename="tryout-`+id+`-gift.txt"` {
+		t.Fatalf("unexpected Content-Disposition %q", disposition)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/questions/export", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/questions/export?format=csv", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/questions/export?format=gift&version=9", nil), http.StatusNotFound)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, "/api/v1/tryouts/"+id+"/questions/export?format=gift", nil), http.StatusForbidden)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/questions/export?format=gift", nil), http.StatusNotFound)
+}
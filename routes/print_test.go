@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"net/http"
+	"quiz-platform/models"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// printTryout prints a tryout and returns the rendered page
+func printTryout(t *testing.T, router *gin.Engine, tryoutID, query string) string {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryoutID+"/print?"+query, nil)
+	expectStatus(t, rec, http.StatusOK)
+	return rec.Body.String()
+}
+
+// printableTryout creates a tryout with one question of each type
+func printableTryout(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	tryout := createTryout(t, router, sampleTryout("Chemistry"))
+	id := tryout.ID.Hex()
+	createQuestion(t, router, id, models.QuestionInput{Text: "Water is wet.", IsTrue: true})
+	createQuestion(t, router, id, models.QuestionInput{
+		Type:        models.QuestionMultipleChoice,
+		Text:        "Symbol of sodium?",
+		Options:     []models.OptionInput{{Text: "So"}, {Text: "Na", IsCorrect: true}, {Text: "Sd"}},
+		Explanation: "From the Latin natrium.",
+	})
+	createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultiSelect,
+		Text:    "Which are <metals>?",
+		Points:  floatPtr(2),
+		Options: []models.OptionInput{{Text: "Iron", IsCorrect: true}, {Text: "Neon"}, {Text: "Zinc", IsCorrect: true}, {Text: "Argon"}},
+	})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Symbol of iron?", AcceptedAnswers: []string{"Fe"}, AcceptedPatterns: []string{"^fe$"}, CaseSensitive: true})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionNumeric, Text: "Molar mass of water?", NumericAnswer: floatPtr(18), Tolerance: 0.5, RelativeTolerance: 0.01, Points: floatPtr(1.5)})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionEssay, Text: "Describe a titration.\nUse an example.", Points: floatPtr(5)})
+	return id
+}
+
+func TestPrintTryout(t *testing.T) {
+	router := newTestRouter(t)
+	id := printableTryout(t, router)
+
+	line := "   ________________________________________________\n"
+	want := "Chemistry\n" +
+		"A tryout used in tests\n" +
+		"Testing · 30 minutes · 6 questions · 11.5 points · Draft\n\n" +
+		"Name: ______________________________  Date: ______________\n\n" +
+		"1. Water is wet. (1 point)\n   (T) True\n   (F) False\n\n" +
+		"2. Symbol of sodium? (1 point)\n   (A) So\n   (B) Na\n   (C) Sd\n\n" +
+		"3. Which are <metals>? (2 points) Select all that apply.\n   (A) Iron\n   (B) Neon\n   (C) Zinc\n   (D) Argon\n\n" +
+		"4. Symbol of iron? (1 point)\n" + line + "\n" +
+		"5. Molar mass of water? (1.5 points)\n" + line + "\n" +
+		"6. Describe a titration.\n   Use an example. (5 points)\n" + strings.Repeat(line, 8) + "\n"
+	if got := printTryout(t, router, id, "format=text"); got != want {
+		t.Fatalf("unexpected exam:\n%s", got)
+	}
+
+	key := "Chemistry — Answer key\n" +
+		"A tryout used in tests\n" +
+		"Testing · 30 minutes · 6 questions · 11.5 points · Draft\n\n" +
+		"1. True (1 point)\n" +
+		"2. B (1 point)\n   From the Latin natrium.\n" +
+		"3. A, C (2 points)\n" +
+		"4. Fe or /^fe$/ (case-sensitive) (1 point)\n" +
+		"5. 18 ± 0.5 or ± 1% (1.5 points)\n" +
+		"6. Graded by hand (5 points)\n"
+	if got := printTryout(t, router, id, "format=text&answerKey=true"); got != key {
+		t.Fatalf("unexpected answer key:\n%s", got)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/print", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	page := rec.Body.String()
+	for _, want := range []string{
+		"<title>Chemistry</title>",
+		`<span class="number">1.</span> Water is wet. <span class="points">(1 point)</span>`,
+		`<li><span class="bubble">B</span>Na</li>`,
+		"Which are &lt;metals&gt;?",
+		"Describe a titration.<br>Use an example.",
+		`<div class="taker">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected the exam to contain %s:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "natrium") || strings.Contains(page, `class="key"`) {
+		t.Fatalf("expected the exam to leave out the answers:\n%s", page)
+	}
+
+	page = printTryout(t, router, id, "answerKey=true")
+	if !strings.Contains(page, "<tr><td>2</td><td>B</td><td>1 point</td><td>From the Latin natrium.</td></tr>") || strings.Contains(page, `class="taker"`) {
+		t.Fatalf("unexpected answer key:\n%s", page)
+	}
+}
+
+func TestPrintVariants(t *testing.T) {
+	router := newTestRouter(t)
+	id := printableTryout(t, router)
+	publishTryout(t, router, id)
+
+	exams := strings.Split(printTryout(t, router, id, "format=text&variants=3&version=1"), "\f")
+	keys := strings.Split(printTryout(t, router, id, "format=text&variants=3&version=1&answerKey=true"), "\f")
+	if len(exams) != 3 || len(keys) != 3 {
+		t.Fatalf("expected 3 variants of each, got %d exams and %d keys", len(exams), len(keys))
+	}
+	for i, label := range []string{"A", "B", "C"} {
+		if !strings.HasPrefix(exams[i], "Chemistry, variant "+label+"\n") || !strings.Contains(exams[i], "· Version 1\n") {
+			t.Fatalf("unexpected heading of variant %s:\n%s", label, exams[i])
+		}
+		if !strings.HasPrefix(keys[i], "Chemistry — Answer key, variant "+label+"\n") {
+			t.Fatalf("unexpected heading of key %s:\n%s", label, keys[i])
+		}
+
+		// Each key gives the letter Na has on its own paper
+		lines := strings.Split(exams[i], "\n")
+		number := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(line, "Symbol of sodium?") })
+		if number < 0 {
+			t.Fatalf("sodium question missing from variant %s:\n%s", label, exams[i])
+		}
+		letter := slices.IndexFunc(lines[number:], func(line string) bool { return strings.HasSuffix(line, ") Na") })
+		question, _, _ := strings.Cut(lines[number], ".")
+		answer := "\n" + question + ". " + strings.TrimSpace(lines[number+letter])[1:2] + " (1 point)\n"
+		if !strings.Contains(keys[i], answer) {
+			t.Fatalf("expected key %s to contain %q:\n%s", label, answer, keys[i])
+		}
+	}
+	if exams[0] == strings.Replace(exams[1], "variant B", "variant A", 1) {
+		t.Fatal("expected the variants to be shuffled differently")
+	}
+
+	// Printing again gives the same papers
+	if again := printTryout(t, router, id, "format=text&variants=3&version=1"); again != strings.Join(exams, "\f") {
+		t.Fatalf("expected the variants to be stable, got:\n%s", again)
+	}
+	page := printTryout(t, router, id, "variants=2")
+	if strings.Count(page, `<section class="paper">`) != 2 || !strings.Contains(page, `<div class="variant">B</div>`) {
+		t.Fatalf("expected 2 papers:\n%s", page)
+	}
+}
+
+func TestPrintErrors(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	id := printableTryout(t, router)
+	path := "/api/v1/tryouts/" + id + "/print"
+
+	for _, query := range []string{"format=pdf", "variants=27", "variants=-1", "variants=two", "answerKey=maybe", "version=latest"} {
+		expectStatus(t, doRequest(t, router, http.MethodGet, path+"?"+query, nil), http.StatusBadRequest)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodGet, path+"?version=1", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/bad/print", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/print", nil), http.StatusNotFound)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, path, nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, path+"?answerKey=true", nil), http.StatusForbidden)
+}
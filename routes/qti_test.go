@@ -0,0 +1,343 @@
+package routes
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"quiz-platform/models"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// zipFiles builds a zip archive of the given files
+func zipFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range names {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(file, files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// unzipFiles reads the files of a zip archive
+func unzipFiles(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("reading package: %v", err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		content, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(data)
+	}
+	return files
+}
+
+func importQTI(t *testing.T, router *gin.Engine, query, body string, status int) models.BundleImport {
+	t.Helper()
+	rec := doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti"+query, "application/zip", body)
+	expectStatus(t, rec, status)
+
+	var imported models.BundleImport
+	decode(t, rec, &imported)
+	return imported
+}
+
+// qtiManifest is a manifest listing items without an assessment test
+func qtiManifest(hrefs ...string) string {
+	manifest := `<?xml version="1.0" encoding="UTF-8"?>
+<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1" identifier="m"><organizations/><resources>`
+	for i, href := range hrefs {
+		manifest += `<resource identifier="r` + string(rune('a'+i)) + `" type="imsqti_item_xmlv2p1" href="` + href + `"><file href="` + href + `"/></resource>`
+	}
+	return manifest + `</resources></manifest>`
+}
+
+// qtiChoiceItem is a single choice item with the given correct response
+func qtiChoiceItem(identifier, correct string) string {
+	return `<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1" identifier="` + identifier + `" title="t" adaptive="false" timeDependent="false">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="identifier">
+    <correctResponse><value>` + correct + `</value></correctResponse>
+  </responseDeclaration>
+  <itemBody>
+    <choiceInteraction responseIdentifier="RESPONSE" shuffle="false" maxChoices="1">
+      <prompt>Pick one of ` + identifier + `</prompt>
+      <simpleChoice identifier="a">Alpha</simpleChoice>
+      <simpleChoice identifier="b">Beta</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+</assessmentItem>`
+}
+
+func TestQTIRoundTrip(t *testing.T) {
+	router := newTestRouter(t)
+	input := sampleTryout("Chemistry")
+	input.Shuffle = models.ShuffleSettings{Questions: true, Options: true}
+	tryout := createTryout(t, router, input)
+	id := tryout.ID.Hex()
+	createQuestion(t, router, id, models.QuestionInput{Text: "Water is wet.", IsTrue: true})
+	createQuestion(t, router, id, models.QuestionInput{Text: "Gold rusts.", Points: floatPtr(2.5), Explanation: "Gold does not oxidise.\nIt stays shiny."})
+	createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "Symbol of sodium?",
+		Options: []models.OptionInput{{Text: "So"}, {Text: "Na", IsCorrect: true}, {Text: "Sd"}},
+	})
+	createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultiSelect,
+		Text:    "Which are metals? <all of them>",
+		Options: []models.OptionInput{{Text: "Iron", IsCorrect: true}, {Text: "Neon"}, {Text: "Zinc & tin", IsCorrect: true}},
+	})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Symbol of iron?", AcceptedAnswers: []string{"Fe", "FE"}, CaseSensitive: true})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionNumeric, Text: "Atomic number of carbon?", NumericAnswer: floatPtr(6)})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionNumeric, Text: "Molar mass of water?", NumericAnswer: floatPtr(18.015), Tolerance: 0.01})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionNumeric, Text: "Avogadro's number?", NumericAnswer: floatPtr(6.022e23), RelativeTolerance: 0.001})
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionEssay, Text: "Describe a titration.\n\nUse an example.", Points: floatPtr(5)})
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/export/qti", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/zip" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "tryout-"+id+"-qti.zip") {
+		t.Fatalf("unexpected Content-Disposition %q", disposition)
+	}
+	if skipped := rec.Header().Get("X-Skipped-Questions"); skipped != "" {
+		t.Fatalf("expected nothing to be skipped, got %q", skipped)
+	}
+	pkg := rec.Body.String()
+
+	files := unzipFiles(t, rec.Body.Bytes())
+	if len(files) != 11 {
+		t.Fatalf("expected a manifest, a test and 9 items, got %d files", len(files))
+	}
+	manifest := files["imsmanifest.xml"]
+	for _, want := range []string{`type="imsqti_test_xmlv2p1" href="assessment.xml"`, `type="imsqti_item_xmlv2p1" href="items/q9.xml"`, "<string>Testing</string>"} {
+		if !strings.Contains(manifest, want) {
+			t.Fatalf("expected the manifest to contain %s:\n%s", want, manifest)
+		}
+	}
+	if test := files["assessment.xml"]; !strings.Contains(test, `<timeLimits maxTime="1800">`) || !strings.Contains(test, `<ordering shuffle="true">`) {
+		t.Fatalf("unexpected assessment test:\n%s", test)
+	}
+	if item := files["items/q1.xml"]; !strings.Contains(item, `<simpleChoice identifier="true">True</simpleChoice>`) ||
+		!strings.Contains(item, "<value>true</value>") || !strings.Contains(item, `<p>Water is wet.</p>`) {
+		t.Fatalf("expected true/false as a choice interaction:\n%s", item)
+	}
+
+	imported := importQTI(t, router, "", pkg, http.StatusCreated)
+	if imported.Tryout.Title != "Chemistry (2)" || imported.RenamedFrom != "Chemistry" || len(imported.Questions) != 9 || len(imported.Unsupported) != 0 {
+		t.Fatalf("unexpected import %+v", imported)
+	}
+
+	// The copy holds the same tryout and questions
+	want := exportTryout(t, router, "/api/v1/tryouts/"+id+"/export")
+	got := exportTryout(t, router, "/api/v1/tryouts/"+imported.Tryout.ID.Hex()+"/export")
+	got.Tryout.Title, got.ExportedAt = want.Tryout.Title, want.ExportedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip changed the tryout:\n got %+v\nwant %+v", got, want)
+	}
+
+	importQTI(t, router, "?onConflict=fail", pkg, http.StatusConflict)
+}
+
+// qtiNumericItem is a numeric text entry item with the given correct response,
+// maximum score and tolerance of its equal comparison
+func qtiNumericItem(identifier, correct, maxScore, tolerance string) string {
+	return `<assessmentItem identifier="` + identifier + `" title="t">
+  <responseDeclaration identifier="R" cardinality="single" baseType="float">
+    <correctResponse><value>` + correct + `</value></correctResponse>
+  </responseDeclaration>
+  <outcomeDeclaration identifier="MAXSCORE" cardinality="single" baseType="float"><defaultValue><value>` + maxScore + `</value></defaultValue></outcomeDeclaration>
+  <outcomeDeclaration identifier="SCORE" cardinality="single" baseType="float" normalMaximum="` + maxScore + `"/>
+  <itemBody><p>Value of ` + identifier + `? <textEntryInteraction responseIdentifier="R"/></p></itemBody>
+  <responseProcessing><responseCondition><responseIf><equal toleranceMode="absolute" tolerance="` + tolerance + `"><variable identifier="R"/><correct identifier="R"/></equal></responseIf></responseCondition></responseProcessing>
+</assessmentItem>`
+}
+
+func TestImportForeignQTI(t *testing.T) {
+	router := newTestRouter(t)
+	pkg := zipFiles(t, map[string]string{
+		"imsmanifest.xml": qtiManifest("one.xml", "match.xml", "text/entry.xml", "two.xml"),
+		"one.xml": `<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p2" identifier="one" title="One">
+  <responseDeclaration identifier="R1" cardinality="multiple" baseType="identifier">
+    <correctResponse><value>c1</value><value>c3</value></correctResponse>
+  </responseDeclaration>
+  <outcomeDeclaration identifier="SCORE" cardinality="single" baseType="float" normalMaximum="3"/>
+  <itemBody>
+    <div><p>Which are <b>even</b>&nbsp;numbers?</p></div>
+    <choiceInteraction responseIdentifier="R1" shuffle="true" maxChoices="3">
+      <simpleChoice identifier="c1">2</simpleChoice>
+      <simpleChoice identifier="c2">3</simpleChoice>
+      <simpleChoice identifier="c3">4</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+  <modalFeedback outcomeIdentifier="FEEDBACK" showHide="show" identifier="fb"><p>Even numbers divide by 2.</p></modalFeedback>
+</assessmentItem>`,
+		"match.xml": `<assessmentItem identifier="match" title="Match">
+  <itemBody><matchInteraction responseIdentifier="R"/></itemBody>
+</assessmentItem>`,
+		"text/entry.xml": `<assessmentItem identifier="entry" title="Entry">
+  <responseDeclaration identifier="R" cardinality="single" baseType="string">
+    <correctResponse><value>Paris</value></correctResponse>
+  </responseDeclaration>
+  <itemBody><p>The capital of France is <textEntryInteraction responseIdentifier="R"/>.</p></itemBody>
+</assessmentItem>`,
+		"two.xml": `<assessmentItem identifier="two" title="Two">
+  <itemBody>
+    <extendedTextInteraction responseIdentifier="A"/>
+    <extendedTextInteraction responseIdentifier="B"/>
+  </itemBody>
+</assessmentItem>`,
+	})
+
+	// The package does not describe the tryout, so the import has to
+	importQTI(t, router, "", pkg, http.StatusBadRequest)
+	imported := importQTI(t, router, "?title=Foreign&description=From+another+LMS&category=Maths&duration=15", pkg, http.StatusCreated)
+	if imported.Tryout.Title != "Foreign" || imported.Tryout.Duration != 15 || !imported.Tryout.Shuffle.Options || imported.Tryout.Shuffle.Questions {
+		t.Fatalf("unexpected tryout %+v", imported.Tryout)
+	}
+	if len(imported.Unsupported) != 2 || imported.Unsupported[0].Row != 2 || imported.Unsupported[0].Identifier != "match" ||
+		imported.Unsupported[1].Row != 4 || imported.Unsupported[1].Identifier != "two" {
+		t.Fatalf("expected items 2 and 4 to be unsupported, got %+v", imported.Unsupported)
+	}
+
+	questions := listQuestions(t, router, imported.Tryout.ID.Hex())
+	if len(questions) != 2 {
+		t.Fatalf("expected 2 questions, got %d", len(questions))
+	}
+	if q := questions[0]; q.Type != models.QuestionMultiSelect || q.Text != "Which are even numbers?" || q.Points != 3 ||
+		!q.Options[0].IsCorrect || q.Options[1].IsCorrect || !q.Options[2].IsCorrect || q.Explanation != "Even numbers divide by 2." {
+		t.Fatalf("unexpected multi-select question %+v", q)
+	}
+	if q := questions[1]; q.Type != models.QuestionShortAnswer || q.Text != "The capital of France is ." || q.AcceptedAnswers[0] != "Paris" || q.Points != 1 {
+		t.Fatalf("unexpected short answer question %+v", q)
+	}
+}
+
+func TestImportQTIErrors(t *testing.T) {
+	router := newTestRouter(t)
+	query := "?description=d&category=c&duration=10&title="
+
+	expectStatus(t, doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti", "application/zip", "not a zip"), http.StatusBadRequest)
+	importQTI(t, router, query+"Empty", zipFiles(t, map[string]string{"readme.txt": "hello"}), http.StatusBadRequest)
+	importQTI(t, router, query+"Broken", zipFiles(t, map[string]string{"imsmanifest.xml": "<manifest"}), http.StatusBadRequest)
+
+	// Errors are numbered by item, unsupported ones included
+	pkg := zipFiles(t, map[string]string{
+		"imsmanifest.xml": qtiManifest("match.xml", "fine.xml", "nokey.xml", "missing.xml", "bad.xml"),
+		"match.xml":       `<assessmentItem identifier="match"><itemBody><matchInteraction responseIdentifier="R"/></itemBody></assessmentItem>`,
+		"fine.xml":        qtiChoiceItem("fine", "a"),
+		"nokey.xml":       qtiChoiceItem("nokey", "z"),
+		"bad.xml":         `<assessmentItem identifier="bad"><itemBody>`,
+	})
+	rec := doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti"+query+"Errors", "application/zip", pkg)
+	expectStatus(t, rec, http.StatusBadRequest)
+	var rejected importErrors
+	decode(t, rec, &rejected)
+	if len(rejected.Errors) != 3 || rejected.Errors[0].Row != 3 || rejected.Errors[1].Row != 4 || rejected.Errors[2].Row != 5 {
+		t.Fatalf("expected errors for items 3 to 5, got %+v", rejected)
+	}
+
+	unsupported := zipFiles(t, map[string]string{
+		"imsmanifest.xml": qtiManifest("match.xml"),
+		"match.xml":       `<assessmentItem identifier="match"><itemBody><matchInteraction responseIdentifier="R"/></itemBody></assessmentItem>`,
+	})
+	rec = doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti"+query+"Unsupported", "application/zip", unsupported)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), "matchInteraction items are not supported") {
+		t.Fatalf("expected the unsupported item to be reported, got %s", rec.Body.String())
+	}
+
+	expectStatus(t, doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti?duration=soon", "application/zip", pkg), http.StatusBadRequest)
+	expectStatus(t, doUpload(t, router, http.MethodPost, "/api/v1/tryouts/import/qti?onConflict=skip", "application/zip", pkg), http.StatusBadRequest)
+	if list := listTryouts(t, router, "/api/v1/tryouts"); len(list.Items) != 0 {
+		t.Fatalf("expected nothing to be created, got %v", titles(list.Items))
+	}
+}
+
+func TestImportQTINonFiniteNumbers(t *testing.T) {
+	router := newTestRouter(t)
+	pkg := zipFiles(t, map[string]string{
+		"imsmanifest.xml": qtiManifest("fine.xml", "nan.xml", "inf.xml", "tolerance.xml"),
+		"fine.xml":        qtiNumericItem("fine", "3.14", "INF", "0.01"),
+		"nan.xml":         qtiNumericItem("nan", "NaN", "1", "0"),
+		"inf.xml":         qtiNumericItem("inf", "-Inf", "1", "0"),
+		"tolerance.xml":   qtiNumericItem("tolerance", "1", "1", "0 Inf NaN"),
+	})
+
+	// Numbers that are not finite leave their items out, and scores that are
+	// not finite leave the default points
+	imported := importQTI(t, router, "?description=d&category=c&duration=10&title=Finite", pkg, http.StatusCreated)
+	if len(imported.Unsupported) != 3 || imported.Unsupported[0].Row != 2 || imported.Unsupported[1].Row != 3 || imported.Unsupported[2].Row != 4 {
+		t.Fatalf("expected items 2 to 4 to be left out, got %+v", imported.Unsupported)
+	}
+	questions := listQuestions(t, router, imported.Tryout.ID.Hex())
+	if len(questions) != 1 || questions[0].Points != 1 || *questions[0].NumericAnswer != 3.14 || questions[0].Tolerance != 0.01 {
+		t.Fatalf("expected the finite item with the default points, got %+v", questions)
+	}
+}
+
+func TestQTIExportAndAccess(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Patterns"))
+	id := tryout.ID.Hex()
+	createQuestion(t, router, id, models.QuestionInput{Text: "Published", IsTrue: true})
+	publishTryout(t, router, id)
+	createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Any colour?", AcceptedPatterns: []string{"^(red|blue)$"}})
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/export/qti", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if skipped := rec.Header().Get("X-Skipped-Questions"); skipped != "2" {
+		t.Fatalf("expected the pattern question to be skipped, got %q", skipped)
+	}
+	if files := unzipFiles(t, rec.Body.Bytes()); len(files) != 3 {
+		t.Fatalf("expected a manifest, a test and 1 item, got %d files", len(files))
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/export/qti?version=1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if skipped := rec.Header().Get("X-Skipped-Questions"); skipped != "" {
+		t.Fatalf("expected version 1 to export whole, got skipped %q", skipped)
+	}
+	pkg := rec.Body.String()
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/export/qti?version=7", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/bad/export/qti", nil), http.StatusBadRequest)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, "/api/v1/tryouts/"+id+"/export/qti", nil), http.StatusForbidden)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/export/qti", nil), http.StatusNotFound)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, "/api/v1/tryouts/import/qti", nil), http.StatusForbidden)
+	if imported := importQTI(t, router, "", pkg, http.StatusCreated); len(listQuestions(t, router, imported.Tryout.ID.Hex())) != 1 {
+		t.Fatal("expected version 1 to import with its one question")
+	}
+}
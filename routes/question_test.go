@@ -0,0 +1,512 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lockTryout publishes a tryout and records a submission against it, so it
+// can only be deleted with force
+func lockTryout(t *testing.T, router *gin.Engine, tryoutID string) {
+	t.Helper()
+	publishTryout(t, router, tryoutID)
+	attempt := startAttempt(t, router, tryoutID)
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryoutID+"/submissions", models.SubmissionInput{
+		AttemptID: attempt.ID.Hex(),
+		Answers:   []models.AnswerInput{},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+}
+
+func TestQuestionCRUD(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Physics"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions"
+
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Light travels faster than sound.", IsTrue: true})
+	if question.Type != models.QuestionTrueFalse || question.Points != 1 || !question.IsTrue || question.TryoutID != tryout.ID {
+		t.Fatalf("unexpected question: %+v", question)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, base, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var questions []models.Question
+	decode(t, rec, &questions)
+	if len(questions) != 1 || questions[0].ID != question.ID {
+		t.Fatalf("unexpected question list: %+v", questions)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, base+"/"+question.ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, router, http.MethodPut, base+"/"+question.ID.Hex(), models.QuestionInput{Text: "Sound travels faster than light.", Points: floatPtr(2)})
+	expectStatus(t, rec, http.StatusOK)
+	var updated models.Question
+	decode(t, rec, &updated)
+	if updated.Text != "Sound travels faster than light." || updated.IsTrue || updated.Points != 2 {
+		t.Fatalf("question not updated: %+v", updated)
+	}
+	if updated.TryoutID != tryout.ID {
+		t.Fatal("expected the question to stay in its tryout")
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/"+question.ID.Hex(), nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/"+question.ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/"+question.ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/"+question.ID.Hex(), models.QuestionInput{Text: "Gone"}), http.StatusNotFound)
+}
+
+func TestQuestionRoutesRejectInvalidIDs(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Chemistry"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Water boils at 100C at sea level.", IsTrue: true})
+	valid := models.QuestionInput{Text: "Valid"}
+
+	cases := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodGet, "/api/v1/tryouts/bad/questions", nil},
+		{http.MethodPost, "/api/v1/tryouts/bad/questions", valid},
+		{http.MethodGet, "/api/v1/tryouts/bad/questions/" + question.ID.Hex(), nil},
+		{http.MethodGet, "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/bad", nil},
+		{http.MethodPut, "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/bad", valid},
+		{http.MethodDelete, "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/bad", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			expectStatus(t, doRequest(t, router, tc.method, tc.path, tc.body), http.StatusBadRequest)
+		})
+	}
+}
+
+func TestQuestionNotFound(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Biology"))
+	other := createTryout(t, router, sampleTryout("Botany"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Cells have membranes.", IsTrue: true})
+
+	// Adding to an unknown tryout
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+missingID+"/questions", models.QuestionInput{Text: "Orphan"})
+	expectStatus(t, rec, http.StatusNotFound)
+
+	// A question is only visible through its own tryout
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+other.ID.Hex()+"/questions/"+question.ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryout.ID.Hex()+"/questions/"+missingID, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	// An unknown tryout simply has no questions
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var questions []models.Question
+	decode(t, rec, &questions)
+	if len(questions) != 0 {
+		t.Fatalf("expected no questions, got %d", len(questions))
+	}
+}
+
+func TestQuestionValidation(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Validation"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions"
+
+	choices := func(correct ...bool) []models.OptionInput {
+		options := make([]models.OptionInput, len(correct))
+		for i, isCorrect := range correct {
+			options[i] = models.OptionInput{Text: string(rune('A' + i)), IsCorrect: isCorrect}
+		}
+		return options
+	}
+
+	cases := map[string]interface{}{
+		"malformed JSON":                  `{"text": 1}`,
+		"missing text":                    models.QuestionInput{IsTrue: true},
+		"negative points":                 models.QuestionInput{Text: "q", Points: floatPtr(-1)},
+		"unknown type":                    models.QuestionInput{Type: "matching", Text: "q"},
+		"true/false with options":         models.QuestionInput{Text: "q", Options: choices(true, false)},
+		"choice with one option":          models.QuestionInput{Type: models.QuestionMultipleChoice, Text: "q", Options: choices(true)},
+		"choice without a correct option": models.QuestionInput{Type: models.QuestionMultipleChoice, Text: "q", Options: choices(false, false)},
+		"choice with two correct options": models.QuestionInput{Type: models.QuestionMultipleChoice, Text: "q", Options: choices(true, true)},
+		"multi-select without correct":    models.QuestionInput{Type: models.QuestionMultiSelect, Text: "q", Options: choices(false, false, false)},
+		"duplicate option text":           models.QuestionInput{Type: models.QuestionMultiSelect, Text: "q", Options: []models.OptionInput{{Text: "A", IsCorrect: true}, {Text: "A"}}},
+		"blank option text":               models.QuestionInput{Type: models.QuestionMultiSelect, Text: "q", Options: []models.OptionInput{{Text: "A", IsCorrect: true}, {Text: " "}}},
+		"short answer without answers":    models.QuestionInput{Type: models.QuestionShortAnswer, Text: "q"},
+		"short answer with invalid regex": models.QuestionInput{Type: models.QuestionShortAnswer, Text: "q", AcceptedPatterns: []string{"(unclosed"}},
+		"numeric without answer":          models.QuestionInput{Type: models.QuestionNumeric, Text: "q"},
+		"numeric with negative tolerance": models.QuestionInput{Type: models.QuestionNumeric, Text: "q", NumericAnswer: floatPtr(1), Tolerance: -0.1},
+		"essay with options":              models.QuestionInput{Type: models.QuestionEssay, Text: "q", Options: choices(true, false)},
+		"position before the first":       models.QuestionInput{Text: "q", Position: intPtr(0)},
+	}
+
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, doRequest(t, router, http.MethodPost, path, body), http.StatusBadRequest)
+		})
+	}
+}
+
+func TestQuestionTypes(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Types"))
+
+	choice := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "Which planet is largest?",
+		IsTrue:  true,
+		Options: []models.OptionInput{{Text: "Jupiter", IsCorrect: true}, {Text: "Mars"}},
+	})
+	if len(choice.Options) != 2 || choice.Options[0].ID.IsZero() || choice.IsTrue {
+		t.Fatalf("unexpected choice question: %+v", choice)
+	}
+
+	short := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:            models.QuestionShortAnswer,
+		Text:            "Name the author of Hamlet",
+		AcceptedAnswers: []string{"  Shakespeare ", ""},
+		NumericAnswer:   floatPtr(3),
+	})
+	if len(short.AcceptedAnswers) != 1 || short.AcceptedAnswers[0] != "Shakespeare" || short.NumericAnswer != nil {
+		t.Fatalf("unexpected short answer question: %+v", short)
+	}
+
+	essay := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Type: models.QuestionEssay, Text: "Discuss.", Points: floatPtr(10)})
+	if essay.Points != 10 {
+		t.Fatalf("unexpected essay question: %+v", essay)
+	}
+}
+
+func TestUpdateKeepsOptionIDs(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Options"))
+	input := models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "Which planet is largest?",
+		Options: []models.OptionInput{{Text: "Jupiter", IsCorrect: true}, {Text: "Mars"}},
+	}
+	question := createQuestion(t, router, tryout.ID.Hex(), input)
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions/" + question.ID.Hex()
+
+	// Options keep their IDs when only the question around them changes
+	input.Text = "Which planet is the largest?"
+	rec := doRequest(t, router, http.MethodPut, path, input)
+	expectStatus(t, rec, http.StatusOK)
+	var updated models.Question
+	decode(t, rec, &updated)
+	if updated.Options[0].ID != question.Options[0].ID || updated.Options[1].ID != question.Options[1].ID {
+		t.Fatalf("expected unchanged options to keep their IDs, got %+v then %+v", question.Options, updated.Options)
+	}
+
+	// Only new options get new IDs
+	input.Options = []models.OptionInput{{Text: "Saturn"}, {Text: "Jupiter", IsCorrect: true}}
+	rec = doRequest(t, router, http.MethodPut, path, input)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &updated)
+	if updated.Options[1].ID != question.Options[0].ID || updated.Options[0].ID == question.Options[1].ID || updated.Options[0].ID.IsZero() {
+		t.Fatalf("expected only the new option to get a new ID, got %+v then %+v", question.Options, updated.Options)
+	}
+}
+
+func TestLegacyQuestionsReadAsTrueFalse(t *testing.T) {
+	// Questions stored before types and points existed lack both fields
+	data, err := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "text": "Old question", "isTrue": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var question models.Question
+	if err := bson.Unmarshal(data, &question); err != nil {
+		t.Fatal(err)
+	}
+	if question.Type != models.QuestionTrueFalse || question.Points != 1 {
+		t.Fatalf("expected legacy question to read as a one-point true/false question, got %+v", question)
+	}
+	var taker models.TakerQuestion
+	if err := bson.Unmarshal(data, &taker); err != nil {
+		t.Fatal(err)
+	}
+	if taker.Type != models.QuestionTrueFalse || taker.Points != 1 {
+		t.Fatalf("expected legacy taker question to read as a one-point true/false question, got %+v", taker)
+	}
+}
+
+func TestZeroPointQuestions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Practice"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions"
+
+	practice := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Warm-up: water is wet.", IsTrue: true, Points: floatPtr(0)})
+	if practice.Points != 0 {
+		t.Fatalf("expected a zero-point question, got %+v", practice)
+	}
+
+	// Points are kept at 0 when read back, by authors and takers alike
+	for _, view := range []string{"author", "taker"} {
+		rec := doRequest(t, router, http.MethodGet, base+"/"+practice.ID.Hex()+"?view="+view, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var question struct{ Points float64 }
+		decode(t, rec, &question)
+		if question.Points != 0 {
+			t.Fatalf("expected the %s view to keep 0 points, got %v", view, question.Points)
+		}
+	}
+
+	// Leaving points out still defaults to 1
+	rec := doRequest(t, router, http.MethodPut, base+"/"+practice.ID.Hex(), models.QuestionInput{Text: "Water is wet.", IsTrue: true})
+	expectStatus(t, rec, http.StatusOK)
+	var updated models.Question
+	decode(t, rec, &updated)
+	if updated.Points != 1 {
+		t.Fatalf("expected missing points to default to 1, got %+v", updated)
+	}
+}
+
+func questionTexts(t *testing.T, router *gin.Engine, token, path string) []string {
+	t.Helper()
+	rec := doRequestAs(t, router, token, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var questions []models.TakerQuestion
+	decode(t, rec, &questions)
+	texts := []string{}
+	for i, question := range questions {
+		if question.Position != i+1 {
+			t.Fatalf("expected question %d to have position %d, got %+v", i, i+1, questions)
+		}
+		texts = append(texts, question.Text)
+	}
+	return texts
+}
+
+func TestQuestionOrdering(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Ordered"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions"
+
+	first := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "first", IsTrue: true})
+	last := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "last", IsTrue: true})
+	if first.Position != 1 || last.Position != 2 {
+		t.Fatalf("expected new questions to go last, got positions %d and %d", first.Position, last.Position)
+	}
+	inserted := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "inserted", IsTrue: true, Position: intPtr(2)})
+	appended := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "appended", IsTrue: true, Position: intPtr(99)})
+	if inserted.Position != 2 || appended.Position != 4 {
+		t.Fatalf("unexpected positions %d and %d", inserted.Position, appended.Position)
+	}
+	if texts := questionTexts(t, router, testToken, base); strings.Join(texts, ",") != "first,inserted,last,appended" {
+		t.Fatalf("unexpected order %v", texts)
+	}
+
+	// Updates keep the question in place
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/"+first.ID.Hex(), models.QuestionInput{Text: "first edited", Position: intPtr(4)}), http.StatusOK)
+
+	order := models.QuestionOrder{QuestionIDs: []primitive.ObjectID{appended.ID, last.ID, inserted.ID, first.ID}}
+	rec := doRequest(t, router, http.MethodPut, base+"/order", order)
+	expectStatus(t, rec, http.StatusOK)
+	var reordered []models.Question
+	decode(t, rec, &reordered)
+	if len(reordered) != 4 || reordered[0].ID != appended.ID || reordered[3].ID != first.ID {
+		t.Fatalf("unexpected reordered questions %+v", reordered)
+	}
+
+	publishTryout(t, router, tryout.ID.Hex())
+	if texts := questionTexts(t, router, student, base); strings.Join(texts, ",") != "appended,last,inserted,first edited" {
+		t.Fatalf("expected takers to get the new order, got %v", texts)
+	}
+
+	// The order must list every question once and nothing else
+	other := createQuestion(t, router, createTryout(t, router, sampleTryout("Other")).ID.Hex(), models.QuestionInput{Text: "other", IsTrue: true})
+	invalid := map[string]interface{}{
+		"missing question":   models.QuestionOrder{QuestionIDs: []primitive.ObjectID{appended.ID, last.ID, inserted.ID}},
+		"duplicate question": models.QuestionOrder{QuestionIDs: []primitive.ObjectID{appended.ID, last.ID, inserted.ID, inserted.ID}},
+		"foreign question":   models.QuestionOrder{QuestionIDs: []primitive.ObjectID{appended.ID, last.ID, inserted.ID, other.ID}},
+		"missing list":       `{}`,
+		"invalid ID":         `{"questionIds": ["nope"]}`,
+	}
+	for name, body := range invalid {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, doRequest(t, router, http.MethodPut, base+"/order", body), http.StatusBadRequest)
+		})
+	}
+	if texts := questionTexts(t, router, testToken, base); strings.Join(texts, ",") != "appended,last,inserted,first edited" {
+		t.Fatalf("expected rejected orders to change nothing, got %v", texts)
+	}
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPut, base+"/order", order), http.StatusForbidden)
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+missingID+"/questions/order", order), http.StatusNotFound)
+}
+
+func TestConcurrentQuestionsGetDistinctPositions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Crowded"))
+
+	const questions = 8
+	recs := make([]*httptest.ResponseRecorder, questions)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryout.ID.Hex()+"/questions", models.QuestionInput{Text: fmt.Sprintf("Question %d", i), IsTrue: true})
+		}()
+	}
+	wg.Wait()
+	for _, rec := range recs {
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	positions := map[int]bool{}
+	for _, question := range listQuestions(t, router, tryout.ID.Hex()) {
+		positions[question.Position] = true
+	}
+	for position := 1; position <= questions; position++ {
+		if !positions[position] {
+			t.Fatalf("expected positions 1 to %d, got %v", questions, positions)
+		}
+	}
+}
+
+// insertUnversionedTryout stores a tryout published before versions existed,
+// owned by the test author, with one question
+func insertUnversionedTryout(t *testing.T, router *gin.Engine) (models.Tryout, models.Question) {
+	t.Helper()
+	author := currentUser(t, router, testToken)
+	tryout := models.Tryout{Title: "Unversioned", Duration: 30, Status: models.TryoutPublished, OwnerID: author.ID, CreatedAt: time.Now()}
+	if err := repository.Tryouts.Create(context.Background(), &tryout, author); err != nil {
+		t.Fatal(err)
+	}
+	question := models.Question{TryoutID: tryout.ID, Type: models.QuestionTrueFalse, Text: "Served as is", IsTrue: true, Points: 1}
+	if err := repository.Questions.Create(context.Background(), &question, author); err != nil {
+		t.Fatal(err)
+	}
+	return tryout, question
+}
+
+func TestHasSubmissionLocksQuestions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout, question := insertUnversionedTryout(t, router)
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	attempt := startAttempt(t, router, tryout.ID.Hex())
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/submissions", models.SubmissionInput{
+		AttemptID: attempt.ID.Hex(),
+		Answers:   []models.AnswerInput{},
+	}), http.StatusCreated)
+
+	rec := doRequest(t, router, http.MethodGet, base, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var locked models.Tryout
+	decode(t, rec, &locked)
+	if !locked.HasSubmission || locked.Version != 0 {
+		t.Fatalf("expected an unversioned tryout with submissions, got %+v", locked)
+	}
+
+	// Takers get the working copy of an unversioned tryout, so it cannot change
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/questions", models.QuestionInput{Text: "New"}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/"+question.ID.Hex(), models.QuestionInput{Text: "Edited"}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/order", models.QuestionOrder{QuestionIDs: []primitive.ObjectID{question.ID}}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/questions/import", []models.QuestionInput{{Text: "Imported"}}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/questions/"+question.ID.Hex(), nil), http.StatusBadRequest)
+
+	// Reading is still allowed
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/questions/"+question.ID.Hex(), nil), http.StatusOK)
+
+	// Publishing a version freezes the questions takers get and lifts the lock
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/versions", nil), http.StatusCreated)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/"+question.ID.Hex(), models.QuestionInput{Text: "Edited"}), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/questions/"+question.ID.Hex(), nil), http.StatusOK)
+}
+
+func TestUnversionedTryoutsLockQuestionsWithoutSubmissions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout, question := insertUnversionedTryout(t, router)
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	// Attempts in progress answer the working copy too
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/questions", models.QuestionInput{Text: "New"}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/"+question.ID.Hex(), models.QuestionInput{Text: "Edited"}), http.StatusBadRequest)
+
+	// A question deleted before the lock cannot come back either
+	if err := repository.Questions.Delete(context.Background(), question.ID, time.Now(), currentUser(t, router, testToken)); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/"+question.ID.Hex()+"/restore", nil), http.StatusBadRequest)
+}
+
+func TestTakersDoNotSeeAnswerKeys(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	grader := registerAs(t, router, "grader@example.com", models.RoleGrader)
+	tryout := createTryout(t, router, sampleTryout("Answers"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/questions"
+
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "The sky is blue.", IsTrue: true, Explanation: "Rayleigh scattering"})
+	choice := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "Which planet is largest?",
+		Options: []models.OptionInput{{Text: "Jupiter", IsCorrect: true}, {Text: "Mars"}},
+	})
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Type: models.QuestionShortAnswer, Text: "Who wrote Hamlet?", AcceptedAnswers: []string{"Shakespeare"}})
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Type: models.QuestionNumeric, Text: "What is 6 x 7?", NumericAnswer: floatPtr(42)})
+	publishTryout(t, router, tryout.ID.Hex())
+
+	answerKeys := []string{"isTrue", "isCorrect", "acceptedAnswers", "numericAnswer", "explanation", "Rayleigh", "Shakespeare"}
+	expectHidden := func(body string) {
+		t.Helper()
+		for _, key := range answerKeys {
+			if strings.Contains(body, key) {
+				t.Fatalf("expected %q to be hidden from takers, got %s", key, body)
+			}
+		}
+	}
+
+	for _, token := range []string{student, ""} {
+		rec := doRequestAs(t, router, token, http.MethodGet, base, nil)
+		expectStatus(t, rec, http.StatusOK)
+		expectHidden(rec.Body.String())
+		var questions []models.TakerQuestion
+		decode(t, rec, &questions)
+		if len(questions) != 4 || len(questions[1].Options) != 2 || questions[1].Options[0].Text != "Jupiter" {
+			t.Fatalf("unexpected taker view: %+v", questions)
+		}
+
+		rec = doRequestAs(t, router, token, http.MethodGet, base+"/"+choice.ID.Hex(), nil)
+		expectStatus(t, rec, http.StatusOK)
+		expectHidden(rec.Body.String())
+
+		expectStatus(t, doRequestAs(t, router, token, http.MethodGet, base+"?view=author", nil), http.StatusForbidden)
+		expectStatus(t, doRequestAs(t, router, token, http.MethodGet, base+"/"+choice.ID.Hex()+"?view=author", nil), http.StatusForbidden)
+	}
+
+	// Authors and graders see the answer keys, and authors can preview the taker view
+	for _, token := range []string{testToken, grader} {
+		rec := doRequestAs(t, router, token, http.MethodGet, base, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var questions []models.Question
+		decode(t, rec, &questions)
+		if !questions[0].IsTrue || questions[0].Explanation != "Rayleigh scattering" || !questions[1].Options[0].IsCorrect {
+			t.Fatalf("expected the author view, got %+v", questions)
+		}
+	}
+	rec := doRequest(t, router, http.MethodGet, base+"?view=taker", nil)
+	expectStatus(t, rec, http.StatusOK)
+	expectHidden(rec.Body.String())
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"?view=everything", nil), http.StatusBadRequest)
+}
@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"quiz-platform/auth"
 	"quiz-platform/controllers"
+	"quiz-platform/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -16,7 +18,7 @@ func SetupRouter() *gin.Engine {
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:5173", "*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Skipped-Questions"},
 		AllowCredentials: true,
 		AllowWildcard:    true,
 	}))
@@ -28,36 +30,112 @@ func SetupRouter() *gin.Engine {
 			"endpoints": []string{
 				"/api/v1/tryouts",
 				"/api/v1/tryouts/:id",
+				"/api/v1/tryouts/:id/status",
+				"/api/v1/tryouts/:id/versions",
+				"/api/v1/tryouts/:id/history",
+				"/api/v1/tryouts/:id/export",
+				"/api/v1/tryouts/import",
+				"/api/v1/tryouts/:id/export/qti",
+				"/api/v1/tryouts/import/qti",
+				"/api/v1/tryouts/:id/print",
 				"/api/v1/tryouts/filter",
+				"/api/v1/tryouts/search",
 				"/api/v1/tryouts/:id/questions",
+				"/api/v1/tryouts/:id/questions/order",
+				"/api/v1/tryouts/:id/questions/import",
+				"/api/v1/tryouts/:id/questions/export",
+				"/api/v1/tryouts/:id/attempts",
+				"/api/v1/tryouts/:id/submissions",
+				"/api/v1/tryouts/:id/grading-queue",
+				"/api/v1/trash",
+				"/api/v1/auth/register",
+				"/api/v1/auth/login",
+				"/api/v1/auth/refresh",
+				"/api/v1/auth/me",
+				"/api/v1/users/:id/role",
 			},
 		})
 	})
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	// API v1 routes, signed in when a bearer access token is sent
+	v1 := router.Group("/api/v1", auth.Authenticate())
 	{
-		// Tryout routes
+		// Account routes
+		accounts := v1.Group("/auth")
+		{
+			accounts.POST("/register", controllers.Register)
+			accounts.POST("/login", controllers.Login)
+			accounts.POST("/refresh", controllers.RefreshTokens)
+			accounts.GET("/me", auth.RequireUser(), controllers.GetCurrentUser)
+		}
+
+		// User management routes
+		users := v1.Group("/users", auth.RequireRole(models.RoleAdmin))
+		{
+			users.PUT("/:id/role", controllers.UpdateUserRole)
+		}
+
+		// Tryout routes; changing content requires a signed-in user, and the
+		// controllers check ownership of existing tryouts
 		tryouts := v1.Group("/tryouts")
 		{
 			tryouts.GET("", controllers.GetAllTryouts)
-			tryouts.POST("", controllers.CreateTryout)
+			tryouts.POST("", auth.RequireRole(models.RoleAdmin, models.RoleAuthor), controllers.CreateTryout)
+			tryouts.POST("/import", auth.RequireRole(models.RoleAdmin, models.RoleAuthor), controllers.ImportTryout)
+			tryouts.POST("/import/qti", auth.RequireRole(models.RoleAdmin, models.RoleAuthor), controllers.ImportTryoutQTI)
 
-			// Filter routes - must come before :id route to avoid conflict
+			// Filter and search routes - must come before :id route to avoid conflict
 			tryouts.GET("/filter", controllers.FilterTryouts)
 			tryouts.GET("/filter/options", controllers.GetTryoutOptions)
+			tryouts.GET("/search", controllers.SearchTryouts)
 
 			// Individual tryout routes with ID parameter
 			tryouts.GET("/:id", controllers.GetTryout)
-			tryouts.PUT("/:id", controllers.UpdateTryout)
-			tryouts.DELETE("/:id", controllers.DeleteTryout)
+			tryouts.PUT("/:id", auth.RequireUser(), controllers.UpdateTryout)
+			tryouts.DELETE("/:id", auth.RequireUser(), controllers.DeleteTryout)
+			tryouts.PUT("/:id/status", auth.RequireUser(), controllers.UpdateTryoutStatus)
+
+			// Version routes
+			tryouts.GET("/:id/versions", auth.RequireUser(), controllers.GetVersions)
+			tryouts.POST("/:id/versions", auth.RequireUser(), controllers.PublishVersion)
+			tryouts.GET("/:id/versions/diff", auth.RequireUser(), controllers.DiffVersions)
+			tryouts.GET("/:id/versions/:version", auth.RequireUser(), controllers.GetVersion)
+			tryouts.GET("/:id/history", auth.RequireUser(), controllers.GetTryoutHistory)
+			tryouts.GET("/:id/export", auth.RequireUser(), controllers.ExportTryout)
+			tryouts.GET("/:id/export/qti", auth.RequireUser(), controllers.ExportTryoutQTI)
+			tryouts.GET("/:id/print", auth.RequireUser(), controllers.PrintTryout)
 
 			// Question routes
 			tryouts.GET("/:id/questions", controllers.GetQuestionsByTryoutID)
-			tryouts.POST("/:id/questions", controllers.CreateQuestion)
-			tryouts.PUT("/:id/questions/:questionId", controllers.UpdateQuestion)
-			tryouts.DELETE("/:id/questions/:questionId", controllers.DeleteQuestion)
+			tryouts.POST("/:id/questions", auth.RequireUser(), controllers.CreateQuestion)
+			tryouts.POST("/:id/questions/import", auth.RequireUser(), controllers.ImportQuestions)
+			tryouts.GET("/:id/questions/export", auth.RequireUser(), controllers.ExportQuestions)
+			tryouts.PUT("/:id/questions/order", auth.RequireUser(), controllers.ReorderQuestions)
+			tryouts.PUT("/:id/questions/:questionId", auth.RequireUser(), controllers.UpdateQuestion)
+			tryouts.DELETE("/:id/questions/:questionId", auth.RequireUser(), controllers.DeleteQuestion)
 			tryouts.GET("/:id/questions/:questionId", controllers.GetQuestionByID)
+
+			// Attempt routes
+			tryouts.POST("/:id/attempts", auth.RequireUser(), controllers.StartAttempt)
+			tryouts.GET("/:id/attempts/:attemptId", auth.RequireUser(), controllers.GetAttempt)
+			tryouts.GET("/:id/attempts/:attemptId/questions", auth.RequireUser(), controllers.GetAttemptQuestions)
+
+			// Submission routes
+			tryouts.GET("/:id/submissions", auth.RequireUser(), controllers.GetSubmissionsByTryoutID)
+			tryouts.POST("/:id/submissions", auth.RequireUser(), controllers.CreateSubmission)
+			tryouts.GET("/:id/submissions/:submissionId", auth.RequireUser(), controllers.GetSubmissionByID)
+
+			// Manual grading routes
+			tryouts.GET("/:id/grading-queue", auth.RequireUser(), controllers.GetGradingQueue)
+			tryouts.PUT("/:id/submissions/:submissionId/answers/:questionId/grade", auth.RequireUser(), controllers.GradeAnswer)
+		}
+
+		// Trash routes
+		trash := v1.Group("/trash", auth.RequireRole(models.RoleAdmin, models.RoleAuthor))
+		{
+			trash.GET("", controllers.GetTrash)
+			trash.POST("/tryouts/:id/restore", controllers.RestoreTryout)
+			trash.POST("/questions/:id/restore", controllers.RestoreQuestion)
 		}
 	}
 
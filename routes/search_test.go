@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"quiz-platform/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchResponse struct {
+	Terms   []string              `json:"terms"`
+	Results []models.SearchResult `json:"results"`
+}
+
+func search(t *testing.T, router *gin.Engine, query url.Values) searchResponse {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/search?"+query.Encode(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var body searchResponse
+	decode(t, rec, &body)
+	return body
+}
+
+func TestSearchTryouts(t *testing.T) {
+	router := newTestRouter(t)
+
+	algebra := sampleTryout("Linear Equations")
+	algebra.Description = "Solving equations with one unknown"
+	algebraTryout := createTryout(t, router, algebra)
+
+	physics := createTryout(t, router, sampleTryout("Mechanics"))
+	question := createQuestion(t, router, physics.ID.Hex(), models.QuestionInput{
+		Text:   "Newton's second law is an equation relating force and acceleration",
+		IsTrue: true,
+	})
+
+	createTryout(t, router, sampleTryout("World History"))
+
+	body := search(t, router, url.Values{"q": {"Equation"}})
+	if len(body.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", body.Results)
+	}
+
+	// Title matches outrank question text matches
+	first, second := body.Results[0], body.Results[1]
+	if first.Tryout.ID != algebraTryout.ID || second.Tryout.ID != physics.ID {
+		t.Fatalf("unexpected ranking: %s, %s", first.Tryout.Title, second.Tryout.Title)
+	}
+	if first.Score <= second.Score {
+		t.Fatalf("expected descending scores, got %v and %v", first.Score, second.Score)
+	}
+
+	if len(first.Highlights) != 2 ||
+		first.Highlights[0].Field != models.HighlightTitle || first.Highlights[0].Snippet != "Linear <mark>Equations</mark>" ||
+		first.Highlights[1].Field != models.HighlightDescription {
+		t.Fatalf("unexpected highlights %+v", first.Highlights)
+	}
+	highlight := second.Highlights[0]
+	if len(second.Highlights) != 1 || highlight.Field != models.HighlightQuestion || highlight.QuestionID == nil || *highlight.QuestionID != question.ID {
+		t.Fatalf("unexpected question highlight %+v", second.Highlights)
+	}
+	if !strings.Contains(highlight.Snippet, "Newton&#39;s") || !strings.Contains(highlight.Snippet, "<mark>equation</mark>") {
+		t.Fatalf("expected an escaped snippet with the match marked, got %q", highlight.Snippet)
+	}
+
+	if body := search(t, router, url.Values{"q": {"equations", "mechanics"}, "limit": {"1"}}); len(body.Results) != 1 {
+		t.Fatalf("expected limit to cap the results, got %d", len(body.Results))
+	}
+	if body := search(t, router, url.Values{"q": {"chemistry"}}); len(body.Results) != 0 {
+		t.Fatalf("expected no results, got %+v", body.Results)
+	}
+}
+
+func TestSearchIgnoresQuerySyntax(t *testing.T) {
+	router := newTestRouter(t)
+	createTryout(t, router, sampleTryout("Regular Expressions"))
+
+	// Regex and $text operators are reduced to plain words
+	body := search(t, router, url.Values{"q": {`(a+)+$ -"regular" .*`}})
+	if strings.Join(body.Terms, ",") != "regular" {
+		t.Fatalf("unexpected terms %v", body.Terms)
+	}
+	if len(body.Results) != 1 {
+		t.Fatalf("expected the negated word to still match, got %+v", body.Results)
+	}
+	if snippet := body.Results[0].Highlights[0].Snippet; snippet != "<mark>Regular</mark> Expressions" {
+		t.Fatalf("unexpected snippet %q", snippet)
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, query := range []url.Values{
+		{},
+		{"q": {"   "}},
+		{"q": {"the of and"}},
+		{"q": {strings.Repeat("x", models.MaxSearchQueryLength+1)}},
+		{"q": {"algebra"}, "limit": {"0"}},
+		{"q": {"algebra"}, "limit": {"many"}},
+	} {
+		rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/search?"+query.Encode(), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("search?%s: expected status 400, got %d", query.Encode(), rec.Code)
+		}
+	}
+}
+
+func TestFilterTitleIsLiteral(t *testing.T) {
+	router := newTestRouter(t)
+	createTryout(t, router, sampleTryout("C++ Basics"))
+	createTryout(t, router, sampleTryout("Cooking"))
+
+	if got := listTryouts(t, router, "/api/v1/tryouts/filter?title="+url.QueryEscape("c++")).Items; len(got) != 1 || got[0].Title != "C++ Basics" {
+		t.Fatalf("expected a literal match, got %+v", got)
+	}
+	if got := listTryouts(t, router, "/api/v1/tryouts/filter?title="+url.QueryEscape("(a+)+$")).Items; len(got) != 0 {
+		t.Fatalf("expected regex syntax to match nothing, got %+v", got)
+	}
+}
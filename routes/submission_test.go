@@ -0,0 +1,472 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// answersFor builds the submission input for an attempt
+func answersFor(attempt models.Attempt, answers ...models.AnswerInput) models.SubmissionInput {
+	if answers == nil {
+		answers = []models.AnswerInput{}
+	}
+	return models.SubmissionInput{AttemptID: attempt.ID.Hex(), Answers: answers}
+}
+
+// insertAttempt stores an attempt of the test account directly, bypassing the
+// server clock
+func insertAttempt(t *testing.T, tryoutID primitive.ObjectID, startedAt time.Time, duration time.Duration) models.Attempt {
+	t.Helper()
+	user, err := repository.Users.GetByEmail(context.Background(), "author@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempt := models.Attempt{
+		TryoutID:  tryoutID,
+		UserID:    user.ID,
+		TakerName: "Clock Tester",
+		Status:    models.AttemptInProgress,
+		StartedAt: startedAt,
+		Deadline:  startedAt.Add(duration),
+	}
+	if err := repository.Attempts.Create(context.Background(), &attempt); err != nil {
+		t.Fatal(err)
+	}
+	return attempt
+}
+
+func TestStartAndGetAttempt(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Timed"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex() + "/attempts"
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	publishTryout(t, router, tryout.ID.Hex())
+
+	attempt := startAttempt(t, router, tryout.ID.Hex())
+	if attempt.Status != models.AttemptInProgress || attempt.TakerName != "Test Taker" {
+		t.Fatalf("unexpected attempt: %+v", attempt)
+	}
+	if got := attempt.Deadline.Sub(attempt.StartedAt); got != 30*time.Minute {
+		t.Fatalf("expected a 30 minute deadline, got %s", got)
+	}
+	if attempt.RemainingSeconds <= 29*60 || attempt.RemainingSeconds > 30*60 {
+		t.Fatalf("unexpected remaining seconds %d", attempt.RemainingSeconds)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, base+"/"+attempt.ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var fetched models.Attempt
+	decode(t, rec, &fetched)
+	if fetched.ID != attempt.ID || fetched.RemainingSeconds <= 0 {
+		t.Fatalf("unexpected attempt: %+v", fetched)
+	}
+
+	// The taker name defaults to the name of the account
+	rec = doRequest(t, router, http.MethodPost, base, models.AttemptInput{})
+	expectStatus(t, rec, http.StatusCreated)
+	var unnamed models.Attempt
+	decode(t, rec, &unnamed)
+	if unnamed.TakerName != "Test User" || unnamed.UserID != attempt.UserID {
+		t.Fatalf("unexpected attempt: %+v", unnamed)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, base, "not json"), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+missingID+"/attempts", models.AttemptInput{TakerName: "x"}), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/bad/attempts", models.AttemptInput{TakerName: "x"}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/bad", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/"+missingID, nil), http.StatusNotFound)
+
+	other := createTryout(t, router, sampleTryout("Other"))
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+other.ID.Hex()+"/attempts/"+attempt.ID.Hex(), nil), http.StatusNotFound)
+}
+
+func TestExpiredAttemptIsClosedOnRead(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Expired"))
+	attempt := insertAttempt(t, tryout.ID, time.Now().Add(-2*time.Hour), time.Hour)
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+tryout.ID.Hex()+"/attempts/"+attempt.ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var fetched models.Attempt
+	decode(t, rec, &fetched)
+	if fetched.Status != models.AttemptExpired || fetched.RemainingSeconds != 0 {
+		t.Fatalf("expected an expired attempt, got %+v", fetched)
+	}
+}
+
+func TestSubmissionDeadline(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Deadline"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	path := "/api/v1/tryouts/" + tryout.ID.Hex() + "/submissions"
+	answer := models.AnswerInput{QuestionID: question.ID.Hex(), Answer: boolPtr(true)}
+
+	// Within the grace period the submission is accepted but flagged late
+	late := insertAttempt(t, tryout.ID, time.Now().Add(-time.Hour), time.Hour-5*time.Second)
+	rec := doRequest(t, router, http.MethodPost, path, answersFor(late, answer))
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	if !submission.IsLate {
+		t.Fatal("expected the submission to be flagged late")
+	}
+
+	// After the grace period it is rejected and the attempt is closed
+	expired := insertAttempt(t, tryout.ID, time.Now().Add(-2*time.Hour), time.Hour)
+	rec = doRequest(t, router, http.MethodPost, path, answersFor(expired, answer))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	stored, err := repository.Attempts.Get(context.Background(), expired.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.AttemptExpired {
+		t.Fatalf("expected the attempt to be expired, got %s", stored.Status)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, answersFor(expired, answer)), http.StatusConflict)
+}
+
+func TestSubmissionGradesEveryQuestionType(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Mixed"))
+	id := tryout.ID.Hex()
+
+	trueFalse := createQuestion(t, router, id, models.QuestionInput{Text: "The sky is blue.", IsTrue: true})
+	choice := createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "2 + 2 = ?",
+		Points:  floatPtr(2),
+		Options: []models.OptionInput{{Text: "3"}, {Text: "4", IsCorrect: true}},
+	})
+	multi := createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultiSelect,
+		Text:    "Pick the primes",
+		Options: []models.OptionInput{{Text: "2", IsCorrect: true}, {Text: "3", IsCorrect: true}, {Text: "4"}},
+	})
+	short := createQuestion(t, router, id, models.QuestionInput{
+		Type:             models.QuestionShortAnswer,
+		Text:             "Who wrote Hamlet?",
+		AcceptedAnswers:  []string{"Shakespeare"},
+		AcceptedPatterns: []string{`(william )?shakespeare`},
+	})
+	numeric := createQuestion(t, router, id, models.QuestionInput{
+		Type:              models.QuestionNumeric,
+		Text:              "g in m/s^2",
+		NumericAnswer:     floatPtr(9.81),
+		RelativeTolerance: 0.01,
+	})
+	skipped := createQuestion(t, router, id, models.QuestionInput{Text: "Left blank", IsTrue: false})
+	publishTryout(t, router, id)
+
+	attempt := startAttempt(t, router, id)
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+id+"/submissions", answersFor(attempt,
+		models.AnswerInput{QuestionID: trueFalse.ID.Hex(), Answer: boolPtr(true)},
+		models.AnswerInput{QuestionID: choice.ID.Hex(), SelectedOptions: []string{choice.Options[1].ID.Hex()}},
+		models.AnswerInput{QuestionID: multi.ID.Hex(), SelectedOptions: []string{multi.Options[0].ID.Hex()}},
+		models.AnswerInput{QuestionID: short.ID.Hex(), Text: "  william   SHAKESPEARE "},
+		models.AnswerInput{QuestionID: numeric.ID.Hex(), Text: "9.8 m/s^2"},
+	))
+	expectStatus(t, rec, http.StatusCreated)
+
+	var submission models.Submission
+	decode(t, rec, &submission)
+	if submission.Status != models.SubmissionGraded || submission.Score == nil {
+		t.Fatalf("expected a graded submission, got %+v", submission)
+	}
+	if *submission.Score != 5 || submission.MaxScore != 7 {
+		t.Fatalf("expected a score of 5/7, got %v/%v", *submission.Score, submission.MaxScore)
+	}
+
+	correct := map[primitive.ObjectID]bool{}
+	for _, answer := range submission.Answers {
+		correct[answer.QuestionID] = answer.IsCorrect
+	}
+	expected := map[primitive.ObjectID]bool{
+		trueFalse.ID: true,
+		choice.ID:    true,
+		multi.ID:     false,
+		short.ID:     true,
+		numeric.ID:   true,
+		skipped.ID:   false,
+	}
+	for questionID, want := range expected {
+		if correct[questionID] != want {
+			t.Errorf("question %s: expected correct=%v", questionID.Hex(), want)
+		}
+	}
+
+	// The attempt is closed and cannot be submitted twice
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/attempts/"+attempt.ID.Hex(), nil)
+	var closed models.Attempt
+	decode(t, rec, &closed)
+	if closed.Status != models.AttemptSubmitted || closed.SubmissionID == nil || *closed.SubmissionID != submission.ID {
+		t.Fatalf("expected the attempt to reference its submission, got %+v", closed)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+id+"/submissions", answersFor(attempt)), http.StatusConflict)
+}
+
+func TestSubmissionValidation(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Strict"))
+	id := tryout.ID.Hex()
+	path := "/api/v1/tryouts/" + id + "/submissions"
+
+	// A tryout without questions cannot be submitted
+	empty := insertAttempt(t, tryout.ID, time.Now(), time.Hour)
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, answersFor(empty)), http.StatusBadRequest)
+
+	question := createQuestion(t, router, id, models.QuestionInput{Text: "q", IsTrue: true})
+	choice := createQuestion(t, router, id, models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "c",
+		Options: []models.OptionInput{{Text: "a", IsCorrect: true}, {Text: "b"}},
+	})
+	foreign := createTryout(t, router, sampleTryout("Foreign"))
+	foreignQuestion := createQuestion(t, router, foreign.ID.Hex(), models.QuestionInput{Text: "f"})
+	publishTryout(t, router, id)
+	attempt := startAttempt(t, router, id)
+
+	cases := map[string]interface{}{
+		"missing attempt":          models.SubmissionInput{Answers: []models.AnswerInput{}},
+		"missing answers":          models.SubmissionInput{AttemptID: attempt.ID.Hex()},
+		"invalid attempt ID":       models.SubmissionInput{AttemptID: "bad", Answers: []models.AnswerInput{}},
+		"invalid question ID":      answersFor(attempt, models.AnswerInput{QuestionID: "bad", Answer: boolPtr(true)}),
+		"missing true/false value": answersFor(attempt, models.AnswerInput{QuestionID: question.ID.Hex()}),
+		"duplicate answer": answersFor(attempt,
+			models.AnswerInput{QuestionID: question.ID.Hex(), Answer: boolPtr(true)},
+			models.AnswerInput{QuestionID: question.ID.Hex(), Answer: boolPtr(false)}),
+		"question from another tryout": answersFor(attempt, models.AnswerInput{QuestionID: foreignQuestion.ID.Hex(), Answer: boolPtr(true)}),
+		"unknown option":               answersFor(attempt, models.AnswerInput{QuestionID: choice.ID.Hex(), SelectedOptions: []string{missingID}}),
+		"two options for single choice": answersFor(attempt, models.AnswerInput{QuestionID: choice.ID.Hex(), SelectedOptions: []string{
+			choice.Options[0].ID.Hex(), choice.Options[1].ID.Hex(),
+		}}),
+	}
+
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, doRequest(t, router, http.MethodPost, path, body), http.StatusBadRequest)
+		})
+	}
+
+	// Rejected submissions leave the attempt open
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, answersFor(attempt)), http.StatusCreated)
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, path, models.SubmissionInput{AttemptID: missingID, Answers: []models.AnswerInput{}}), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+missingID+"/submissions", answersFor(attempt)), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/bad/submissions", answersFor(attempt)), http.StatusBadRequest)
+}
+
+func TestListAndGetSubmissions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Results"))
+	id := tryout.ID.Hex()
+	createQuestion(t, router, id, models.QuestionInput{Text: "q", IsTrue: true})
+	publishTryout(t, router, id)
+	path := "/api/v1/tryouts/" + id + "/submissions"
+
+	var created []models.Submission
+	for i := 0; i < 2; i++ {
+		rec := doRequest(t, router, http.MethodPost, path, answersFor(startAttempt(t, router, id)))
+		expectStatus(t, rec, http.StatusCreated)
+		var submission models.Submission
+		decode(t, rec, &submission)
+		created = append(created, submission)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var submissions []models.Submission
+	decode(t, rec, &submissions)
+	if len(submissions) != 2 {
+		t.Fatalf("expected 2 submissions, got %d", len(submissions))
+	}
+
+	rec = doRequest(t, router, http.MethodGet, path+"/"+created[0].ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	other := createTryout(t, router, sampleTryout("Other"))
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+other.ID.Hex()+"/submissions/"+created[0].ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, path+"/"+missingID, nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, path+"/bad", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/bad/submissions", nil), http.StatusBadRequest)
+}
+
+func TestEssayGradingQueue(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Literature"))
+	id := tryout.ID.Hex()
+
+	auto := createQuestion(t, router, id, models.QuestionInput{Text: "Hamlet is a tragedy.", IsTrue: true})
+	essay := createQuestion(t, router, id, models.QuestionInput{Type: models.QuestionEssay, Text: "Discuss Hamlet's indecision.", Points: floatPtr(10)})
+	publishTryout(t, router, id)
+
+	attempt := startAttempt(t, router, id)
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+id+"/submissions", answersFor(attempt,
+		models.AnswerInput{QuestionID: auto.ID.Hex(), Answer: boolPtr(true)},
+		models.AnswerInput{QuestionID: essay.ID.Hex(), Text: "He hesitates because..."},
+	))
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	if submission.Status != models.SubmissionPending || submission.Score != nil || submission.MaxScore != 11 {
+		t.Fatalf("expected a pending submission, got %+v", submission)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/grading-queue", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var queue []models.GradingQueueItem
+	decode(t, rec, &queue)
+	if len(queue) != 1 || queue[0].QuestionID != essay.ID || queue[0].QuestionText != essay.Text || queue[0].Text != "He hesitates because..." {
+		t.Fatalf("unexpected grading queue: %+v", queue)
+	}
+
+	gradePath := "/api/v1/tryouts/" + id + "/submissions/" + submission.ID.Hex() + "/answers/"
+	expectStatus(t, doRequest(t, router, http.MethodPut, gradePath+essay.ID.Hex()+"/grade", models.GradeInput{Points: floatPtr(11)}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, gradePath+essay.ID.Hex()+"/grade", models.GradeInput{}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, gradePath+auto.ID.Hex()+"/grade", models.GradeInput{Points: floatPtr(1)}), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, gradePath+missingID+"/grade", models.GradeInput{Points: floatPtr(1)}), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+id+"/submissions/"+missingID+"/answers/"+essay.ID.Hex()+"/grade", models.GradeInput{Points: floatPtr(1)}), http.StatusNotFound)
+
+	rec = doRequest(t, router, http.MethodPut, gradePath+essay.ID.Hex()+"/grade", models.GradeInput{Points: floatPtr(7.5), Feedback: "Good analysis"})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &submission)
+	if submission.Status != models.SubmissionGraded || submission.Score == nil || *submission.Score != 8.5 {
+		t.Fatalf("expected a graded submission scoring 8.5, got %+v", submission)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+id+"/grading-queue", nil)
+	decode(t, rec, &queue)
+	if len(queue) != 0 {
+		t.Fatalf("expected an empty grading queue, got %+v", queue)
+	}
+}
+
+// attemptQuestions returns the questions of an attempt in the order it shows them
+func attemptQuestions(t *testing.T, router *gin.Engine, token string, attempt models.Attempt) []models.TakerQuestion {
+	t.Helper()
+	rec := doRequestAs(t, router, token, http.MethodGet, "/api/v1/tryouts/"+attempt.TryoutID.Hex()+"/attempts/"+attempt.ID.Hex()+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var questions []models.TakerQuestion
+	decode(t, rec, &questions)
+	return questions
+}
+
+func TestShuffledAttempts(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	input := sampleTryout("Shuffled")
+	input.Shuffle = models.ShuffleSettings{Questions: true, Options: true}
+	tryout := createTryout(t, router, input)
+
+	canonical := []string{}
+	keys := map[string]bool{}
+	for i := 0; i < 6; i++ {
+		text := string(rune('A' + i))
+		createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: text, IsTrue: i%2 == 0})
+		canonical = append(canonical, text)
+		keys[text] = i%2 == 0
+	}
+	choice := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "choice",
+		Options: []models.OptionInput{{Text: "1", IsCorrect: true}, {Text: "2"}, {Text: "3"}, {Text: "4"}, {Text: "5"}},
+	})
+	canonical = append(canonical, "choice")
+	publishTryout(t, router, tryout.ID.Hex())
+
+	texts := func(questions []models.TakerQuestion) string {
+		joined := ""
+		for i, question := range questions {
+			if question.Position != i+1 {
+				t.Fatalf("expected position %d, got %+v", i+1, question)
+			}
+			joined += question.Text + ","
+		}
+		return joined
+	}
+
+	// Each attempt keeps its own order across reloads, and the orders differ
+	shuffled := false
+	var attempt models.Attempt
+	var order []models.TakerQuestion
+	for i := 0; i < 10 && !shuffled; i++ {
+		attempt = startAttempt(t, router, tryout.ID.Hex())
+		order = attemptQuestions(t, router, testToken, attempt)
+		if texts(order) != texts(attemptQuestions(t, router, testToken, attempt)) {
+			t.Fatal("expected reloading an attempt to keep its order")
+		}
+		shuffled = texts(order) != strings.Join(canonical, ",")+","
+	}
+	if !shuffled || len(order) != len(canonical) {
+		t.Fatalf("expected attempts to shuffle the questions, got %s", texts(order))
+	}
+
+	// Answers given by position are graded against the question shown there
+	answers := []models.AnswerInput{}
+	for _, question := range order {
+		answer := models.AnswerInput{Position: question.Position}
+		if question.ID == choice.ID {
+			if len(question.Options) != 5 {
+				t.Fatalf("expected every option, got %+v", question.Options)
+			}
+			answer.SelectedOptions = []string{choice.Options[0].ID.Hex()}
+		} else {
+			answer.Answer = boolPtr(keys[question.Text])
+		}
+		answers = append(answers, answer)
+	}
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryout.ID.Hex()+"/submissions", answersFor(attempt, answers...))
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	if submission.Score == nil || *submission.Score != submission.MaxScore || submission.MaxScore != 7 || len(submission.Answers) != len(canonical) {
+		t.Fatalf("expected every answer to be correct, got %+v", submission)
+	}
+
+	// Positions must exist and agree with a question ID given alongside
+	other := startAttempt(t, router, tryout.ID.Hex())
+	otherOrder := attemptQuestions(t, router, testToken, other)
+	invalid := map[string]models.AnswerInput{
+		"position past the end":   {Position: len(canonical) + 1, Answer: boolPtr(true)},
+		"mismatched question ID":  {Position: 1, QuestionID: otherOrder[1].ID.Hex(), Answer: boolPtr(true)},
+		"neither ID nor position": {Answer: boolPtr(true)},
+	}
+	for name, answer := range invalid {
+		t.Run(name, func(t *testing.T) {
+			rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+tryout.ID.Hex()+"/submissions", answersFor(other, answer))
+			expectStatus(t, rec, http.StatusBadRequest)
+		})
+	}
+
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, "/api/v1/tryouts/"+tryout.ID.Hex()+"/attempts/"+other.ID.Hex()+"/questions", nil), http.StatusForbidden)
+}
+
+func TestUnshuffledAttemptsKeepTheQuestionOrder(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("In order"))
+	first := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "first", IsTrue: true})
+	second := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{
+		Type:    models.QuestionMultipleChoice,
+		Text:    "second",
+		Options: []models.OptionInput{{Text: "1", IsCorrect: true}, {Text: "2"}, {Text: "3"}},
+	})
+	publishTryout(t, router, tryout.ID.Hex())
+
+	attempt := startAttempt(t, router, tryout.ID.Hex())
+	if attempt.Shuffle.Questions || attempt.Shuffle.Options {
+		t.Fatalf("expected the attempt not to shuffle, got %+v", attempt.Shuffle)
+	}
+	questions := attemptQuestions(t, router, testToken, attempt)
+	if len(questions) != 2 || questions[0].ID != first.ID || questions[1].ID != second.ID || questions[1].Options[0].Text != "1" {
+		t.Fatalf("expected the canonical order, got %+v", questions)
+	}
+}
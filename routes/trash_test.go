@@ -0,0 +1,224 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type trashResponse struct {
+	Tryouts       []models.Tryout   `json:"tryouts"`
+	Questions     []models.Question `json:"questions"`
+	RetentionDays int               `json:"retentionDays"`
+}
+
+func getTrash(t *testing.T, router *gin.Engine) trashResponse {
+	t.Helper()
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/trash", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var trash trashResponse
+	decode(t, rec, &trash)
+	return trash
+}
+
+func TestDeletedTryoutIsHidden(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Trashed Geometry"))
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Geometry question", IsTrue: true})
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base, nil), http.StatusOK)
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, base, nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPut, base, sampleTryout("Edited")), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base, nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/questions/"+question.ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/attempts", models.AttemptInput{TakerName: "Late"}), http.StatusNotFound)
+
+	if list := listTryouts(t, router, "/api/v1/tryouts"); list.Total != 0 {
+		t.Fatalf("expected deleted tryouts to be left out of listings, got %+v", list)
+	}
+	if list := listTryouts(t, router, "/api/v1/tryouts/filter?category=Testing"); list.Total != 0 {
+		t.Fatalf("expected deleted tryouts to be left out of filters, got %+v", list)
+	}
+	if body := search(t, router, url.Values{"q": {"geometry"}}); len(body.Results) != 0 {
+		t.Fatalf("expected deleted tryouts to be left out of search, got %+v", body.Results)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, base+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var questions []models.Question
+	decode(t, rec, &questions)
+	if len(questions) != 0 {
+		t.Fatalf("expected the questions to be trashed with their tryout, got %d", len(questions))
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Restorable"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	kept := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Deleted with the tryout", IsTrue: true})
+	earlier := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Deleted on its own", IsTrue: true})
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"/questions/"+earlier.ID.Hex(), nil), http.StatusOK)
+
+	trash := getTrash(t, router)
+	if len(trash.Tryouts) != 0 || len(trash.Questions) != 1 || trash.Questions[0].ID != earlier.ID || trash.Questions[0].DeletedAt == nil {
+		t.Fatalf("expected only the deleted question in the trash, got %+v", trash)
+	}
+	if trash.RetentionDays != 30 {
+		t.Fatalf("expected the default retention of 30 days, got %d", trash.RetentionDays)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base, nil), http.StatusOK)
+
+	// Questions of a trashed tryout are listed under the tryout only
+	trash = getTrash(t, router)
+	if len(trash.Tryouts) != 1 || trash.Tryouts[0].ID != tryout.ID || len(trash.Questions) != 0 {
+		t.Fatalf("expected only the tryout in the trash, got %+v", trash)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/"+earlier.ID.Hex()+"/restore", nil), http.StatusConflict)
+
+	rec := doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+tryout.ID.Hex()+"/restore", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var restored models.Tryout
+	decode(t, rec, &restored)
+	if restored.ID != tryout.ID || restored.DeletedAt != nil {
+		t.Fatalf("unexpected restored tryout %+v", restored)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+tryout.ID.Hex()+"/restore", nil), http.StatusNotFound)
+
+	// The question deleted on its own stays in the trash until restored itself
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/questions/"+kept.ID.Hex(), nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/questions/"+earlier.ID.Hex(), nil), http.StatusNotFound)
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/"+earlier.ID.Hex()+"/restore", nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/questions/"+earlier.ID.Hex(), nil), http.StatusOK)
+
+	if trash := getTrash(t, router); len(trash.Tryouts) != 0 || len(trash.Questions) != 0 {
+		t.Fatalf("expected an empty trash, got %+v", trash)
+	}
+}
+
+func TestForcedDeleteKeepsSubmissions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Taken"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Taken question", IsTrue: true})
+	publishTryout(t, router, tryout.ID.Hex())
+	submitted := startAttempt(t, router, tryout.ID.Hex())
+	rec := doRequest(t, router, http.MethodPost, base+"/submissions", models.SubmissionInput{AttemptID: submitted.ID.Hex(), Answers: []models.AnswerInput{}})
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	started := startAttempt(t, router, tryout.ID.Hex())
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, base+"?force=true", nil), http.StatusOK)
+
+	// Attempts and submissions stay with the trashed tryout, out of reach
+	paths := []string{
+		base + "/attempts/" + started.ID.Hex(),
+		base + "/attempts/" + started.ID.Hex() + "/questions",
+		base + "/submissions",
+		base + "/submissions/" + submission.ID.Hex(),
+		base + "/grading-queue",
+	}
+	for _, path := range paths {
+		expectStatus(t, doRequest(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/submissions", models.SubmissionInput{AttemptID: started.ID.Hex(), Answers: []models.AnswerInput{}}), http.StatusNotFound)
+	submissions, err := repository.Submissions.ListByTryout(context.Background(), tryout.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 1 {
+		t.Fatalf("expected the submission to stay until the tryout is purged, got %d", len(submissions))
+	}
+
+	// and come back with it
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+tryout.ID.Hex()+"/restore", nil), http.StatusOK)
+	for _, path := range paths {
+		expectStatus(t, doRequest(t, router, http.MethodGet, path, nil), http.StatusOK)
+	}
+}
+
+func TestRestoreValidation(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Locked Later"))
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Stays", IsTrue: true})
+	deleted := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Deleted before the tryout was taken", IsTrue: true})
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, "/api/v1/tryouts/"+tryout.ID.Hex()+"/questions/"+deleted.ID.Hex(), nil), http.StatusOK)
+	lockTryout(t, router, tryout.ID.Hex())
+
+	// Restoring only changes the working copy of a tryout that has been taken
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/"+deleted.ID.Hex()+"/restore", nil), http.StatusOK)
+	rec := doRequestAs(t, router, "", http.MethodGet, "/api/v1/tryouts/"+tryout.ID.Hex()+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var published []models.TakerQuestion
+	decode(t, rec, &published)
+	if len(published) != 1 {
+		t.Fatalf("expected takers to keep getting the published question, got %+v", published)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/not-an-id/restore", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/questions/not-an-id/restore", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+missingID+"/restore", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+tryout.ID.Hex()+"/restore", nil), http.StatusNotFound)
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	router := newTestRouter(t)
+	ctx := context.Background()
+
+	old := createTryout(t, router, sampleTryout("Old"))
+	oldQuestion := createQuestion(t, router, old.ID.Hex(), models.QuestionInput{Text: "Old question", IsTrue: true})
+	lockTryout(t, router, old.ID.Hex())
+
+	recent := createTryout(t, router, sampleTryout("Recent"))
+	stray := createQuestion(t, router, recent.ID.Hex(), models.QuestionInput{Text: "Stray", IsTrue: true})
+
+	author := currentUser(t, router, testToken)
+	longAgo := time.Now().Add(-48 * time.Hour)
+	if err := repository.Tryouts.Delete(ctx, old.ID, true, longAgo, author); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Questions.Delete(ctx, stray.ID, longAgo, author); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodDelete, "/api/v1/tryouts/"+recent.ID.Hex(), nil), http.StatusOK)
+
+	report, err := repository.Maintenance.PurgeDeleted(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Tryouts != 1 || report.Questions != 2 {
+		t.Fatalf("expected the old tryout, its question and the stray question to be purged, got %+v", report)
+	}
+
+	trash := getTrash(t, router)
+	if len(trash.Tryouts) != 1 || trash.Tryouts[0].ID != recent.ID {
+		t.Fatalf("expected only the recently deleted tryout to remain in the trash, got %+v", trash)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/trash/tryouts/"+old.ID.Hex()+"/restore", nil), http.StatusNotFound)
+
+	// Purging a tryout takes its submissions with it
+	submissions, err := repository.Submissions.ListByTryout(ctx, old.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 0 {
+		t.Fatalf("expected the submissions of the purged tryout to be removed, got %d", len(submissions))
+	}
+	if _, err := repository.Questions.Get(ctx, oldQuestion.ID); err != repository.ErrNotFound {
+		t.Fatalf("expected the purged question to be gone, got %v", err)
+	}
+}
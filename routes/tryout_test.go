@@ -0,0 +1,563 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"quiz-platform/jobs"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const missingID = "0123456789abcdef01234567"
+
+func TestHealthCheck(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodGet, "/", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var body struct {
+		Message   string   `json:"message"`
+		Endpoints []string `json:"endpoints"`
+	}
+	decode(t, rec, &body)
+	if body.Message == "" || len(body.Endpoints) == 0 {
+		t.Fatalf("unexpected health check body: %s", rec.Body.String())
+	}
+}
+
+func TestGetAllTryouts(t *testing.T) {
+	router := newTestRouter(t)
+
+	list := listTryouts(t, router, "/api/v1/tryouts")
+	if len(list.Items) != 0 || list.Total != 0 {
+		t.Fatalf("expected no tryouts, got %+v", list)
+	}
+
+	createTryout(t, router, sampleTryout("First"))
+	createTryout(t, router, sampleTryout("Second"))
+
+	list = listTryouts(t, router, "/api/v1/tryouts")
+	if len(list.Items) != 2 || list.Total != 2 {
+		t.Fatalf("expected 2 tryouts, got %+v", list)
+	}
+	if list.Page != 1 || list.PageSize != models.DefaultPageSize || list.NextCursor != "" {
+		t.Fatalf("unexpected pagination: %+v", list)
+	}
+}
+
+// listTryouts fetches a page of tryouts as the test account
+func listTryouts(t *testing.T, router *gin.Engine, path string) models.TryoutList {
+	t.Helper()
+	return listTryoutsAs(t, router, testToken, path)
+}
+
+// listTryoutsAs fetches a page of tryouts as the holder of an access token
+func listTryoutsAs(t *testing.T, router *gin.Engine, token, path string) models.TryoutList {
+	t.Helper()
+	rec := doRequestAs(t, router, token, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var list models.TryoutList
+	decode(t, rec, &list)
+	return list
+}
+
+func titles(tryouts []models.Tryout) []string {
+	result := make([]string, len(tryouts))
+	for i, tryout := range tryouts {
+		result[i] = tryout.Title
+	}
+	return result
+}
+
+func TestTryoutPagination(t *testing.T) {
+	router := newTestRouter(t)
+
+	for i, title := range []string{"Echo", "Alpha", "Delta", "Charlie", "Bravo"} {
+		input := sampleTryout(title)
+		input.Duration = 10 * (i + 1)
+		createTryout(t, router, input)
+	}
+
+	// Numbered pages, oldest first by default
+	list := listTryouts(t, router, "/api/v1/tryouts?page=2&pageSize=2")
+	if got := strings.Join(titles(list.Items), ","); got != "Delta,Charlie" {
+		t.Fatalf("expected the second page to be Delta,Charlie, got %s", got)
+	}
+	if list.Total != 5 || list.Page != 2 || list.PageSize != 2 || list.NextCursor == "" {
+		t.Fatalf("unexpected pagination: %+v", list)
+	}
+	if list := listTryouts(t, router, "/api/v1/tryouts?page=4&pageSize=2"); len(list.Items) != 0 || list.Total != 5 {
+		t.Fatalf("expected an empty page past the end, got %+v", list)
+	}
+
+	// Sorting
+	if got := strings.Join(titles(listTryouts(t, router, "/api/v1/tryouts?sort=title").Items), ","); got != "Alpha,Bravo,Charlie,Delta,Echo" {
+		t.Fatalf("unexpected title order %s", got)
+	}
+	if got := strings.Join(titles(listTryouts(t, router, "/api/v1/tryouts?sort=-duration").Items), ","); got != "Bravo,Charlie,Delta,Alpha,Echo" {
+		t.Fatalf("unexpected duration order %s", got)
+	}
+
+	// Following the cursor visits every tryout exactly once
+	var visited []string
+	path := "/api/v1/tryouts?sort=title&pageSize=2"
+	for {
+		list := listTryouts(t, router, path)
+		if list.Page != 0 && len(visited) > 0 {
+			t.Fatalf("cursor pages should not report a page number: %+v", list)
+		}
+		visited = append(visited, titles(list.Items)...)
+		if list.NextCursor == "" {
+			break
+		}
+		path = "/api/v1/tryouts?sort=title&pageSize=2&after=" + list.NextCursor
+	}
+	if got := strings.Join(visited, ","); got != "Alpha,Bravo,Charlie,Delta,Echo" {
+		t.Fatalf("cursor pagination visited %s", got)
+	}
+
+	// The same parameters apply to the filter endpoint
+	list = listTryouts(t, router, "/api/v1/tryouts/filter?category=Testing&sort=title&pageSize=3")
+	if got := strings.Join(titles(list.Items), ","); got != "Alpha,Bravo,Charlie" || list.Total != 5 || list.NextCursor == "" {
+		t.Fatalf("unexpected filtered page %s: %+v", got, list)
+	}
+	list = listTryouts(t, router, "/api/v1/tryouts/filter?category=Testing&sort=title&pageSize=3&after="+list.NextCursor)
+	if got := strings.Join(titles(list.Items), ","); got != "Delta,Echo" || list.NextCursor != "" {
+		t.Fatalf("unexpected second filtered page %s: %+v", got, list)
+	}
+}
+
+func TestTryoutPaginationValidation(t *testing.T) {
+	router := newTestRouter(t)
+	createTryout(t, router, sampleTryout("Only"))
+
+	for _, query := range []string{
+		"page=0",
+		"page=abc",
+		"pageSize=0",
+		"pageSize=101",
+		"sort=difficulty",
+		"sort=--title",
+		"after=not-an-id",
+		"after=" + missingID,
+		"page=2&after=" + missingID,
+	} {
+		for _, endpoint := range []string{"/api/v1/tryouts", "/api/v1/tryouts/filter"} {
+			rec := doRequest(t, router, http.MethodGet, endpoint+"?"+query, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s?%s: expected status 400, got %d", endpoint, query, rec.Code)
+			}
+		}
+	}
+}
+
+func TestCreateTryout(t *testing.T) {
+	router := newTestRouter(t)
+
+	tryout := createTryout(t, router, sampleTryout("Algebra"))
+	if tryout.ID.IsZero() {
+		t.Fatal("expected the created tryout to have an ID")
+	}
+	if tryout.Title != "Algebra" || tryout.Duration != 30 || tryout.HasSubmission {
+		t.Fatalf("unexpected tryout: %+v", tryout)
+	}
+	if tryout.CreatedAt.IsZero() || tryout.UpdatedAt.IsZero() {
+		t.Fatal("expected timestamps to be set")
+	}
+}
+
+func TestCreateTryoutValidation(t *testing.T) {
+	router := newTestRouter(t)
+
+	cases := map[string]interface{}{
+		"malformed JSON":      `{"title":`,
+		"missing title":       models.TryoutInput{Description: "d", Category: "c", Duration: 10},
+		"missing description": models.TryoutInput{Title: "t", Category: "c", Duration: 10},
+		"missing category":    models.TryoutInput{Title: "t", Description: "d", Duration: 10},
+		"missing duration":    models.TryoutInput{Title: "t", Description: "d", Category: "c"},
+		"negative duration":   models.TryoutInput{Title: "t", Description: "d", Category: "c", Duration: -5},
+	}
+
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := doRequest(t, router, http.MethodPost, "/api/v1/tryouts", body)
+			expectStatus(t, rec, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestGetTryout(t *testing.T) {
+	router := newTestRouter(t)
+	created := createTryout(t, router, sampleTryout("Geometry"))
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+created.ID.Hex(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var tryout models.Tryout
+	decode(t, rec, &tryout)
+	if tryout.ID != created.ID || tryout.Title != "Geometry" {
+		t.Fatalf("unexpected tryout: %+v", tryout)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID, nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/not-an-id", nil), http.StatusBadRequest)
+}
+
+func TestUpdateTryout(t *testing.T) {
+	router := newTestRouter(t)
+	created := createTryout(t, router, sampleTryout("Draft"))
+
+	input := models.TryoutInput{Title: "Final", Description: "Updated", Category: "History", Duration: 90}
+	rec := doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+created.ID.Hex(), input)
+	expectStatus(t, rec, http.StatusOK)
+
+	var tryout models.Tryout
+	decode(t, rec, &tryout)
+	if tryout.Title != "Final" || tryout.Description != "Updated" || tryout.Category != "History" || tryout.Duration != 90 {
+		t.Fatalf("tryout not updated: %+v", tryout)
+	}
+	// Stored timestamps have millisecond precision, like MongoDB dates
+	if !tryout.CreatedAt.Equal(created.CreatedAt.Truncate(time.Millisecond)) {
+		t.Fatal("expected createdAt to be preserved")
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+missingID, input), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/not-an-id", input), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodPut, "/api/v1/tryouts/"+created.ID.Hex(), models.TryoutInput{Title: "Only a title"}), http.StatusBadRequest)
+}
+
+func TestDeleteTryout(t *testing.T) {
+	router := newTestRouter(t)
+	created := createTryout(t, router, sampleTryout("Disposable"))
+	question := createQuestion(t, router, created.ID.Hex(), models.QuestionInput{Text: "Goes too", IsTrue: true})
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, "/api/v1/tryouts/"+created.ID.Hex(), nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+created.ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, "/api/v1/tryouts/"+created.ID.Hex(), nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, "/api/v1/tryouts/not-an-id", nil), http.StatusBadRequest)
+
+	if _, err := repository.Questions.Get(context.Background(), question.ID); err != repository.ErrNotFound {
+		t.Fatalf("expected the question to be deleted with its tryout, got %v", err)
+	}
+}
+
+func TestDeleteTryoutWithSubmissions(t *testing.T) {
+	router := newTestRouter(t)
+	tryout := createTryout(t, router, sampleTryout("Taken"))
+	path := "/api/v1/tryouts/" + tryout.ID.Hex()
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Answered", IsTrue: true})
+	lockTryout(t, router, tryout.ID.Hex())
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, path, nil), http.StatusConflict)
+	expectStatus(t, doRequest(t, router, http.MethodDelete, path+"?force=maybe", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, path, nil), http.StatusOK)
+
+	expectStatus(t, doRequest(t, router, http.MethodDelete, path+"?force=true", nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+
+	report, err := repository.Maintenance.FindOrphans(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total() != 0 {
+		t.Fatalf("expected the forced delete to leave nothing behind, got %+v", report)
+	}
+}
+
+func TestOrphanCleanup(t *testing.T) {
+	router := newTestRouter(t)
+	ctx := context.Background()
+	tryout := createTryout(t, router, sampleTryout("Kept"))
+	kept := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Kept", IsTrue: true})
+
+	// Simulate a question left behind by a deletion that did not cascade
+	orphan := models.Question{TryoutID: primitive.NewObjectID(), Type: models.QuestionTrueFalse, Text: "Orphan", Points: 1}
+	if err := repository.Questions.Create(ctx, &orphan, currentUser(t, router, testToken)); err != nil {
+		t.Fatal(err)
+	}
+	insertAttempt(t, orphan.TryoutID, time.Now(), time.Minute)
+
+	report, err := repository.Maintenance.FindOrphans(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Questions) != 1 || report.Questions[0] != orphan.ID || len(report.Attempts) != 1 || len(report.Submissions) != 0 {
+		t.Fatalf("unexpected orphan report %+v", report)
+	}
+
+	if err := repository.Maintenance.DeleteOrphans(ctx, report); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := repository.Maintenance.FindOrphans(ctx); report.Total() != 0 {
+		t.Fatalf("expected no orphans after cleanup, got %+v", report)
+	}
+	if _, err := repository.Questions.Get(ctx, kept.ID); err != nil {
+		t.Fatalf("expected questions of existing tryouts to be kept, got %v", err)
+	}
+}
+
+func TestFilterTryouts(t *testing.T) {
+	router := newTestRouter(t)
+
+	math := sampleTryout("Basic Mathematics")
+	math.Category = "Mathematics"
+	createTryout(t, router, math)
+
+	calculus := sampleTryout("Advanced Calculus")
+	calculus.Category = "Mathematics"
+	createTryout(t, router, calculus)
+
+	history := sampleTryout("World History")
+	history.Category = "History"
+	createTryout(t, router, history)
+
+	filter := func(query url.Values) []models.Tryout {
+		t.Helper()
+		return listTryouts(t, router, "/api/v1/tryouts/filter?"+query.Encode()).Items
+	}
+
+	if got := filter(url.Values{"title": {"mathematics"}}); len(got) != 1 || got[0].Title != "Basic Mathematics" {
+		t.Fatalf("title filter returned %+v", got)
+	}
+	if got := filter(url.Values{"category": {"Mathematics"}}); len(got) != 2 {
+		t.Fatalf("expected 2 mathematics tryouts, got %d", len(got))
+	}
+	if got := filter(url.Values{"category": {"Mathematics"}, "title": {"calculus"}}); len(got) != 1 {
+		t.Fatalf("expected 1 calculus tryout, got %d", len(got))
+	}
+	if got := filter(url.Values{}); len(got) != 3 {
+		t.Fatalf("expected all tryouts without filters, got %d", len(got))
+	}
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if got := filter(url.Values{"startDate": {past}, "endDate": {future}}); len(got) != 3 {
+		t.Fatalf("expected all tryouts within the date range, got %d", len(got))
+	}
+	if got := filter(url.Values{"startDate": {future}}); len(got) != 0 {
+		t.Fatalf("expected no tryouts created in the future, got %d", len(got))
+	}
+	if got := filter(url.Values{"endDate": {past}}); len(got) != 0 {
+		t.Fatalf("expected no tryouts created before an hour ago, got %d", len(got))
+	}
+
+	// Unparseable dates are ignored rather than rejected
+	if got := filter(url.Values{"startDate": {"yesterday"}}); len(got) != 3 {
+		t.Fatalf("expected invalid dates to be ignored, got %d tryouts", len(got))
+	}
+}
+
+func TestGetTryoutOptions(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, category := range []string{"Science", "History", "Science"} {
+		input := sampleTryout(category + " quiz")
+		input.Category = category
+		createTryout(t, router, input)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/v1/tryouts/filter/options", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var body struct {
+		Categories []struct {
+			Category string `json:"category"`
+		} `json:"categories"`
+	}
+	decode(t, rec, &body)
+	if len(body.Categories) != 2 {
+		t.Fatalf("expected 2 distinct categories, got %+v", body.Categories)
+	}
+}
+
+func TestTryoutLifecycle(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	other := registerAs(t, router, "other@example.com", models.RoleAuthor)
+	tryout := createTryout(t, router, sampleTryout("Lifecycle"))
+	id := tryout.ID.Hex()
+	base := "/api/v1/tryouts/" + id
+	setStatus := func(token, status string) *httptest.ResponseRecorder {
+		return doRequestAs(t, router, token, http.MethodPut, base+"/status", models.StatusInput{Status: status})
+	}
+
+	// Drafts are only visible to those who manage them
+	if tryout.Status != models.TryoutDraft {
+		t.Fatalf("expected a new tryout to be a draft, got %q", tryout.Status)
+	}
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base, nil), http.StatusNotFound)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base+"/questions", nil), http.StatusNotFound)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, base+"/attempts", models.AttemptInput{}), http.StatusNotFound)
+	for _, token := range []string{student, other, ""} {
+		if list := listTryoutsAs(t, router, token, "/api/v1/tryouts"); list.Total != 0 {
+			t.Fatalf("expected drafts to be hidden from others, got %+v", list)
+		}
+	}
+	if list := listTryouts(t, router, "/api/v1/tryouts?status=draft"); list.Total != 1 {
+		t.Fatalf("expected the author to find their draft, got %+v", list)
+	}
+
+	expectStatus(t, setStatus(testToken, models.TryoutPublished), http.StatusBadRequest)
+	expectStatus(t, setStatus(testToken, models.TryoutArchived), http.StatusConflict)
+	expectStatus(t, setStatus(testToken, "live"), http.StatusBadRequest)
+	expectStatus(t, setStatus(other, models.TryoutPublished), http.StatusForbidden)
+	expectStatus(t, setStatus(student, models.TryoutPublished), http.StatusForbidden)
+
+	createQuestion(t, router, id, models.QuestionInput{Text: "q", IsTrue: true})
+	rec := setStatus(testToken, models.TryoutPublished)
+	expectStatus(t, rec, http.StatusOK)
+	var published models.Tryout
+	decode(t, rec, &published)
+	if published.Status != models.TryoutPublished || published.PublishedAt == nil {
+		t.Fatalf("expected a published tryout, got %+v", published)
+	}
+	expectStatus(t, setStatus(testToken, models.TryoutDraft), http.StatusConflict)
+
+	if list := listTryoutsAs(t, router, student, "/api/v1/tryouts"); list.Total != 1 {
+		t.Fatalf("expected students to see published tryouts, got %+v", list)
+	}
+	if list := listTryouts(t, router, "/api/v1/tryouts/filter?status=draft,archived"); list.Total != 0 {
+		t.Fatalf("expected no drafts left, got %+v", list)
+	}
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, base+"/attempts", models.AttemptInput{}), http.StatusCreated)
+
+	rec = setStatus(testToken, models.TryoutArchived)
+	expectStatus(t, rec, http.StatusOK)
+	var archived models.Tryout
+	decode(t, rec, &archived)
+	if archived.Status != models.TryoutArchived || archived.ArchivedAt == nil || archived.PublishedAt == nil {
+		t.Fatalf("expected an archived tryout, got %+v", archived)
+	}
+
+	// Archived tryouts stay readable but are no longer listed or open
+	if list := listTryoutsAs(t, router, student, "/api/v1/tryouts"); list.Total != 0 {
+		t.Fatalf("expected archived tryouts to be hidden from students, got %+v", list)
+	}
+	if list := listTryouts(t, router, "/api/v1/tryouts?status=archived"); list.Total != 1 {
+		t.Fatalf("expected the author to find the archived tryout, got %+v", list)
+	}
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base, nil), http.StatusOK)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, base+"/attempts", models.AttemptInput{}), http.StatusConflict)
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts?status=live", nil), http.StatusBadRequest)
+}
+
+func TestAvailabilityWindow(t *testing.T) {
+	router := newTestRouter(t)
+	now := time.Now()
+	window := func(title string, opensAt, closesAt *time.Time) models.Tryout {
+		input := sampleTryout(title)
+		input.OpensAt, input.ClosesAt = opensAt, closesAt
+		tryout := createTryout(t, router, input)
+		createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+		publishTryout(t, router, tryout.ID.Hex())
+		return tryout
+	}
+	hourAgo, inAnHour, inTenMinutes := now.Add(-time.Hour), now.Add(time.Hour), now.Add(10*time.Minute)
+
+	upcoming := window("Upcoming", &inAnHour, nil)
+	open := window("Open", &hourAgo, &inTenMinutes)
+	window("Always Open", nil, nil)
+	closed := insertClosedTryout(t, hourAgo)
+
+	for window, want := range map[string]string{
+		models.WindowOpen:     "Open,Always Open",
+		models.WindowUpcoming: "Upcoming",
+		models.WindowClosed:   closed.Title,
+	} {
+		list := listTryouts(t, router, "/api/v1/tryouts/filter?window="+window)
+		if got := strings.Join(titles(list.Items), ","); got != want {
+			t.Errorf("window %s: expected %q, got %q", window, want, got)
+		}
+	}
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/filter?window=soon", nil), http.StatusBadRequest)
+
+	// Attempts only start inside the window and end when it closes
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+upcoming.ID.Hex()+"/attempts", models.AttemptInput{}), http.StatusConflict)
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts/"+closed.ID.Hex()+"/attempts", models.AttemptInput{}), http.StatusConflict)
+	attempt := startAttempt(t, router, open.ID.Hex())
+	if attempt.Deadline.Sub(inTenMinutes).Abs() > time.Millisecond {
+		t.Fatalf("expected the attempt to end when the window closes at %s, got %s", inTenMinutes, attempt.Deadline)
+	}
+
+	input := sampleTryout("Backwards")
+	input.OpensAt, input.ClosesAt = &inAnHour, &hourAgo
+	expectStatus(t, doRequest(t, router, http.MethodPost, "/api/v1/tryouts", input), http.StatusBadRequest)
+}
+
+// insertClosedTryout stores a published tryout whose window closed at closesAt
+func insertClosedTryout(t *testing.T, closesAt time.Time) models.Tryout {
+	t.Helper()
+	tryout := models.Tryout{
+		Title:     "Closed",
+		Duration:  30,
+		Status:    models.TryoutPublished,
+		ClosesAt:  &closesAt,
+		CreatedAt: time.Now(),
+	}
+	if err := repository.Tryouts.Create(context.Background(), &tryout, models.User{Name: models.SeedActor}); err != nil {
+		t.Fatal(err)
+	}
+	return tryout
+}
+
+func TestScheduledPublishing(t *testing.T) {
+	router := newTestRouter(t)
+	now := time.Now()
+	opensAt, closesAt := now.Add(time.Hour), now.Add(2*time.Hour)
+	input := sampleTryout("Scheduled")
+	input.OpensAt, input.ClosesAt = &opensAt, &closesAt
+	scheduled := createTryout(t, router, input)
+	createQuestion(t, router, scheduled.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	empty := createTryout(t, router, input)
+
+	status := func(tryout models.Tryout) string {
+		t.Helper()
+		stored, err := repository.Tryouts.Get(context.Background(), tryout.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored.Status
+	}
+
+	jobs.ApplyDueSchedules(context.Background(), now)
+	if status(scheduled) != models.TryoutDraft {
+		t.Fatal("expected the tryout to stay a draft before it opens")
+	}
+
+	// A draft without questions is reported once, not on every run
+	var logged bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logged)
+	jobs.ApplyDueSchedules(context.Background(), opensAt)
+	jobs.ApplyDueSchedules(context.Background(), opensAt.Add(time.Minute))
+	log.SetOutput(previous)
+	if status(scheduled) != models.TryoutPublished {
+		t.Fatal("expected the tryout to be published when it opens")
+	}
+	if status(empty) != models.TryoutDraft {
+		t.Fatal("expected a tryout without questions to stay a draft")
+	}
+	if count := strings.Count(logged.String(), empty.ID.Hex()); count != 1 {
+		t.Fatalf("expected the draft without questions to be logged once, got:\n%s", logged.String())
+	}
+
+	jobs.ApplyDueSchedules(context.Background(), closesAt)
+	if status(scheduled) != models.TryoutArchived {
+		t.Fatal("expected the tryout to be archived when it closes")
+	}
+	if status(empty) != models.TryoutDraft {
+		t.Fatal("expected a draft whose window has passed to stay a draft")
+	}
+}
@@ -0,0 +1,228 @@
+package routes
+
+import (
+	"net/http"
+	"quiz-platform/models"
+	"testing"
+	"time"
+)
+
+func TestEditingTakenTryoutCreatesDraftVersion(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Versioned"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	question := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Original question", IsTrue: true})
+
+	lockTryout(t, router, tryout.ID.Hex())
+	started := startAttempt(t, router, tryout.ID.Hex())
+	if started.Version != 1 {
+		t.Fatalf("expected the attempt to be on version 1, got %d", started.Version)
+	}
+
+	// Taken tryouts can still be edited; the changes go into a draft version
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/questions/"+question.ID.Hex(), models.QuestionInput{Text: "Edited question"}), http.StatusOK)
+	added := createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "Added question", IsTrue: true})
+	edited := sampleTryout("Versioned")
+	edited.Duration = 60
+	expectStatus(t, doRequest(t, router, http.MethodPut, base, edited), http.StatusOK)
+
+	var history models.VersionHistory
+	rec := doRequest(t, router, http.MethodGet, base+"/versions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &history)
+	if history.Current != 1 || !history.HasDraftChanges || len(history.Versions) != 1 || history.Versions[0].Submissions != 1 {
+		t.Fatalf("unexpected version history %+v", history)
+	}
+
+	// Takers keep getting the published version until the draft is published
+	rec = doRequestAs(t, router, student, http.MethodGet, base+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var taken []models.TakerQuestion
+	decode(t, rec, &taken)
+	if len(taken) != 1 || taken[0].Text != "Original question" {
+		t.Fatalf("expected the published question, got %+v", taken)
+	}
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base+"/questions/"+added.ID.Hex(), nil), http.StatusNotFound)
+	if attempt := startAttempt(t, router, tryout.ID.Hex()); attempt.Deadline.Sub(attempt.StartedAt) != 30*time.Minute {
+		t.Fatalf("expected the published duration, got %s", attempt.Deadline.Sub(attempt.StartedAt))
+	}
+
+	var diff models.VersionDiff
+	rec = doRequest(t, router, http.MethodGet, base+"/versions/diff", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &diff)
+	if diff.From != "1" || diff.To != models.DraftVersion || len(diff.Fields) != 1 || diff.Fields[0].Field != "duration" ||
+		len(diff.Added) != 1 || diff.Added[0].ID != added.ID || len(diff.Removed) != 0 || len(diff.Changed) != 1 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	if fields := diff.Changed[0].Fields; len(fields) != 2 || fields[0] != "isTrue" || fields[1] != "text" {
+		t.Fatalf("unexpected changed fields %v", fields)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, base+"/versions", nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var published models.TryoutVersion
+	decode(t, rec, &published)
+	if published.Number != 2 || published.QuestionCount != 2 || published.Duration != 60 {
+		t.Fatalf("unexpected version %+v", published)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/versions", nil), http.StatusConflict)
+
+	rec = doRequestAs(t, router, student, http.MethodGet, base+"/questions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &taken)
+	if len(taken) != 2 || taken[0].Text != "Edited question" {
+		t.Fatalf("expected the new version's questions, got %+v", taken)
+	}
+
+	// The attempt started on version 1 is graded against version 1
+	rec = doRequest(t, router, http.MethodPost, base+"/submissions", answersFor(started,
+		models.AnswerInput{QuestionID: question.ID.Hex(), Answer: boolPtr(true)}))
+	expectStatus(t, rec, http.StatusCreated)
+	var submission models.Submission
+	decode(t, rec, &submission)
+	if submission.Version != 1 || len(submission.Answers) != 1 || !submission.Answers[0].IsCorrect {
+		t.Fatalf("expected a correct answer graded against version 1, got %+v", submission)
+	}
+
+	var submissions []models.Submission
+	rec = doRequest(t, router, http.MethodGet, base+"/submissions?version=1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &submissions)
+	if len(submissions) != 2 {
+		t.Fatalf("expected two submissions on version 1, got %d", len(submissions))
+	}
+	rec = doRequest(t, router, http.MethodGet, base+"/submissions?version=2", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &submissions)
+	if len(submissions) != 0 {
+		t.Fatalf("expected no submissions on version 2, got %d", len(submissions))
+	}
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/submissions?version=latest", nil), http.StatusBadRequest)
+
+	// Published versions stay as they were
+	rec = doRequest(t, router, http.MethodGet, base+"/versions/1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var first models.TryoutVersion
+	decode(t, rec, &first)
+	if len(first.Questions) != 1 || first.Questions[0].Text != "Original question" || !first.Questions[0].IsTrue {
+		t.Fatalf("unexpected first version %+v", first)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, base+"/versions/diff?from=1&to=2", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &diff)
+	if diff.From != "1" || diff.To != "2" || len(diff.Added) != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
+
+func TestEditedDetailsWaitForNextVersion(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Published title"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "A question", IsTrue: true})
+	publishTryout(t, router, tryout.ID.Hex())
+
+	getTryout := func(token string) models.Tryout {
+		t.Helper()
+		rec := doRequestAs(t, router, token, http.MethodGet, base, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var got models.Tryout
+		decode(t, rec, &got)
+		return got
+	}
+
+	edited := sampleTryout("Edited title")
+	edited.Duration = 45
+	edited.Shuffle.Questions = true
+	rec := doRequest(t, router, http.MethodPut, base, edited)
+	expectStatus(t, rec, http.StatusOK)
+	var updated models.Tryout
+	decode(t, rec, &updated)
+	if updated.Title != "Published title" || updated.Draft == nil || updated.Draft.Title != "Edited title" || updated.Draft.Duration != 45 {
+		t.Fatalf("expected the details to go to the draft, got %+v", updated)
+	}
+
+	// Takers keep the published details and do not see the draft, while the
+	// unversioned shuffle settings apply right away
+	seen := getTryout(student)
+	if seen.Title != "Published title" || seen.Duration != 30 || seen.Draft != nil || !seen.Shuffle.Questions {
+		t.Fatalf("unexpected tryout for takers %+v", seen)
+	}
+	rec = doRequestAs(t, router, student, http.MethodGet, "/api/v1/tryouts", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var list models.TryoutList
+	decode(t, rec, &list)
+	if len(list.Items) != 1 || list.Items[0].Title != "Published title" || list.Items[0].Draft != nil {
+		t.Fatalf("unexpected listing for takers %+v", list.Items)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, base+"/versions", nil)
+	expectStatus(t, rec, http.StatusCreated)
+	if seen := getTryout(student); seen.Title != "Edited title" || seen.Duration != 45 {
+		t.Fatalf("expected the published edits, got %+v", seen)
+	}
+	if author := getTryout(testToken); author.Draft != nil {
+		t.Fatalf("expected publishing to clear the draft, got %+v", author.Draft)
+	}
+
+	// Editing the details back to the published ones leaves no draft
+	expectStatus(t, doRequest(t, router, http.MethodPut, base, sampleTryout("Changed again")), http.StatusOK)
+	edited.Shuffle.Questions = false
+	rec = doRequest(t, router, http.MethodPut, base, edited)
+	expectStatus(t, rec, http.StatusOK)
+	var reverted models.Tryout
+	decode(t, rec, &reverted)
+	if reverted.Draft != nil {
+		t.Fatalf("expected no draft, got %+v", reverted.Draft)
+	}
+}
+
+func TestVersionValidation(t *testing.T) {
+	router := newTestRouter(t)
+	student := registerAs(t, router, "student@example.com", models.RoleStudent)
+	tryout := createTryout(t, router, sampleTryout("Versions"))
+	base := "/api/v1/tryouts/" + tryout.ID.Hex()
+
+	// Drafts have no versions yet and cannot be published without questions
+	var history models.VersionHistory
+	rec := doRequest(t, router, http.MethodGet, base+"/versions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &history)
+	if history.Current != 0 || len(history.Versions) != 0 || !history.HasDraftChanges {
+		t.Fatalf("unexpected version history %+v", history)
+	}
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/versions", nil), http.StatusBadRequest)
+
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "q", IsTrue: true})
+	rec = doRequest(t, router, http.MethodPost, base+"/versions", nil)
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = doRequest(t, router, http.MethodGet, base, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var published models.Tryout
+	decode(t, rec, &published)
+	if published.Status != models.TryoutPublished || published.Version != 1 {
+		t.Fatalf("expected version 1 to be published, got %+v", published)
+	}
+
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/versions/2", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/versions/first", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/versions/diff?from=0", nil), http.StatusOK)
+	expectStatus(t, doRequest(t, router, http.MethodGet, base+"/versions/diff?to=-1", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/not-an-id/versions", nil), http.StatusBadRequest)
+	expectStatus(t, doRequest(t, router, http.MethodGet, "/api/v1/tryouts/"+missingID+"/versions", nil), http.StatusNotFound)
+
+	// Only those who grade the tryout see its versions, and only its managers publish them
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base+"/versions", nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodGet, base+"/versions/1", nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, student, http.MethodPost, base+"/versions", nil), http.StatusForbidden)
+	expectStatus(t, doRequestAs(t, router, "", http.MethodGet, base+"/versions", nil), http.StatusUnauthorized)
+
+	expectStatus(t, doRequest(t, router, http.MethodPut, base+"/status", models.StatusInput{Status: models.TryoutArchived}), http.StatusOK)
+	createQuestion(t, router, tryout.ID.Hex(), models.QuestionInput{Text: "late", IsTrue: true})
+	expectStatus(t, doRequest(t, router, http.MethodPost, base+"/versions", nil), http.StatusConflict)
+}
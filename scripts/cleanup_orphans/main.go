@@ -0,0 +1,59 @@
+// Command cleanup_orphans finds questions, attempts and submissions whose
+// tryout no longer exists, as left behind by tryout deletions before they
+// cascaded. It only reports them unless -delete is given.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"quiz-platform/config"
+	"quiz-platform/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	remove := flag.Bool("delete", false, "delete the orphaned documents instead of only reporting them")
+	flag.Parse()
+
+	config.ConnectStorage()
+	defer config.CloseDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	report, err := repository.Maintenance.FindOrphans(ctx)
+	if err != nil {
+		log.Fatal("Failed to find orphaned documents: ", err)
+	}
+
+	printOrphans("questions", report.Questions)
+	printOrphans("attempts", report.Attempts)
+	printOrphans("submissions", report.Submissions)
+	printOrphans("versions", report.Versions)
+
+	if report.Total() == 0 {
+		fmt.Println("No orphaned documents found")
+		return
+	}
+	if !*remove {
+		fmt.Printf("Found %d orphaned documents, run with -delete to remove them\n", report.Total())
+		return
+	}
+
+	if err := repository.Maintenance.DeleteOrphans(ctx, report); err != nil {
+		log.Fatal("Failed to delete orphaned documents: ", err)
+	}
+	fmt.Printf("Deleted %d orphaned documents\n", report.Total())
+}
+
+func printOrphans(collection string, ids []primitive.ObjectID) {
+	fmt.Printf("%s: %d orphaned\n", collection, len(ids))
+	for _, id := range ids {
+		fmt.Println("  ", id.Hex())
+	}
+}
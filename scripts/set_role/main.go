@@ -0,0 +1,43 @@
+// Command set_role changes the role of an account by email. Use it to make
+// the first admin, who can then manage roles through the API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"quiz-platform/config"
+	"quiz-platform/models"
+	"quiz-platform/repository"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the account")
+	role := flag.String("role", models.RoleAdmin, "role to give the account: admin, author, grader or student")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+	if !models.IsValidRole(*role) {
+		log.Fatalf("Unknown role %q", *role)
+	}
+
+	config.ConnectStorage()
+	defer config.CloseDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := repository.Users.GetByEmail(ctx, models.NormalizeEmail(*email))
+	if err != nil {
+		log.Fatalf("Failed to find account %s: %v", *email, err)
+	}
+	if err := repository.Users.SetRole(ctx, user.ID, *role, time.Now()); err != nil {
+		log.Fatal("Failed to set role: ", err)
+	}
+	fmt.Printf("%s is now %s\n", user.Email, *role)
+}